@@ -1,32 +1,132 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/ech00wv/SNserver/internal/config"
 	handler "github.com/ech00wv/SNserver/internal/handlers"
-	"github.com/joho/godotenv"
+	"github.com/ech00wv/SNserver/internal/logging"
+	service "github.com/ech00wv/SNserver/internal/services"
+	"github.com/ech00wv/SNserver/internal/tracing"
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
+const tracingFlushTimeout = 5 * time.Second
+
 func main() {
-	err := godotenv.Load("../../.env")
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("invalid configuration:\n%s", err)
+	}
+
+	logger, err := logging.New(os.Stderr, cfg.Log.Format, cfg.Log.Level)
+	if err != nil {
+		log.Fatalf("cannot create logger: %s", err)
+	}
+	// standard log package is redirected to the logger too
+	slog.SetDefault(logger)
+
+	err = run(cfg, logger)
+	if err != nil {
+		logger.Error("server failed", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("server stopped")
+}
+
+// run serves requests until SIGINT or SIGTERM, then reports not being ready for the drain timeout,
+// lets in-flight requests finish, stops background jobs and closes the database
+func run(cfg config.Config, logger *slog.Logger) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TracingExporter)
+	if err != nil {
+		return err
+	}
+	// deferred functions run in reverse order, so spans of the last requests and jobs are flushed after they stop
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+		defer cancel()
+		err := shutdownTracing(flushCtx)
+		if err != nil {
+			logger.Error("cannot flush traces", "error", err)
+		}
+	}()
+
+	apiCfg, err := config.InitializeApiConfig(ctx, cfg, logger)
 	if err != nil {
-		log.Fatalf("failed loading enviroment: %s", err)
+		return err
 	}
+	defer apiCfg.DB.Close()
+	serverCfg := cfg.Server
 
-	apiCfg := config.InitializeApiConfig()
+	// jobs are stopped after the server, so they stay alive for liveness checks while draining
+	jobsCtx, stopJobs := context.WithCancel(logging.NewContext(context.Background(), logger))
+	defer stopJobs()
+	jobs := &service.Jobs{}
+	repos := apiCfg.Repositories
+	userServ := service.NewUserService(apiCfg, repos.Users, repos.LoginAttempts, repos.RefreshTokens)
+	jobs.Start(jobsCtx, "account deletion", service.AccountDeletionJobInterval, userServ.DeleteScheduledUsers)
+	jobs.Start(jobsCtx, "login throttle cleanup", service.LoginThrottleCleanupJobInterval, userServ.DeleteIdleLoginThrottles)
+	// data exports are kept in the database only
+	if !cfg.DemoMode {
+		exportServ := service.NewDataExportService(apiCfg, apiCfg.Queries, repos)
+		jobs.Start(jobsCtx, "data export", service.DataExportJobInterval, exportServ.ProcessExports)
+	}
+	jobs.Start(jobsCtx, "content filter reload", apiCfg.ContentFilterReloadInterval, apiCfg.ContentFilter.Reload)
+	jobs.Start(jobsCtx, "rate limit cleanup", service.RateLimitCleanupJobInterval, apiCfg.RateLimiter.Cleanup)
+	apiCfg.Health.AddLiveness("background jobs", jobs.Check)
 
 	serveMux := handler.InitializeMux(apiCfg)
 
 	httpServer := http.Server{
-		Addr:    ":8080",
-		Handler: serveMux,
+		Addr:           serverCfg.Addr,
+		Handler:        serveMux,
+		ReadTimeout:    serverCfg.ReadTimeout,
+		WriteTimeout:   serverCfg.WriteTimeout,
+		IdleTimeout:    serverCfg.IdleTimeout,
+		MaxHeaderBytes: serverCfg.MaxHeaderBytes,
 	}
-	err = httpServer.ListenAndServe()
-	if err != nil {
-		log.Fatalf("Server failed: %s", err)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("listening", "addr", serverCfg.Addr)
+		serverErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err = <-serverErr:
+	case <-ctx.Done():
+		stop()
+		apiCfg.Health.SetDraining()
+		if serverCfg.DrainTimeout > 0 {
+			logger.Info("draining before shutdown", "timeout", serverCfg.DrainTimeout.String())
+			time.Sleep(serverCfg.DrainTimeout)
+		}
+		logger.Info("shutting down, waiting for in-flight requests", "timeout", serverCfg.ShutdownTimeout.String())
 	}
 
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverCfg.ShutdownTimeout)
+	defer cancel()
+	shutdownErr := httpServer.Shutdown(shutdownCtx)
+	if shutdownErr != nil {
+		logger.Error("cannot shut down gracefully", "error", shutdownErr)
+	}
+
+	stopJobs()
+	jobs.Wait()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
@@ -15,238 +15,179 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/admin/reset": {
-            "post": {
-                "description": "Reset app and clear all the users (hence messages, etc.)",
-                "summary": "Reset app",
-                "responses": {
-                    "200": {
-                        "description": "app successfully resetted!",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "403": {
-                        "description": "Forbidden"
-                    },
-                    "500": {
-                        "description": "error in deleting users",
-                        "schema": {
-                            "$ref": "#/definitions/handler.responseError"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/login": {
+        "/admin/content-filter/reload": {
             "post": {
-                "description": "Login user with email and password",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "summary": "Login user",
+                "description": "Reload content filter rules from the file or the database without waiting for periodic reload",
+                "summary": "Reload content filter",
                 "parameters": [
                     {
-                        "description": "User's email",
-                        "name": "email",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    {
-                        "description": "User's password",
-                        "name": "password",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "string"
-                        }
+                        "type": "string",
+                        "description": "Admin's access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "User's data",
-                        "schema": {
-                            "$ref": "#/definitions/models.UserResponse"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
-                    "400": {
-                        "description": "Something is wrong in provided information",
+                    "401": {
+                        "description": "User is unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     },
-                    "401": {
-                        "description": "User is unauthorized",
+                    "403": {
+                        "description": "User is not an admin",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Rules cannot be loaded, previous rules are kept",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     }
                 }
             }
         },
-        "/api/messages": {
+        "/admin/content-filter/rules": {
             "get": {
-                "description": "Get all messages (either all of them or from specific author)",
+                "description": "Get content filter rules stored in the database",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get all messages",
+                "summary": "Content filter rules",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "author_id",
-                        "name": "author_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Sorting order ('asc', 'desc' or nothing)",
-                        "name": "sort",
-                        "in": "query"
+                        "description": "Admin's access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of messages",
+                        "description": "List of rules",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.MessageResponse"
+                                "$ref": "#/definitions/models.ContentFilterRuleResponse"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Something is wrong in provided information",
+                    "401": {
+                        "description": "User is unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     },
-                    "404": {
-                        "description": "Messages not found",
+                    "403": {
+                        "description": "User is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Rules are loaded from a file",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Create a message for given user",
+                "description": "Add word or phrase to the content filter, the rule applies to new messages right away",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Message creation",
+                "summary": "Create content filter rule",
                 "parameters": [
-                    {
-                        "description": "Message content",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
                     {
                         "type": "string",
-                        "description": "Access token",
+                        "description": "Admin's access token",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "description": "Word or phrase and action (mask, reject or flag)",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ContentFilterRuleRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Created message information",
+                        "description": "Created rule",
                         "schema": {
-                            "$ref": "#/definitions/models.MessageResponse"
+                            "$ref": "#/definitions/models.ContentFilterRuleResponse"
                         }
                     },
                     "400": {
                         "description": "Something is wrong in provided information",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     },
                     "401": {
                         "description": "User is unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
-                        "schema": {
-                            "$ref": "#/definitions/handler.responseError"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/messages/{messageID}": {
-            "get": {
-                "description": "Get one specific message by it's id",
-                "produces": [
-                    "application/json"
-                ],
-                "summary": "Get message",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "messageID",
-                        "name": "messageID",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Message content",
-                        "schema": {
-                            "$ref": "#/definitions/models.MessageResponse"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     },
-                    "400": {
-                        "description": "Something is wrong in provided information",
+                    "403": {
+                        "description": "User is not an admin",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     },
-                    "404": {
-                        "description": "Message not found",
+                    "409": {
+                        "description": "Rules are loaded from a file or the term already has a rule",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/content-filter/rules/{ruleID}": {
             "delete": {
-                "description": "Delete specific message by it's id",
-                "summary": "Delete message",
+                "description": "Remove rule from the content filter",
+                "summary": "Delete content filter rule",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID of message that needs to be deleted",
-                        "name": "messageID",
+                        "description": "Admin's access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the rule",
+                        "name": "ruleID",
                         "in": "path",
                         "required": true
                     }
@@ -258,315 +199,3161 @@ const docTemplate = `{
                     "400": {
                         "description": "Something is wrong in provided information",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     },
                     "401": {
                         "description": "User is unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     },
                     "403": {
-                        "description": "User cannot delete this message",
+                        "description": "User is not an admin",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     },
                     "404": {
-                        "description": "Message is not found",
+                        "description": "Rule not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Rules are loaded from a file",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     }
                 }
             }
         },
-        "/api/payment/webhook": {
-            "post": {
-                "description": "Delete specific message by it's id",
-                "summary": "Delete message",
+        "/admin/login-attempts": {
+            "get": {
+                "description": "Get latest login attempts (either all of them or for specific email)",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Login attempts",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID of message that needs to be deleted",
-                        "name": "messageID",
-                        "in": "path",
+                        "description": "Admin's access token",
+                        "name": "Authorization",
+                        "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "email",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of attempts (100 by default)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "List of login attempts",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LoginAttemptResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
                     },
                     "401": {
-                        "description": "Wrong api key",
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "User is not an admin",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     }
                 }
             }
         },
-        "/api/refresh": {
-            "post": {
-                "description": "Refresh access token with user's refresh token",
+        "/admin/metrics": {
+            "get": {
+                "description": "Returns an html with visitors counter and database connection pool statistics",
+                "produces": [
+                    "text/html"
+                ],
+                "summary": "Fileservers metrics",
+                "responses": {
+                    "200": {
+                        "description": "html page with metrics",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/moderation-actions": {
+            "get": {
+                "description": "Get moderator decisions, either latest ones or all decisions for specific report",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Refresh access token",
+                "summary": "Moderation audit trail",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Refresh token",
+                        "description": "Admin's access token",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the report",
+                        "name": "report_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of actions (50 by default)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "New access token",
+                        "description": "List of moderation actions",
                         "schema": {
-                            "$ref": "#/definitions/handler.jsonTokenResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ModerationActionResponse"
+                            }
                         }
                     },
                     "400": {
                         "description": "Something is wrong in provided information",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     },
                     "401": {
                         "description": "User is unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "User is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     }
                 }
             }
         },
-        "/api/revoke": {
-            "post": {
-                "description": "Revoke specific refresh token",
-                "summary": "Revoke refresh token",
+        "/admin/reports": {
+            "get": {
+                "description": "Get reports with given status, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Moderation queue",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Refresh token",
+                        "description": "Admin's access token",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
-                    }
-                ],
+                    },
+                    {
+                        "type": "string",
+                        "description": "open (default), claimed or resolved",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of reports (50 by default)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "List of reports",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ReportResponse"
+                            }
+                        }
                     },
                     "400": {
                         "description": "Something is wrong in provided information",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "User is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     }
                 }
             }
         },
-        "/api/users": {
-            "put": {
-                "description": "Update specific user's credentials by it's access token",
+        "/admin/reports/{reportID}/claim": {
+            "post": {
+                "description": "Assign open report to the moderator",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Update user's credentials",
+                "summary": "Claim report",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Access token",
+                        "description": "Admin's access token",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
                     },
                     {
-                        "description": "User's new email",
-                        "name": "email",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "ID of the report",
+                        "name": "reportID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Claimed report",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.ReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "User is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Report not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Report is already claimed or resolved",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
+                    }
+                }
+            }
+        },
+        "/admin/reports/{reportID}/resolve": {
+            "post": {
+                "description": "Resolve report claimed by the moderator: dismiss it, delete reported message or suspend reported user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Resolve report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin's access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     },
                     {
-                        "description": "User's new password",
-                        "name": "password",
+                        "type": "string",
+                        "description": "ID of the report",
+                        "name": "reportID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Action (dismiss, delete_message or suspend_user), note and suspension length in days (7 by default)",
+                        "name": "resolution",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.ResolveReportRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User with updated credentials",
+                        "description": "Resolved report",
                         "schema": {
-                            "$ref": "#/definitions/models.UserResponse"
+                            "$ref": "#/definitions/models.ReportResponse"
                         }
                     },
                     "400": {
                         "description": "Something is wrong in provided information",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     },
                     "401": {
                         "description": "User is unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "User is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Report not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Report is not claimed by the moderator or action cannot be applied",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/reset": {
             "post": {
-                "description": "Create a user with provided email and password",
+                "description": "Reset app and clear all the users (hence messages, etc.)",
+                "summary": "Reset app",
+                "responses": {
+                    "200": {
+                        "description": "app successfully resetted!",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Platform is not dev",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "error in deleting users",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{userID}/status": {
+            "put": {
+                "description": "Suspend, shadow-ban, ban or restore user's account. Shadow-banned user's messages are visible only to the user",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "User creation",
+                "summary": "Change account status",
                 "parameters": [
                     {
-                        "description": "User's email",
-                        "name": "email",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "string"
-                        }
+                        "type": "string",
+                        "description": "Admin's access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     },
                     {
-                        "description": "User's password",
-                        "name": "password",
+                        "type": "string",
+                        "description": "ID of the user",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Status (active, suspended, shadow_banned or banned), reason and suspension length in days (7 by default)",
+                        "name": "status",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.UserStatusRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created user's information",
+                    "200": {
+                        "description": "New account status",
                         "schema": {
-                            "$ref": "#/definitions/models.UserResponse"
+                            "$ref": "#/definitions/models.UserStatusResponse"
                         }
                     },
                     "400": {
-                        "description": "User credentials is incorrect",
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "User is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Admins cannot be restricted",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handler.responseError"
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     }
                 }
             }
         },
-        "/metrics": {
+        "/api/auth/identities": {
             "get": {
-                "description": "Returns an html with visitors counter",
+                "description": "Get external identities linked to the user",
                 "produces": [
-                    "text/html"
+                    "application/json"
+                ],
+                "summary": "Linked identities",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
                 ],
-                "summary": "Fileservers metrics",
                 "responses": {
                     "200": {
-                        "description": "html page with metrics",
+                        "description": "List of identities",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UserIdentityResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     }
                 }
             }
         },
-        "/status": {
+        "/api/auth/{provider}/callback": {
             "get": {
-                "description": "Returns just an \"OK\"",
+                "description": "Callback for OpenID Connect provider. Logs the user in (creating an account if needed) or links the identity",
                 "produces": [
-                    "text/html"
+                    "application/json"
+                ],
+                "summary": "Finish social login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
                 ],
-                "summary": "Checking server status",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "User's data (with tokens when logging in)",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "ID token is not valid",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Provider is not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Identity or email is already used by another user",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "502": {
+                        "description": "Provider is unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
                         }
                     }
                 }
             }
-        }
-    },
+        },
+        "/api/auth/{provider}/start": {
+            "get": {
+                "description": "Redirect to OpenID Connect provider. If access token is provided, the provider's identity will be linked to the user",
+                "summary": "Start social login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token (for account linking)",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Provider is not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "502": {
+                        "description": "Provider is unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/blocks": {
+            "get": {
+                "description": "Get users blocked by the user",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get blocked users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Blocked users",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UserRelationResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Action requires user's own access token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/blocks/{userID}": {
+            "put": {
+                "description": "Block the user. Blocked user and the blocker do not see each other's messages",
+                "summary": "Block user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Token does not have required scope",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove block of the user",
+                "summary": "Unblock user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Token does not have required scope",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/exports/{exportID}/download": {
+            "get": {
+                "description": "Download zip archive of completed data export by signed url from export status",
+                "produces": [
+                    "application/zip"
+                ],
+                "summary": "Download data export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the export",
+                        "name": "exportID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Link expiration time",
+                        "name": "expires",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Link signature",
+                        "name": "signature",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Zip archive",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Link is not valid or has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Export not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/login": {
+            "post": {
+                "description": "Login user with email and password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Login user",
+                "parameters": [
+                    {
+                        "description": "User's email",
+                        "name": "email",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "User's password",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User's data",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Account is suspended or banned",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests or failed login attempts",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/messages": {
+            "get": {
+                "description": "Get all messages (either all of them or from specific author). Messages of blocked and muted users are left out for authenticated viewer",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get all messages",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "author_id",
+                        "name": "author_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sorting order ('asc', 'desc' or nothing)",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of messages",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.MessageResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Access token is not valid",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Token does not have messages:read scope",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Messages not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a message for given user. Words from content filter are masked, messages with rejected words are not created and messages with flagged words are sent to moderation queue",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Message creation",
+                "parameters": [
+                    {
+                        "description": "Message content",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created message information",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Message is too long or contains prohibited content",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Token does not have required scope or account is suspended or banned",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/messages/{messageID}": {
+            "get": {
+                "description": "Get one specific message by it's id. Messages are not found between users who blocked each other",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "messageID",
+                        "name": "messageID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Message content",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Access token is not valid",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Token does not have messages:read scope",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Message not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete specific message by it's id",
+                "summary": "Delete message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of message that needs to be deleted",
+                        "name": "messageID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "User cannot delete this message",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Message is not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/messages/{messageID}/report": {
+            "post": {
+                "description": "Send message to moderation queue",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Report message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the message",
+                        "name": "messageID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason (spam, harassment, hate_speech, violence, sexual_content or other) and details",
+                        "name": "report",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created report",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Token does not have required scope",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Message not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Message is already reported",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mutes": {
+            "get": {
+                "description": "Get users muted by the user",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get muted users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Muted users",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UserRelationResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Action requires user's own access token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mutes/{userID}": {
+            "put": {
+                "description": "Mute the user. Messages of muted user are left out of the user's message lists",
+                "summary": "Mute user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Token does not have required scope",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove mute of the user",
+                "summary": "Unmute user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Token does not have required scope",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/oauth/authorize": {
+            "get": {
+                "description": "Validate authorization request and redirect user to the consent screen",
+                "summary": "OAuth authorization endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must be 'code'",
+                        "name": "response_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client's id",
+                        "name": "client_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "One of client's redirect uris",
+                        "name": "redirect_uri",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space separated scopes",
+                        "name": "scope",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "State",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE code challenge",
+                        "name": "code_challenge",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Must be 'S256'",
+                        "name": "code_challenge_method",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Approve or deny authorization request on behalf of the user, returns where the user should be redirected",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "OAuth consent",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Authorization request and user's decision",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.OAuthAuthorizeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Client's redirect uri with code or error",
+                        "schema": {
+                            "$ref": "#/definitions/models.OAuthAuthorizeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/oauth/clients": {
+            "get": {
+                "description": "Get OAuth clients registered by the user",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "User's OAuth clients",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of clients",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OAuthClientResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Register third-party application that can act on behalf of users. Secret is returned only once and only for confidential clients",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Register OAuth client",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Client's name, redirect uris and scopes",
+                        "name": "client",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.OAuthClientRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Registered client",
+                        "schema": {
+                            "$ref": "#/definitions/models.OAuthClientResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/oauth/clients/{clientID}": {
+            "get": {
+                "description": "Get public information about OAuth client",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get OAuth client",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "clientID",
+                        "name": "clientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Client information",
+                        "schema": {
+                            "$ref": "#/definitions/models.OAuthClientResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Client not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete OAuth client registered by the user, access tokens issued to the client stop working",
+                "summary": "Delete OAuth client",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "clientID",
+                        "name": "clientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Client not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/oauth/login": {
+            "post": {
+                "description": "Login user with email and password on the OAuth consent page, only an access token is issued",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Login on the consent page",
+                "parameters": [
+                    {
+                        "description": "User's email and password",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User's data with access token",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Account is suspended or banned",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests or failed login attempts",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/oauth/token": {
+            "post": {
+                "description": "Exchange authorization code for scoped access token. Confidential clients authenticate with HTTP Basic or client_secret parameter",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "OAuth token endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must be 'authorization_code'",
+                        "name": "grant_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Redirect uri used in authorization request",
+                        "name": "redirect_uri",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client's id",
+                        "name": "client_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client's secret",
+                        "name": "client_secret",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE code verifier",
+                        "name": "code_verifier",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Access token",
+                        "schema": {
+                            "$ref": "#/definitions/models.OAuthTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.OAuthErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Client authentication failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.OAuthErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.OAuthErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/payment/webhook": {
+            "post": {
+                "description": "Delete specific message by it's id",
+                "summary": "Delete message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of message that needs to be deleted",
+                        "name": "messageID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Wrong api key",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/refresh": {
+            "post": {
+                "description": "Refresh access token with user's refresh token",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Refresh access token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Refresh token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New access token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.jsonTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Account is suspended or banned",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/revoke": {
+            "post": {
+                "description": "Revoke specific refresh token",
+                "summary": "Revoke refresh token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Refresh token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tokens": {
+            "get": {
+                "description": "Get user's personal access tokens (without token values)",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Personal access tokens",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of tokens",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PersonalTokenResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create long-lived scoped token for scripts and bots. Token value is returned only once",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create personal access token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Token's name, scopes and lifetime in days (0 means never expires)",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PersonalTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created token",
+                        "schema": {
+                            "$ref": "#/definitions/models.PersonalTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Too many tokens",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tokens/{tokenID}": {
+            "delete": {
+                "description": "Revoke user's personal access token",
+                "summary": "Delete personal access token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "tokenID",
+                        "name": "tokenID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Token not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users": {
+            "put": {
+                "description": "Update user's email and password after current password confirmation (accounts created through social login have no password and skip it). Requires user's own access token, third-party and personal tokens are rejected",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update user's credentials",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "User's new email and password and current password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User with updated credentials",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized or current password is incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Action requires user's own access token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "User with this email already exists",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a user with provided email and password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "User creation",
+                "parameters": [
+                    {
+                        "description": "User's email",
+                        "name": "email",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "User's password",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created user's information",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "User credentials is incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "User with this email already exists",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/me": {
+            "delete": {
+                "description": "Schedule deletion of the user's account after password confirmation (accounts created through social login have no password and skip it). Logging in during the grace period cancels the deletion",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "User's current password, empty for accounts created through social login",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DeleteAccountRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Time when the account will be deleted",
+                        "schema": {
+                            "$ref": "#/definitions/models.AccountDeletionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized or password is incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Action requires user's own access token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/me/export": {
+            "post": {
+                "description": "Start building zip archive with user's profile, messages, sessions and payment history. If an export is already in progress, it is returned",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Request data export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Export status",
+                        "schema": {
+                            "$ref": "#/definitions/models.DataExportResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Action requires user's own access token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/me/export/{exportID}": {
+            "get": {
+                "description": "Get status of user's data export. Completed export contains signed download url valid for an hour",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get data export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the export",
+                        "name": "exportID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Export status",
+                        "schema": {
+                            "$ref": "#/definitions/models.DataExportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Action requires user's own access token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Export not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{userID}/report": {
+            "post": {
+                "description": "Send user to moderation queue",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Report user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason (spam, harassment, hate_speech, violence, sexual_content or other) and details",
+                        "name": "report",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created report",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Something is wrong in provided information",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "User is unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Token does not have required scope",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "User is already reported",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Checks that the process works (background jobs are running), the process should be restarted if it fails",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Liveness check",
+                "responses": {
+                    "200": {
+                        "description": "Process is alive",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Some of the checks failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "description": "Returns request counts, latencies and in-flight requests per route, domain counters (messages, logins, payment webhooks), database pool and Go runtime metrics in Prometheus exposition format",
+                "produces": [
+                    "text/plain"
+                ],
+                "summary": "Prometheus metrics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer METRICS_TOKEN, required when the token is configured",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "metrics in Prometheus exposition format",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Metrics token is missing or wrong",
+                        "schema": {
+                            "$ref": "#/definitions/handler.problemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Checks that the server can handle requests (database is reachable and migrated), the server should not get traffic if it fails or while it is draining on shutdown",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Readiness check",
+                "responses": {
+                    "200": {
+                        "description": "Server is ready",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Some of the checks failed or the server is draining",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/status": {
+            "get": {
+                "description": "Returns just an \"OK\"",
+                "produces": [
+                    "text/html"
+                ],
+                "summary": "Checking server status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
     "definitions": {
+        "auth.PasswordViolation": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string"
+                }
+            }
+        },
         "handler.jsonTokenResponse": {
             "type": "object",
             "properties": {
-                "token": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.problemDetails": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "violations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/auth.PasswordViolation"
+                    }
+                }
+            }
+        },
+        "models.AccountDeletionResponse": {
+            "type": "object",
+            "properties": {
+                "deletion_scheduled_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ContentFilterRuleRequest": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "term": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ContentFilterRuleResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "term": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DataExportResponse": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "download_url": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DeleteAccountRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.HealthCheckResponse": {
+            "type": "object",
+            "properties": {
+                "duration_ms": {
+                    "type": "number"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.HealthCheckResponse"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LoginAttemptResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "succeeded": {
+                    "type": "boolean"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MessageResponse": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ModerationActionResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "message_id": {
+                    "type": "string"
+                },
+                "moderator_id": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "report_id": {
+                    "type": "string"
+                },
+                "target_user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OAuthAuthorizeRequest": {
+            "type": "object",
+            "properties": {
+                "approve": {
+                    "type": "boolean"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "code_challenge": {
+                    "type": "string"
+                },
+                "code_challenge_method": {
+                    "type": "string"
+                },
+                "redirect_uri": {
+                    "type": "string"
+                },
+                "response_type": {
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OAuthAuthorizeResponse": {
+            "type": "object",
+            "properties": {
+                "redirect_uri": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OAuthClientRequest": {
+            "type": "object",
+            "properties": {
+                "confidential": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "redirect_uris": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.OAuthClientResponse": {
+            "type": "object",
+            "properties": {
+                "confidential": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "redirect_uris": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
                     "type": "string"
                 }
             }
         },
-        "handler.responseError": {
+        "models.OAuthErrorResponse": {
             "type": "object",
             "properties": {
                 "error": {
                     "type": "string"
+                },
+                "error_description": {
+                    "type": "string"
                 }
             }
         },
-        "models.MessageResponse": {
+        "models.OAuthTokenResponse": {
             "type": "object",
             "properties": {
-                "body": {
+                "access_token": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "type": "integer"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PersonalTokenRequest": {
+            "type": "object",
+            "properties": {
+                "expires_in_days": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.PersonalTokenResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ReportRequest": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ReportResponse": {
+            "type": "object",
+            "properties": {
+                "claimed_at": {
+                    "type": "string"
+                },
+                "claimed_by": {
                     "type": "string"
                 },
                 "created_at": {
                     "type": "string"
                 },
+                "details": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
-                "updated_at": {
+                "message_body": {
+                    "type": "string"
+                },
+                "message_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "reported_user_id": {
+                    "type": "string"
+                },
+                "reporter_id": {
+                    "type": "string"
+                },
+                "resolution": {
+                    "type": "string"
+                },
+                "resolved_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "target_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ResolveReportRequest": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "suspend_days": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UpdateUserRequest": {
+            "type": "object",
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UserIdentityResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UserRelationResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
                     "type": "string"
                 },
                 "user_id": {
@@ -574,6 +3361,17 @@ const docTemplate = `{
                 }
             }
         },
+        "models.UserRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
         "models.UserResponse": {
             "type": "object",
             "properties": {
@@ -599,6 +3397,40 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "models.UserStatusRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "suspend_days": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UserStatusResponse": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "suspended_until": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
@@ -2,58 +2,54 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
-	"unicode"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// PersonalAccessTokenPrefix tells personal access tokens apart from JWTs in the Authorization header
+const PersonalAccessTokenPrefix = "snp_"
+
 const (
-	minPasswordLength = 4
-	minDigitCount     = 2
+	ScopeMessagesRead  = "messages:read"
+	ScopeMessagesWrite = "messages:write"
+	// ScopeProfileWrite covers profile settings other than credentials (e.g. blocks and mutes),
+	// email and password are changed only with the user's own session
+	ScopeProfileWrite = "profile:write"
 )
 
-func HashPassword(password string) (string, error) {
-	err := checkPassword(password)
-	if err != nil {
-		return "", err
-	}
-	hashedPass, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(hashedPass), err
-}
+// Scopes lists every scope third-party clients can request
+var Scopes = []string{ScopeMessagesRead, ScopeMessagesWrite, ScopeProfileWrite}
 
-func CheckPasswordHash(password, hash string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+// AccessClaims are claims of access tokens. Tokens issued to third-party clients
+// carry the client's id and granted scopes, first-party tokens have neither.
+type AccessClaims struct {
+	jwt.RegisteredClaims
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
 }
 
-func checkPassword(password string) error {
-	if len([]rune(password)) < minPasswordLength {
-		return fmt.Errorf("password must be at least 4 characters")
-	}
-	digitCount := 0
-	for _, char := range password {
-		if unicode.IsDigit(char) {
-			digitCount++
-		}
-	}
-	if digitCount < minDigitCount {
-		return fmt.Errorf("password must have at least 2 digits")
-	}
-	return nil
+func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+	return MakeClientJWT(userID, "", nil, tokenSecret, expiresIn)
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    "SNserver",
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		ExpiresAt: jwt.NewNumericDate(time.Time.Add(time.Now(), expiresIn)),
-		Subject:   userID.String(),
+// MakeClientJWT makes access token that a third-party client can use on behalf of the user within granted scopes
+func MakeClientJWT(userID uuid.UUID, clientID string, scopes []string, tokenSecret string, expiresIn time.Duration) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "SNserver",
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Time.Add(time.Now(), expiresIn)),
+			Subject:   userID.String(),
+		},
+		ClientID: clientID,
+		Scope:    strings.Join(scopes, " "),
 	})
 	signedToken, err := token.SignedString([]byte(tokenSecret))
 	if err != nil {
@@ -62,8 +58,21 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 	return signedToken, nil
 }
 
+// ValidateJWT accepts only first-party access tokens
 func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
+	claims, err := ParseAccessToken(tokenString, tokenSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if claims.ClientID != "" {
+		return uuid.Nil, fmt.Errorf("token issued to third-party client is not allowed here")
+	}
+	return ParseSubject(claims)
+}
+
+func ParseAccessToken(tokenString, tokenSecret string) (*AccessClaims, error) {
+	claims := &AccessClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unknown signing method: %v", token.Method.Alg())
 		}
@@ -71,10 +80,13 @@ func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
 	})
 
 	if err != nil || !token.Valid {
-		return uuid.Nil, fmt.Errorf("token is not valid")
+		return nil, fmt.Errorf("token is not valid")
 	}
+	return claims, nil
+}
 
-	subjectId, err := token.Claims.GetSubject()
+func ParseSubject(claims *AccessClaims) (uuid.UUID, error) {
+	subjectId, err := claims.GetSubject()
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("subject is unknown")
 	}
@@ -102,12 +114,30 @@ func MakeRefreshToken() (string, error) {
 	randomData := make([]byte, 32)
 	_, err := rand.Read(randomData)
 	if err != nil {
-		return "", fmt.Errorf("error in randomization: %s", err)
+		return "", fmt.Errorf("error in randomization: %w", err)
 	}
 	token := hex.EncodeToString(randomData)
 	return token, nil
 }
 
+func MakePersonalAccessToken() (string, error) {
+	token, err := MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	return PersonalAccessTokenPrefix + token, nil
+}
+
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// HashToken returns hex encoded SHA-256 of random token, so the token itself is never stored
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
 func GetApiKey(headers http.Header) (string, error) {
 	authContent := headers.Get("Authorization")
 	if authContent == "" {
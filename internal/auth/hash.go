@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// Argon2Params are parameters of new password hashes. Hashes are stored in PHC string format
+// ($argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>), so every hash
+// keeps parameters it was made with and can be verified after parameters change.
+type Argon2Params struct {
+	MemoryKiB   uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params follow OWASP recommendation for Argon2id
+func DefaultArgon2Params() Argon2Params {
+	return Argon2Params{
+		MemoryKiB:   19 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// HashPassword hashes password that was already checked by PasswordPolicy.Validate
+func HashPassword(password string, params Argon2Params) (string, error) {
+	salt := make([]byte, params.SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", fmt.Errorf("error in randomization: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.MemoryKiB, params.Parallelism, params.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		params.MemoryKiB,
+		params.Iterations,
+		params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// CheckPasswordHash verifies password against Argon2id hash or legacy bcrypt hash
+func CheckPasswordHash(password, hash string) error {
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	}
+
+	params, salt, key, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return err
+	}
+	otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.MemoryKiB, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return fmt.Errorf("password does not match")
+	}
+	return nil
+}
+
+// NeedsRehash reports whether the hash was made with bcrypt or with other Argon2id parameters
+func NeedsRehash(hash string, params Argon2Params) bool {
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		return true
+	}
+	hashParams, salt, _, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return true
+	}
+	return hashParams.MemoryKiB != params.MemoryKiB ||
+		hashParams.Iterations != params.Iterations ||
+		hashParams.Parallelism != params.Parallelism ||
+		hashParams.KeyLength != params.KeyLength ||
+		uint32(len(salt)) != params.SaltLength
+}
+
+// dummyHashes keeps a fixed hash per parameters, so simulated checks cost as much as real ones
+// with whatever parameters the caller uses
+var dummyHashes sync.Map
+
+// SimulatePasswordCheck verifies password against a fixed hash so that logins for unknown emails
+// (and accounts without a password) take as long as logins with a wrong password.
+func SimulatePasswordCheck(password string, params Argon2Params) {
+	dummyHash, ok := dummyHashes.Load(params)
+	if !ok {
+		newHash, _ := HashPassword("dummy-password-00", params)
+		dummyHash, _ = dummyHashes.LoadOrStore(params, newHash)
+	}
+	CheckPasswordHash(password, dummyHash.(string))
+}
+
+func decodeArgon2Hash(hash string) (Argon2Params, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return Argon2Params{}, nil, nil, fmt.Errorf("wrong argon2id hash format")
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return Argon2Params{}, nil, nil, fmt.Errorf("unsupported argon2 version")
+	}
+
+	var params Argon2Params
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.MemoryKiB, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("wrong argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("wrong argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("wrong argon2id key: %w", err)
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2Params keep tests fast, the format does not depend on the cost
+var testArgon2Params = Argon2Params{MemoryKiB: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("Correct-horse-98battery", testArgon2Params)
+	if err != nil {
+		t.Fatalf("cannot hash password: %s", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("hash is not in PHC format: %s", hash)
+	}
+
+	otherHash, err := HashPassword("Correct-horse-98battery", testArgon2Params)
+	if err != nil {
+		t.Fatalf("cannot hash password: %s", err)
+	}
+	if otherHash == hash {
+		t.Fatal("hashes of the same password must have different salts")
+	}
+
+	if err := CheckPasswordHash("Correct-horse-98battery", hash); err != nil {
+		t.Fatalf("password does not match its hash: %s", err)
+	}
+	if err := CheckPasswordHash("Correct-horse-98battery!", hash); err == nil {
+		t.Fatal("wrong password matches the hash")
+	}
+}
+
+func TestCheckPasswordHashFormats(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("legacy-42"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("cannot make bcrypt hash: %s", err)
+	}
+	if err := CheckPasswordHash("legacy-42", string(bcryptHash)); err != nil {
+		t.Fatalf("legacy bcrypt hash was not verified: %s", err)
+	}
+
+	invalidHashes := []string{
+		"$argon2id$v=19$m=64,t=1,p=1$salt",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=19$m=64$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$not base64$a2V5",
+	}
+	for _, hash := range invalidHashes {
+		if err := CheckPasswordHash("password", hash); err == nil {
+			t.Fatalf("invalid hash %q was accepted", hash)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	hash, err := HashPassword("Correct-horse-98battery", testArgon2Params)
+	if err != nil {
+		t.Fatalf("cannot hash password: %s", err)
+	}
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("legacy-42"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("cannot make bcrypt hash: %s", err)
+	}
+
+	strongerParams := testArgon2Params
+	strongerParams.Iterations = 2
+	longerSalt := testArgon2Params
+	longerSalt.SaltLength = 32
+
+	tests := []struct {
+		name     string
+		hash     string
+		params   Argon2Params
+		expected bool
+	}{
+		{"same parameters", hash, testArgon2Params, false},
+		{"more iterations", hash, strongerParams, true},
+		{"longer salt", hash, longerSalt, true},
+		{"bcrypt hash", string(bcryptHash), testArgon2Params, true},
+		{"invalid hash", "$argon2id$broken", testArgon2Params, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if NeedsRehash(test.hash, test.params) != test.expected {
+				t.Fatalf("expected %t", test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestMakePKCEChallenge(t *testing.T) {
+	// example from RFC 7636, appendix B
+	challenge := MakePKCEChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if challenge != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Fatalf("unexpected challenge %s", challenge)
+	}
+}
+
+func TestMakeOIDCSecret(t *testing.T) {
+	secret, err := MakeOIDCSecret()
+	if err != nil {
+		t.Fatalf("cannot make secret: %s", err)
+	}
+	// PKCE verifier must be 43 to 128 unreserved characters
+	decoded, err := base64.RawURLEncoding.DecodeString(secret)
+	if err != nil || len(secret) != 43 || len(decoded) != 32 {
+		t.Fatalf("secret %q is not 32 url-safe encoded bytes: %v", secret, err)
+	}
+
+	other, err := MakeOIDCSecret()
+	if err != nil {
+		t.Fatalf("cannot make secret: %s", err)
+	}
+	if other == secret {
+		t.Fatal("secrets are not random")
+	}
+}
+
+func TestCheckOIDCState(t *testing.T) {
+	stateHash := HashOIDCState("state")
+	if stateHash == "state" {
+		t.Fatal("state is stored as is")
+	}
+
+	tests := []struct {
+		name     string
+		state    string
+		hash     string
+		expected bool
+	}{
+		{"matching state", "state", stateHash, true},
+		{"another state", "other", stateHash, false},
+		{"state instead of hash", "state", "state", false},
+		{"missing cookie", "state", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if CheckOIDCState(test.state, test.hash) != test.expected {
+				t.Fatalf("expected %t", test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// upper bound of PasswordPolicy.MaxBytes, legacy bcrypt hashes ignore everything after 72 bytes
+const MaxPasswordBytes = 72
+
+const (
+	RuleMinLength       = "min_length"
+	RuleMaxLength       = "max_length"
+	RuleUppercase       = "uppercase"
+	RuleLowercase       = "lowercase"
+	RuleDigits          = "digits"
+	RuleSymbol          = "symbol"
+	RuleBannedSubstring = "banned_substring"
+	RuleBreached        = "breached"
+)
+
+// PasswordPolicy describes which passwords users may choose
+type PasswordPolicy struct {
+	MinLength        int
+	MaxBytes         int
+	MinDigits        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireSymbol    bool
+	BannedSubstrings []string
+	// Breached is consulted for known leaked passwords, nil disables the check
+	Breached BreachedPasswordSource
+}
+
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every rule the password breaks
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (policyErr *PasswordPolicyError) Error() string {
+	messages := make([]string, len(policyErr.Violations))
+	for i, violation := range policyErr.Violations {
+		messages[i] = violation.Message
+	}
+	return "password is not valid: " + strings.Join(messages, "; ")
+}
+
+// BreachedPasswordSource returns SHA-1 suffixes of leaked passwords for the first 5 hex characters of the hash,
+// so that neither the password nor its full hash leaves the checker (k-anonymity)
+type BreachedPasswordSource interface {
+	Range(ctx context.Context, hashPrefix string) ([]string, error)
+}
+
+// DefaultPasswordPolicy is the policy used when nothing is configured
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength: 4,
+		MaxBytes:  MaxPasswordBytes,
+		MinDigits: 2,
+	}
+}
+
+// Validate checks password against the policy, email is used to ban passwords containing it
+func (policy PasswordPolicy) Validate(ctx context.Context, password, email string) error {
+	var violations []PasswordViolation
+	addViolation := func(rule, message string, args ...interface{}) {
+		violations = append(violations, PasswordViolation{Rule: rule, Message: fmt.Sprintf(message, args...)})
+	}
+
+	if utf8.RuneCountInString(password) < policy.MinLength {
+		addViolation(RuleMinLength, "password must be at least %d characters", policy.MinLength)
+	}
+	maxBytes := policy.MaxBytes
+	if maxBytes <= 0 || maxBytes > MaxPasswordBytes {
+		maxBytes = MaxPasswordBytes
+	}
+	if len(password) > maxBytes {
+		addViolation(RuleMaxLength, "password must be at most %d bytes", maxBytes)
+	}
+
+	var digitCount, upperCount, lowerCount, symbolCount int
+	for _, char := range password {
+		switch {
+		case unicode.IsDigit(char):
+			digitCount++
+		case unicode.IsUpper(char):
+			upperCount++
+		case unicode.IsLower(char):
+			lowerCount++
+		case unicode.IsPunct(char) || unicode.IsSymbol(char) || unicode.IsSpace(char):
+			symbolCount++
+		}
+	}
+	if digitCount < policy.MinDigits {
+		addViolation(RuleDigits, "password must have at least %d digits", policy.MinDigits)
+	}
+	if policy.RequireUppercase && upperCount == 0 {
+		addViolation(RuleUppercase, "password must have an uppercase letter")
+	}
+	if policy.RequireLowercase && lowerCount == 0 {
+		addViolation(RuleLowercase, "password must have a lowercase letter")
+	}
+	if policy.RequireSymbol && symbolCount == 0 {
+		addViolation(RuleSymbol, "password must have a symbol")
+	}
+
+	loweredPassword := strings.ToLower(password)
+	for _, banned := range policy.bannedSubstrings(email) {
+		if strings.Contains(loweredPassword, banned) {
+			addViolation(RuleBannedSubstring, "password must not contain %q", banned)
+		}
+	}
+
+	if policy.Breached != nil && len(violations) == 0 {
+		breached, err := isBreached(ctx, policy.Breached, password)
+		if err != nil {
+			return fmt.Errorf("cannot check password against breached passwords: %w", err)
+		}
+		if breached {
+			addViolation(RuleBreached, "password appeared in a data breach, choose another one")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// bannedSubstrings adds user's email and its local part to configured banned substrings
+func (policy PasswordPolicy) bannedSubstrings(email string) []string {
+	banned := make([]string, 0, len(policy.BannedSubstrings)+2)
+	for _, substring := range policy.BannedSubstrings {
+		if substring != "" {
+			banned = append(banned, strings.ToLower(substring))
+		}
+	}
+
+	email = strings.ToLower(email)
+	localPart, _, _ := strings.Cut(email, "@")
+	if len(localPart) >= 3 {
+		banned = append(banned, localPart)
+	} else if email != "" {
+		banned = append(banned, email)
+	}
+	return banned
+}
+
+func isBreached(ctx context.Context, source BreachedPasswordSource, password string) (bool, error) {
+	hash := sha1.Sum([]byte(password))
+	hexHash := strings.ToUpper(hex.EncodeToString(hash[:]))
+	prefix, suffix := hexHash[:5], hexHash[5:]
+
+	suffixes, err := source.Range(ctx, prefix)
+	if err != nil {
+		return false, err
+	}
+	for _, breachedSuffix := range suffixes {
+		if breachedSuffix == suffix {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// LocalBreachedPasswords is a breached password list loaded into memory
+type LocalBreachedPasswords struct {
+	ranges map[string][]string
+}
+
+// LoadBreachedPasswords reads file with one hex SHA-1 hash per line, optionally followed by ":count"
+// as in the Have I Been Pwned downloads, the first line that is not a hash is reported with its number
+func LoadBreachedPasswords(path string) (*LocalBreachedPasswords, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	breached := &LocalBreachedPasswords{ranges: make(map[string][]string)}
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hexHash, _, _ := strings.Cut(line, ":")
+		hexHash = strings.ToUpper(hexHash)
+		_, err = hex.DecodeString(hexHash)
+		if len(hexHash) != sha1.Size*2 || err != nil {
+			return nil, fmt.Errorf("line %d is not a hex SHA-1 hash", lineNumber)
+		}
+		breached.ranges[hexHash[:5]] = append(breached.ranges[hexHash[:5]], hexHash[5:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return breached, nil
+}
+
+func (breached *LocalBreachedPasswords) Range(ctx context.Context, hashPrefix string) ([]string, error) {
+	return breached.ranges[strings.ToUpper(hashPrefix)], nil
+}
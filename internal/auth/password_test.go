@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func violatedRules(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var policyErr *PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("expected PasswordPolicyError, got %v", err)
+	}
+	rules := make([]string, len(policyErr.Violations))
+	for i, violation := range policyErr.Violations {
+		rules[i] = violation.Rule
+	}
+	return rules
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	strictPolicy := PasswordPolicy{
+		MinLength:        12,
+		MaxBytes:         MaxPasswordBytes,
+		MinDigits:        2,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireSymbol:    true,
+		BannedSubstrings: []string{"Snserver"},
+	}
+
+	tests := []struct {
+		name     string
+		policy   PasswordPolicy
+		password string
+		email    string
+		expected []string
+	}{
+		{"default policy", DefaultPasswordPolicy(), "ab12", "", nil},
+		{"strict policy", strictPolicy, "Correct-horse-98battery", "user@example.com", nil},
+		{"every rule at once", strictPolicy, "abc", "user@example.com", []string{RuleMinLength, RuleDigits, RuleUppercase, RuleSymbol}},
+		{"length is counted in characters", PasswordPolicy{MinLength: 4}, "пароль", "", nil},
+		{"too many bytes", DefaultPasswordPolicy(), strings.Repeat("1", MaxPasswordBytes+1), "", []string{RuleMaxLength}},
+		{"max bytes above the limit", PasswordPolicy{MaxBytes: 1000}, strings.Repeat("1", MaxPasswordBytes+1), "", []string{RuleMaxLength}},
+		{"banned substring ignores case", strictPolicy, "My-SNSERVER-98pass", "user@example.com", []string{RuleBannedSubstring}},
+		{"email local part", DefaultPasswordPolicy(), "alice-42", "Alice@example.com", []string{RuleBannedSubstring}},
+		{"short local part bans whole email", DefaultPasswordPolicy(), "al-42", "al@example.com", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules := violatedRules(t, test.policy.Validate(context.Background(), test.password, test.email))
+			if !slices.Equal(rules, test.expected) {
+				t.Fatalf("expected violations %v, got %v", test.expected, rules)
+			}
+		})
+	}
+}
+
+func TestBreachedPasswords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	// SHA-1 of "password" as in the Have I Been Pwned downloads, lowercase hashes are accepted too
+	content := "# leaked passwords\n5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\n\n7c4a8d09ca3762af61e59520943dc26494f8941b\n"
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("cannot write file: %s", err)
+	}
+	breached, err := LoadBreachedPasswords(path)
+	if err != nil {
+		t.Fatalf("cannot load breached passwords: %s", err)
+	}
+
+	policy := PasswordPolicy{MaxBytes: MaxPasswordBytes, Breached: breached}
+	rules := violatedRules(t, policy.Validate(context.Background(), "password", ""))
+	if !slices.Equal(rules, []string{RuleBreached}) {
+		t.Fatalf("expected breached password, got %v", rules)
+	}
+	rules = violatedRules(t, policy.Validate(context.Background(), "123456", ""))
+	if !slices.Equal(rules, []string{RuleBreached}) {
+		t.Fatalf("expected breached password from lowercase hash, got %v", rules)
+	}
+	err = policy.Validate(context.Background(), "Correct-horse-98battery", "")
+	if err != nil {
+		t.Fatalf("password is not breached: %s", err)
+	}
+
+	for name, content := range map[string]string{
+		"not a hash":      "not a hash\n",
+		"short hash":      "# comment\n5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD\n",
+		"non-hex of size": "# comment\n5BAA61E4C9B93F3F0682250B6CF8331B7EE68FDZ:3\n",
+	} {
+		err = os.WriteFile(path, []byte(content), 0o600)
+		if err != nil {
+			t.Fatalf("cannot write file: %s", err)
+		}
+		_, err = LoadBreachedPasswords(path)
+		if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("line %d", strings.Count(content, "\n"))) {
+			t.Fatalf("%s: expected error with line number, got %v", name, err)
+		}
+	}
+}
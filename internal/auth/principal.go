@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+type TokenType string
+
+const (
+	// TokenTypeSession is an access token issued to the user by login
+	TokenTypeSession TokenType = "session"
+	// TokenTypeOAuth is an access token issued to a third-party client
+	TokenTypeOAuth TokenType = "oauth"
+	// TokenTypePersonal is a personal access token
+	TokenTypePersonal TokenType = "personal"
+)
+
+const RoleAdmin = "admin"
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	UserID    uuid.UUID
+	Roles     []string
+	Scopes    []string
+	TokenType TokenType
+	ClientID  string
+}
+
+type principalContextKey struct{}
+
+func (principal Principal) IsAuthenticated() bool {
+	return principal.UserID != uuid.Nil
+}
+
+// IsFirstParty reports whether the user authenticated directly, not through a third-party client or personal token
+func (principal Principal) IsFirstParty() bool {
+	return principal.TokenType == TokenTypeSession
+}
+
+// HasScope reports whether the caller is allowed to act within scope. Session tokens have every scope.
+func (principal Principal) HasScope(scope string) bool {
+	return principal.IsFirstParty() || slices.Contains(principal.Scopes, scope)
+}
+
+func (principal Principal) HasRole(role string) bool {
+	return slices.Contains(principal.Roles, role)
+}
+
+func ContextWithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the caller, or zero Principal for anonymous requests
+func PrincipalFromContext(ctx context.Context) Principal {
+	principal, _ := ctx.Value(principalContextKey{}).(Principal)
+	return principal
+}
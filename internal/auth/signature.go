@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// SignURL appends expires and signature query parameters to path, so the link
+// can be used without authentication until it expires
+func SignURL(path string, expiresAt time.Time, secret string) string {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", urlSignature(path, expires, secret))
+	return path + "?" + query.Encode()
+}
+
+// VerifyURLSignature checks expires and signature query parameters made by SignURL
+func VerifyURLSignature(path string, query url.Values, secret string) error {
+	expires := query.Get("expires")
+	expiresUnix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return fmt.Errorf("link is not valid")
+	}
+
+	expectedSignature := urlSignature(path, expires, secret)
+	if !hmac.Equal([]byte(expectedSignature), []byte(query.Get("signature"))) {
+		return fmt.Errorf("link is not valid")
+	}
+	if time.Now().Unix() > expiresUnix {
+		return fmt.Errorf("link has expired")
+	}
+	return nil
+}
+
+func urlSignature(path, expires, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("url:" + path + ":" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
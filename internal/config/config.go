@@ -0,0 +1,370 @@
+package config
+
+import (
+	"log/slog"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/logging"
+	"github.com/ech00wv/SNserver/internal/ratelimit"
+	"github.com/ech00wv/SNserver/internal/tracing"
+)
+
+// Config holds validated settings, ApiConfig is built from it by InitializeApiConfig
+type Config struct {
+	Platform   string
+	DBDriver   string
+	DBURL      string
+	DBPool     DBPoolConfig
+	JWTSecret  string
+	PaymentKey string
+	// AccessTokenTTL is lifetime of access tokens issued on login, refresh and OAuth code exchange
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// AccountDeletionGracePeriod is how long a deleted account can be restored by logging in
+	AccountDeletionGracePeriod time.Duration
+	StorageDir                 string
+	// ContentFilterFile is read instead of content_filter_rules table if it is set
+	ContentFilterFile           string
+	ContentFilterReloadInterval time.Duration
+	RateLimitStore              string
+	RateLimits                  []ratelimit.Policy
+	// TrustedProxies are addresses of reverse proxies whose X-Forwarded-For header gives the client address
+	TrustedProxies        []netip.Prefix
+	PasswordPolicy        auth.PasswordPolicy
+	BreachedPasswordsFile string
+	PasswordHash          auth.Argon2Params
+	OIDCProviders         []OIDCProviderConfig
+	Server                ServerConfig
+	Log                   LogConfig
+	// TracingExporter is where spans are sent, one of tracing.Exporters
+	TracingExporter string
+	// MetricsToken is the bearer token GET /metrics requires, the endpoint is public if it is empty
+	MetricsToken string
+	// DemoMode keeps data of repositories (users, messages, tokens, etc.) in memory, the database is not used then
+	// and database-only features answer 501
+	DemoMode bool
+}
+
+// drivers of DB_DRIVER, DB_URL is path to the database file for SQLite
+const (
+	DBDriverPostgres = "postgres"
+	DBDriverSQLite   = "sqlite"
+)
+
+// DBPoolConfig tunes database/sql connection pool and the connection check on startup. It is used with Postgres only,
+// SQLite database is a local file opened with a single connection.
+type DBPoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// ConnectAttempts is how many times the database is pinged on startup before giving up,
+	// waiting ConnectBackoff after the first failure and twice as long after every next one
+	ConnectAttempts int
+	ConnectBackoff  time.Duration
+}
+
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+// ServerConfig configures the http server
+type ServerConfig struct {
+	Addr           string
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+	IdleTimeout    time.Duration
+	MaxHeaderBytes int
+	// DrainTimeout is how long the server keeps serving after SIGTERM while reporting it is not ready,
+	// so that load balancer stops sending new requests before the server stops accepting them
+	DrainTimeout time.Duration
+	// ShutdownTimeout is how long in-flight requests are waited for on shutdown
+	ShutdownTimeout time.Duration
+}
+
+// LogConfig configures structured logs written to stderr
+type LogConfig struct {
+	Format string
+	Level  slog.Level
+}
+
+// dbPoolOptions configure DBPoolConfig, they are rejected for SQLite so that they are not silently ignored
+var dbPoolOptions = []string{
+	"DB_MAX_OPEN_CONNS",
+	"DB_MAX_IDLE_CONNS",
+	"DB_CONN_MAX_LIFETIME_SECONDS",
+	"DB_CONN_MAX_IDLE_TIME_SECONDS",
+	"DB_CONNECT_ATTEMPTS",
+	"DB_CONNECT_BACKOFF_SECONDS",
+}
+
+// names of rate limit policies, each can be overridden with RATE_LIMIT_<NAME>=<limit>/<period>
+const (
+	RateLimitLogin      = "login"
+	RateLimitSignup     = "signup"
+	RateLimitRefresh    = "refresh"
+	RateLimitOAuthToken = "oauth_token"
+	RateLimitMessages   = "messages"
+	RateLimitReports    = "reports"
+)
+
+// rateLimitStoreOption is the only RATE_LIMIT_* option that does not name a policy
+const rateLimitStoreOption = "RATE_LIMIT_STORE"
+
+// stores of RATE_LIMIT_STORE, the database store keeps buckets in the database of DB_DRIVER
+const (
+	RateLimitStoreMemory   = "memory"
+	RateLimitStoreDatabase = "database"
+	// rateLimitStorePostgres is the old name of the database store, from before SQLite was supported
+	rateLimitStorePostgres = "postgres"
+)
+
+var defaultRateLimits = []ratelimit.Policy{
+	{Name: RateLimitLogin, Limit: 10, Period: time.Minute},
+	{Name: RateLimitSignup, Limit: 5, Period: time.Hour},
+	{Name: RateLimitRefresh, Limit: 30, Period: time.Minute},
+	{Name: RateLimitOAuthToken, Limit: 30, Period: time.Minute},
+	{Name: RateLimitMessages, Limit: 30, Period: time.Minute},
+	{Name: RateLimitReports, Limit: 20, Period: time.Hour},
+}
+
+// Load reads configuration from command-line flags, environment variables (and .env file given by ENV_FILE,
+// ../../.env by default) and YAML file given by CONFIG_FILE, in that order of precedence.
+// All invalid options are reported together, so the server does not start half-configured.
+func Load(args []string) (Config, error) {
+	configLoader, err := newLoader(args)
+	if err != nil {
+		return Config{}, err
+	}
+
+	demoMode := configLoader.Bool("DEMO_MODE", false)
+	cfg := Config{
+		Platform: configLoader.String("PLATFORM", ""),
+		DemoMode: demoMode,
+		DBDriver: configLoader.String("DB_DRIVER", DBDriverPostgres),
+		DBURL:    configLoader.String("DB_URL", ""),
+		DBPool: DBPoolConfig{
+			MaxOpenConns:    configLoader.Int("DB_MAX_OPEN_CONNS", 25),
+			MaxIdleConns:    configLoader.Int("DB_MAX_IDLE_CONNS", 25),
+			ConnMaxLifetime: configLoader.Duration("DB_CONN_MAX_LIFETIME_SECONDS", 30*time.Minute, time.Second),
+			ConnMaxIdleTime: configLoader.Duration("DB_CONN_MAX_IDLE_TIME_SECONDS", 5*time.Minute, time.Second),
+			ConnectAttempts: configLoader.Int("DB_CONNECT_ATTEMPTS", 5),
+			ConnectBackoff:  configLoader.Duration("DB_CONNECT_BACKOFF_SECONDS", time.Second, time.Second),
+		},
+		JWTSecret:                   configLoader.Required("JWT_SECRET"),
+		PaymentKey:                  configLoader.String("PAYMENT_KEY", ""),
+		AccessTokenTTL:              configLoader.Duration("ACCESS_TOKEN_TTL_SECONDS", time.Hour, time.Second),
+		RefreshTokenTTL:             configLoader.Duration("REFRESH_TOKEN_TTL_SECONDS", 60*24*time.Hour, time.Second),
+		AccountDeletionGracePeriod:  configLoader.Duration("ACCOUNT_DELETION_GRACE_DAYS", 30*24*time.Hour, 24*time.Hour),
+		StorageDir:                  configLoader.String("STORAGE_DIR", "../../storage"),
+		ContentFilterFile:           configLoader.String("CONTENT_FILTER_FILE", ""),
+		ContentFilterReloadInterval: configLoader.Duration("CONTENT_FILTER_RELOAD_SECONDS", 30*time.Second, time.Second),
+		RateLimitStore:              loadRateLimitStore(configLoader),
+		RateLimits:                  loadRateLimits(configLoader),
+		TrustedProxies:              loadTrustedProxies(configLoader),
+		PasswordPolicy:              loadPasswordPolicy(configLoader),
+		BreachedPasswordsFile:       configLoader.String("BREACHED_PASSWORDS_FILE", ""),
+		PasswordHash:                loadPasswordHash(configLoader),
+		OIDCProviders:               loadOIDCProviders(configLoader),
+		Server: ServerConfig{
+			Addr:            configLoader.String("SERVER_ADDR", ":8080"),
+			ReadTimeout:     configLoader.Duration("SERVER_READ_TIMEOUT_SECONDS", 15*time.Second, time.Second),
+			WriteTimeout:    configLoader.Duration("SERVER_WRITE_TIMEOUT_SECONDS", 30*time.Second, time.Second),
+			IdleTimeout:     configLoader.Duration("SERVER_IDLE_TIMEOUT_SECONDS", 60*time.Second, time.Second),
+			MaxHeaderBytes:  configLoader.Int("SERVER_MAX_HEADER_BYTES", 1<<20),
+			DrainTimeout:    configLoader.Duration("SERVER_DRAIN_TIMEOUT_SECONDS", 0, time.Second),
+			ShutdownTimeout: configLoader.Duration("SERVER_SHUTDOWN_TIMEOUT_SECONDS", 30*time.Second, time.Second),
+		},
+		TracingExporter: configLoader.String("TRACING_EXPORTER", tracing.ExporterNone),
+		MetricsToken:    configLoader.String("METRICS_TOKEN", ""),
+		Log: LogConfig{
+			Format: configLoader.String("LOG_FORMAT", logging.FormatJSON),
+			Level:  loadLogLevel(configLoader),
+		},
+	}
+
+	if cfg.DBURL == "" && !cfg.DemoMode {
+		configLoader.errorf("DB_URL", "is required")
+	}
+	if !slices.Contains([]string{DBDriverPostgres, DBDriverSQLite}, cfg.DBDriver) {
+		configLoader.errorf("DB_DRIVER", "must be postgres or sqlite")
+	}
+	if !slices.Contains([]string{RateLimitStoreMemory, RateLimitStoreDatabase}, cfg.RateLimitStore) {
+		configLoader.errorf("RATE_LIMIT_STORE", "must be memory or database")
+	}
+	if cfg.DemoMode && cfg.RateLimitStore != RateLimitStoreMemory {
+		configLoader.errorf("RATE_LIMIT_STORE", "must be memory in demo mode")
+	}
+	if cfg.DBDriver == DBDriverSQLite {
+		for _, name := range dbPoolOptions {
+			if _, ok := configLoader.lookup(name); ok {
+				configLoader.errorf(name, "is only used with postgres")
+			}
+		}
+	} else {
+		configLoader.Positive("DB_MAX_OPEN_CONNS", int64(cfg.DBPool.MaxOpenConns))
+		if cfg.DBPool.MaxIdleConns < 0 || cfg.DBPool.MaxIdleConns > cfg.DBPool.MaxOpenConns {
+			configLoader.errorf("DB_MAX_IDLE_CONNS", "must be between 0 and DB_MAX_OPEN_CONNS")
+		}
+		configLoader.Positive("DB_CONN_MAX_LIFETIME_SECONDS", int64(cfg.DBPool.ConnMaxLifetime))
+		configLoader.Positive("DB_CONN_MAX_IDLE_TIME_SECONDS", int64(cfg.DBPool.ConnMaxIdleTime))
+		configLoader.Positive("DB_CONNECT_ATTEMPTS", int64(cfg.DBPool.ConnectAttempts))
+		configLoader.Positive("DB_CONNECT_BACKOFF_SECONDS", int64(cfg.DBPool.ConnectBackoff))
+	}
+	configLoader.Positive("ACCESS_TOKEN_TTL_SECONDS", int64(cfg.AccessTokenTTL))
+	configLoader.Positive("REFRESH_TOKEN_TTL_SECONDS", int64(cfg.RefreshTokenTTL))
+	configLoader.Positive("ACCOUNT_DELETION_GRACE_DAYS", int64(cfg.AccountDeletionGracePeriod))
+	configLoader.Positive("CONTENT_FILTER_RELOAD_SECONDS", int64(cfg.ContentFilterReloadInterval))
+	configLoader.Positive("SERVER_READ_TIMEOUT_SECONDS", int64(cfg.Server.ReadTimeout))
+	configLoader.Positive("SERVER_WRITE_TIMEOUT_SECONDS", int64(cfg.Server.WriteTimeout))
+	configLoader.Positive("SERVER_IDLE_TIMEOUT_SECONDS", int64(cfg.Server.IdleTimeout))
+	configLoader.Positive("SERVER_MAX_HEADER_BYTES", int64(cfg.Server.MaxHeaderBytes))
+	if cfg.Server.DrainTimeout < 0 {
+		configLoader.errorf("SERVER_DRAIN_TIMEOUT_SECONDS", "must not be negative")
+	}
+	configLoader.Positive("SERVER_SHUTDOWN_TIMEOUT_SECONDS", int64(cfg.Server.ShutdownTimeout))
+	if !slices.Contains(tracing.Exporters, cfg.TracingExporter) {
+		configLoader.errorf("TRACING_EXPORTER", "must be one of %s", strings.Join(tracing.Exporters, ", "))
+	}
+	if !slices.Contains(logging.Formats, cfg.Log.Format) {
+		configLoader.errorf("LOG_FORMAT", "must be one of %s", strings.Join(logging.Formats, ", "))
+	}
+
+	return cfg, configLoader.Err()
+}
+
+// loadLogLevel reads level name (debug, info, warn or error)
+func loadLogLevel(configLoader *loader) slog.Level {
+	var level slog.Level
+	value := configLoader.String("LOG_LEVEL", "info")
+	err := level.UnmarshalText([]byte(value))
+	if err != nil {
+		configLoader.errorf("LOG_LEVEL", "must be debug, info, warn or error, got %q", value)
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// loadRateLimitStore accepts "postgres" as the database store, configurations made before SQLite keep working
+func loadRateLimitStore(configLoader *loader) string {
+	store := configLoader.String(rateLimitStoreOption, RateLimitStoreMemory)
+	if store == rateLimitStorePostgres {
+		return RateLimitStoreDatabase
+	}
+	return store
+}
+
+// loadRateLimits overrides default policies with RATE_LIMIT_<NAME> options. Options that do not name a policy are
+// rejected, so a typo does not leave the default in place; flags and config file keys are rejected by loader.Err.
+func loadRateLimits(configLoader *loader) []ratelimit.Policy {
+	policies := make([]ratelimit.Policy, len(defaultRateLimits))
+	options := []string{rateLimitStoreOption}
+	for i, policy := range defaultRateLimits {
+		policies[i] = policy
+		name := "RATE_LIMIT_" + strings.ToUpper(policy.Name)
+		options = append(options, name)
+		value := configLoader.String(name, "")
+		if value == "" {
+			continue
+		}
+		parsedPolicy, err := ratelimit.ParsePolicy(policy.Name, value)
+		if err != nil {
+			configLoader.errorf(name, "%s", err)
+			continue
+		}
+		policies[i] = parsedPolicy
+	}
+
+	for _, variable := range os.Environ() {
+		name, _, _ := strings.Cut(variable, "=")
+		if strings.HasPrefix(name, "RATE_LIMIT_") && !slices.Contains(options, name) {
+			configLoader.errorf(name, "is not a rate limit policy, policies are %s", strings.Join(options[1:], ", "))
+		}
+	}
+	return policies
+}
+
+// loadTrustedProxies reads comma separated ip addresses and CIDR ranges, e.g. "10.0.0.0/8,127.0.0.1"
+func loadTrustedProxies(configLoader *loader) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, item := range configLoader.List("TRUSTED_PROXIES") {
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(item)
+			if addrErr != nil {
+				configLoader.errorf("TRUSTED_PROXIES", "%q is not an ip address or CIDR range", item)
+				continue
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
+}
+
+// loadPasswordPolicy overrides default policy with PASSWORD_* options
+func loadPasswordPolicy(configLoader *loader) auth.PasswordPolicy {
+	policy := auth.DefaultPasswordPolicy()
+	policy.MinLength = configLoader.Int("PASSWORD_MIN_LENGTH", policy.MinLength)
+	policy.MaxBytes = configLoader.Int("PASSWORD_MAX_BYTES", policy.MaxBytes)
+	policy.MinDigits = configLoader.Int("PASSWORD_MIN_DIGITS", policy.MinDigits)
+	policy.RequireUppercase = configLoader.Bool("PASSWORD_REQUIRE_UPPERCASE", policy.RequireUppercase)
+	policy.RequireLowercase = configLoader.Bool("PASSWORD_REQUIRE_LOWERCASE", policy.RequireLowercase)
+	policy.RequireSymbol = configLoader.Bool("PASSWORD_REQUIRE_SYMBOL", policy.RequireSymbol)
+	policy.BannedSubstrings = append(policy.BannedSubstrings, configLoader.List("PASSWORD_BANNED_SUBSTRINGS")...)
+
+	configLoader.Positive("PASSWORD_MIN_LENGTH", int64(policy.MinLength))
+	if policy.MaxBytes < policy.MinLength {
+		configLoader.errorf("PASSWORD_MAX_BYTES", "must not be less than PASSWORD_MIN_LENGTH")
+	}
+	return policy
+}
+
+// loadPasswordHash overrides default Argon2id parameters with ARGON2_* options
+func loadPasswordHash(configLoader *loader) auth.Argon2Params {
+	params := auth.DefaultArgon2Params()
+	memoryKiB := configLoader.Int("ARGON2_MEMORY_KIB", int(params.MemoryKiB))
+	iterations := configLoader.Int("ARGON2_ITERATIONS", int(params.Iterations))
+	parallelism := configLoader.Int("ARGON2_PARALLELISM", int(params.Parallelism))
+
+	if memoryKiB < 8*parallelism {
+		configLoader.errorf("ARGON2_MEMORY_KIB", "must be at least 8 KiB per thread")
+	}
+	configLoader.Positive("ARGON2_ITERATIONS", int64(iterations))
+	if parallelism <= 0 || parallelism > 255 {
+		configLoader.errorf("ARGON2_PARALLELISM", "must be between 1 and 255")
+	}
+
+	params.MemoryKiB = uint32(memoryKiB)
+	params.Iterations = uint32(iterations)
+	params.Parallelism = uint8(parallelism)
+	return params
+}
+
+// loadOIDCProviders reads providers listed in OIDC_PROVIDERS (comma separated names),
+// each configured by OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET
+// and OIDC_<NAME>_REDIRECT_URL
+func loadOIDCProviders(configLoader *loader) []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range configLoader.List("OIDC_PROVIDERS") {
+		name = strings.ToLower(name)
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:         name,
+			Issuer:       configLoader.Required(prefix + "ISSUER"),
+			ClientID:     configLoader.Required(prefix + "CLIENT_ID"),
+			ClientSecret: configLoader.String(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  configLoader.Required(prefix + "REDIRECT_URL"),
+		})
+	}
+	return providers
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loadTestConfig loads configuration from flags only, with an empty env file instead of the repository's .env
+func loadTestConfig(t *testing.T, args ...string) (Config, error) {
+	t.Helper()
+	envFile := filepath.Join(t.TempDir(), ".env")
+	err := os.WriteFile(envFile, nil, 0o600)
+	if err != nil {
+		t.Fatalf("cannot write env file: %s", err)
+	}
+	args = append([]string{"-env-file=" + envFile, "-jwt-secret=secret", "-db-url=postgres://localhost/test"}, args...)
+	return Load(args)
+}
+
+func TestLoadTrustedProxies(t *testing.T) {
+	cfg, err := loadTestConfig(t, "-trusted-proxies=10.0.0.0/8, 192.168.1.7,10.1.2.3/16")
+	if err != nil {
+		t.Fatalf("cannot load config: %s", err)
+	}
+	expected := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.1.7/32"),
+		netip.MustParsePrefix("10.1.0.0/16"),
+	}
+	if !slices.Equal(cfg.TrustedProxies, expected) {
+		t.Fatalf("expected %v, got %v", expected, cfg.TrustedProxies)
+	}
+
+	_, err = loadTestConfig(t, "-trusted-proxies=10.0.0.0/8,proxy.local")
+	if err == nil || !strings.Contains(err.Error(), "TRUSTED_PROXIES") {
+		t.Fatalf("expected TRUSTED_PROXIES error, got %v", err)
+	}
+}
+
+func TestLoadRateLimits(t *testing.T) {
+	cfg, err := loadTestConfig(t, "-rate-limit-login=3/1h")
+	if err != nil {
+		t.Fatalf("cannot load config: %s", err)
+	}
+	if len(cfg.RateLimits) != len(defaultRateLimits) {
+		t.Fatalf("expected %d policies, got %v", len(defaultRateLimits), cfg.RateLimits)
+	}
+	for _, policy := range cfg.RateLimits {
+		if policy.Name == RateLimitLogin && policy.Limit != 3 {
+			t.Fatalf("login policy was not overridden: %+v", policy)
+		}
+	}
+
+	t.Setenv("RATE_LIMIT_MESSAGE", "10/1m")
+	_, err = loadTestConfig(t)
+	if err == nil || !strings.Contains(err.Error(), "RATE_LIMIT_MESSAGE") {
+		t.Fatalf("expected unknown policy error, got %v", err)
+	}
+}
+
+func TestLoadRateLimitStore(t *testing.T) {
+	for _, store := range []string{"database", "postgres"} {
+		cfg, err := loadTestConfig(t, "-rate-limit-store="+store)
+		if err != nil {
+			t.Fatalf("cannot load config: %s", err)
+		}
+		if cfg.RateLimitStore != RateLimitStoreDatabase {
+			t.Fatalf("expected %s store for %s, got %s", RateLimitStoreDatabase, store, cfg.RateLimitStore)
+		}
+	}
+}
+
+func TestLoadTokenTTL(t *testing.T) {
+	cfg, err := loadTestConfig(t, "-access-token-ttl-seconds=900", "-refresh-token-ttl-seconds=24h")
+	if err != nil {
+		t.Fatalf("cannot load config: %s", err)
+	}
+	if cfg.AccessTokenTTL != 15*time.Minute {
+		t.Fatalf("expected access token TTL of 15m, got %s", cfg.AccessTokenTTL)
+	}
+	if cfg.RefreshTokenTTL != 24*time.Hour {
+		t.Fatalf("expected refresh token TTL of 24h, got %s", cfg.RefreshTokenTTL)
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		// options expected in the error, none for valid configuration
+		invalid []string
+	}{
+		{"defaults", nil, nil},
+		{"sqlite", []string{"-db-driver=sqlite"}, nil},
+		{"unknown driver", []string{"-db-driver=mysql"}, []string{"DB_DRIVER"}},
+		{"pool options with sqlite", []string{"-db-driver=sqlite", "-db-max-open-conns=5"}, []string{"DB_MAX_OPEN_CONNS: is only used with postgres"}},
+		{"idle connections above open ones", []string{"-db-max-open-conns=5", "-db-max-idle-conns=10"}, []string{"DB_MAX_IDLE_CONNS"}},
+		{"database rate limit store with sqlite", []string{"-db-driver=sqlite", "-rate-limit-store=database"}, nil},
+		{"unknown rate limit store", []string{"-rate-limit-store=redis"}, []string{"RATE_LIMIT_STORE: must be memory or database"}},
+		{"database rate limit store in demo mode", []string{"-demo-mode", "-rate-limit-store=database"}, []string{"RATE_LIMIT_STORE"}},
+		{"postgres rate limit store in demo mode", []string{"-demo-mode", "-rate-limit-store=postgres"}, []string{"RATE_LIMIT_STORE"}},
+		{"several invalid options", []string{"-access-token-ttl-seconds=0", "-log-format=xml", "-log-level=loud"}, []string{"ACCESS_TOKEN_TTL_SECONDS", "LOG_FORMAT", "LOG_LEVEL"}},
+		{"unknown flag", []string{"-server-adr=:9000"}, []string{"unknown flag -server-adr"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := loadTestConfig(t, test.args...)
+			if len(test.invalid) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected errors for %v", test.invalid)
+			}
+			for _, option := range test.invalid {
+				if !strings.Contains(err.Error(), option) {
+					t.Fatalf("error does not mention %s: %s", option, err)
+				}
+			}
+		})
+	}
+}
@@ -1,40 +1,316 @@
 package config
 
 import (
+	"context"
 	"database/sql"
-	"log"
-	"os"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"strings"
 	"sync/atomic"
+	"time"
 
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/contentfilter"
 	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/database/sqlite"
+	"github.com/ech00wv/SNserver/internal/health"
+	"github.com/ech00wv/SNserver/internal/metrics"
+	"github.com/ech00wv/SNserver/internal/ratelimit"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/storage"
+	"github.com/ech00wv/SNserver/internal/tracing"
+	"github.com/ech00wv/SNserver/sql/schema"
 )
 
+const dbPingTimeout = 5 * time.Second
+
 type ApiConfig struct {
 	FileserverHits atomic.Int64
-	Queries        *database.Queries
+	DB             *sql.DB
+	Queries        database.Querier
 	Platfrom       string
 	JWTSecret      string
 	PaymentKey     string
+	// AccessTokenTTL is lifetime of access tokens issued on login, refresh and OAuth code exchange
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	OIDCProviders   map[string]*auth.OIDCProvider
+	PasswordPolicy  auth.PasswordPolicy
+	PasswordHash    auth.Argon2Params
+	// AccountDeletionGracePeriod is how long a deleted account can be restored by logging in
+	AccountDeletionGracePeriod time.Duration
+	Storage                    storage.Storage
+	ContentFilter              *contentfilter.Filter
+	// ContentFilterReloadInterval is how often content filter rules are reloaded from their source
+	ContentFilterReloadInterval time.Duration
+	RateLimiter                 *ratelimit.Limiter
+	// TrustedProxies are reverse proxies whose X-Forwarded-For header is used as the client address
+	TrustedProxies []netip.Prefix
+	Health         *health.Registry
+	Metrics        *metrics.Metrics
+	MetricsToken   string
+	// Logger is the base logger, requests and background jobs get loggers derived from it through their contexts
+	Logger *slog.Logger
+	// Repositories are storages of services that are decoupled from the database, they are kept in memory in demo mode
+	Repositories repository.Repositories
+	// newQuerier builds Queries of the configured driver on top of a transaction
+	newQuerier func(db tracing.DBTX) database.Querier
+	// dbDriver is DB_DRIVER, transactions are traced as queries of this database
+	dbDriver string
+	// demoMode has no database transactions, see InRepositoriesTx
+	demoMode bool
 }
 
-func InitializeApiConfig() *ApiConfig {
-	apiCfg := &ApiConfig{
-		FileserverHits: atomic.Int64{},
-		Queries:        initializeDBQueries(),
-		Platfrom:       os.Getenv("PLATFORM"),
-		JWTSecret:      os.Getenv("JWT_SECRET"),
-		PaymentKey:     os.Getenv("PAYMENT_KEY"),
+// ErrDemoMode is returned by the database in demo mode, services report it as ErrorNotImplemented
+var ErrDemoMode = errors.New("database is not available in demo mode")
+
+// InitializeApiConfig connects to the database and builds services' dependencies,
+// ctx cancels waiting for the database on startup
+func InitializeApiConfig(ctx context.Context, cfg Config, logger *slog.Logger) (*ApiConfig, error) {
+	var err error
+	passwordPolicy := cfg.PasswordPolicy
+	if cfg.BreachedPasswordsFile != "" {
+		passwordPolicy.Breached, err = auth.LoadBreachedPasswords(cfg.BreachedPasswordsFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load breached passwords: %w", err)
+		}
 	}
+
+	if cfg.DemoMode {
+		return initializeDemoApiConfig(cfg, logger, passwordPolicy), nil
+	}
+
+	var db *sql.DB
+	var newQuerier func(db tracing.DBTX) database.Querier
+	healthRegistry := health.NewRegistry()
+	if cfg.DBDriver == DBDriverSQLite {
+		db, err = initializeSQLiteDB(ctx, cfg.DBURL)
+		if err != nil {
+			return nil, err
+		}
+		newQuerier = newSQLiteQuerier
+		// migrations are applied on startup, so only the connection can break
+		healthRegistry.AddReadiness("database", health.DatabaseCheck(db))
+	} else {
+		db, err = initializeDB(ctx, logger, cfg.DBURL, cfg.DBPool)
+		if err != nil {
+			return nil, err
+		}
+		newQuerier = newPostgresQuerier
+
+		migrationVersion, err := schema.LatestVersion()
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("cannot get latest migration: %w", err)
+		}
+		healthRegistry.AddReadiness("database", health.DatabaseCheck(db))
+		healthRegistry.AddReadiness("migrations", health.MigrationsCheck(db, migrationVersion))
+	}
+
+	queries := newQuerier(tracing.WrapDB(db, cfg.DBDriver))
+	apiCfg := newApiConfig(cfg, logger, passwordPolicy, db, queries, repository.NewDatabase(queries))
+	apiCfg.newQuerier = newQuerier
+	apiCfg.ContentFilter = initializeContentFilter(logger, contentfilter.DatabaseSource{Queries: queries}, cfg.ContentFilterFile)
+	apiCfg.RateLimiter = initializeRateLimiter(queries, cfg.RateLimitStore, cfg.RateLimits)
+	apiCfg.Health = healthRegistry
+	return apiCfg, nil
+}
+
+// initializeDemoApiConfig keeps repositories in memory. Features that still need the database
+// (e.g. moderation queue, OAuth clients, data exports) fail with ErrDemoMode.
+func initializeDemoApiConfig(cfg Config, logger *slog.Logger, passwordPolicy auth.PasswordPolicy) *ApiConfig {
+	logger.Warn("running in demo mode, data is kept in memory and lost on restart")
+	db := sql.OpenDB(unavailableConnector{})
+	queries := newPostgresQuerier(tracing.WrapDB(db, cfg.DBDriver))
+
+	apiCfg := newApiConfig(cfg, logger, passwordPolicy, db, queries, repository.NewMemory().Repositories())
+	apiCfg.newQuerier = newPostgresQuerier
+	apiCfg.ContentFilter = initializeContentFilter(logger, contentfilter.StaticSource(contentfilter.DefaultRules), cfg.ContentFilterFile)
+	apiCfg.RateLimiter = initializeRateLimiter(queries, cfg.RateLimitStore, cfg.RateLimits)
+	apiCfg.Health = health.NewRegistry()
 	return apiCfg
 }
 
-func initializeDBQueries() *database.Queries {
-	dbURL := os.Getenv("DB_URL")
+func newApiConfig(cfg Config, logger *slog.Logger, passwordPolicy auth.PasswordPolicy, db *sql.DB, queries database.Querier, repositories repository.Repositories) *ApiConfig {
+	return &ApiConfig{
+		FileserverHits:              atomic.Int64{},
+		DB:                          db,
+		Queries:                     queries,
+		Repositories:                repositories,
+		Platfrom:                    cfg.Platform,
+		JWTSecret:                   cfg.JWTSecret,
+		PaymentKey:                  cfg.PaymentKey,
+		AccessTokenTTL:              cfg.AccessTokenTTL,
+		RefreshTokenTTL:             cfg.RefreshTokenTTL,
+		OIDCProviders:               initializeOIDCProviders(cfg.OIDCProviders),
+		PasswordPolicy:              passwordPolicy,
+		PasswordHash:                cfg.PasswordHash,
+		AccountDeletionGracePeriod:  cfg.AccountDeletionGracePeriod,
+		Storage:                     storage.NewLocalStorage(cfg.StorageDir),
+		ContentFilterReloadInterval: cfg.ContentFilterReloadInterval,
+		Metrics:                     metrics.New(db, cfg.DBDriver),
+		MetricsToken:                cfg.MetricsToken,
+		TrustedProxies:              cfg.TrustedProxies,
+		Logger:                      logger,
+		dbDriver:                    cfg.DBDriver,
+		demoMode:                    cfg.DemoMode,
+	}
+}
+
+// InTx runs fn with queries bound to a new transaction, which is committed if fn succeeds and rolled back otherwise.
+// fn must not use ApiConfig.Queries, SQLite has a single connection and the transaction holds it.
+func (apiCfg *ApiConfig) InTx(ctx context.Context, fn func(queries database.Querier) error) error {
+	tx, err := apiCfg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("cannot begin transaction: %w", err)
+	}
+
+	err = fn(apiCfg.newQuerier(tracing.WrapDB(tx, apiCfg.dbDriver)))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("cannot commit transaction: %w", err)
+	}
+	return nil
+}
+
+// InRepositoriesTx runs fn with repositories bound to a new transaction like InTx, so services built on repositories
+// can write several of them together. Memory repositories of demo mode have no transactions, fn runs on them directly.
+func (apiCfg *ApiConfig) InRepositoriesTx(ctx context.Context, fn func(repos repository.Repositories) error) error {
+	if apiCfg.demoMode {
+		return fn(apiCfg.Repositories)
+	}
+	return apiCfg.InTx(ctx, func(queries database.Querier) error {
+		return fn(repository.NewDatabase(queries))
+	})
+}
+
+func newPostgresQuerier(db tracing.DBTX) database.Querier {
+	return database.NewPostgresQuerier(db)
+}
+
+func newSQLiteQuerier(db tracing.DBTX) database.Querier {
+	return sqlite.NewQuerier(db)
+}
+
+// unavailableConnector is the database of demo mode, it fails every connection
+type unavailableConnector struct{}
+
+func (unavailableConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return nil, ErrDemoMode
+}
+
+func (unavailableConnector) Driver() driver.Driver {
+	return unavailableDriver{}
+}
+
+type unavailableDriver struct{}
+
+func (unavailableDriver) Open(name string) (driver.Conn, error) {
+	return nil, ErrDemoMode
+}
+
+// initializeDB opens connection pool and pings the database until it answers,
+// so the server does not start serving requests it cannot handle
+func initializeDB(ctx context.Context, logger *slog.Logger, dbURL string, pool DBPoolConfig) (*sql.DB, error) {
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
-		log.Printf("error in db connection: %s", err)
-		return nil
+		return nil, fmt.Errorf("error in db connection: %w", err)
+	}
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+
+	backoff := pool.ConnectBackoff
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, dbPingTimeout)
+		err = db.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return db, nil
+		}
+		if attempt == pool.ConnectAttempts {
+			break
+		}
+
+		logger.Warn("database is not available, retrying", "attempt", attempt, "attempts", pool.ConnectAttempts, "backoff", backoff.String(), "error", err)
+		select {
+		case <-ctx.Done():
+			db.Close()
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	db.Close()
+	return nil, fmt.Errorf("database is not available after %d attempts: %w", pool.ConnectAttempts, err)
+}
+
+// initializeSQLiteDB opens the database file, creating it if it does not exist, and applies migrations.
+// SQLite has a single writer, so one connection is enough, it also keeps ":memory:" database alive.
+func initializeSQLiteDB(ctx context.Context, path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		return nil, fmt.Errorf("error in db connection: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	err = sqlite.Migrate(ctx, db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("cannot migrate sqlite database: %w", err)
+	}
+	return db, nil
+}
+
+// sqliteDSN turns on foreign keys, which cascade deletes like in Postgres, waits for locks of other processes
+// (e.g. backups) and writes times in the format that SQLite date functions understand
+func sqliteDSN(path string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite"
+}
+
+// initializeContentFilter loads rules from the file if it is set, otherwise from defaultSource
+func initializeContentFilter(logger *slog.Logger, defaultSource contentfilter.RuleSource, rulesFile string) *contentfilter.Filter {
+	source := defaultSource
+	if rulesFile != "" {
+		source = contentfilter.FileSource{Path: rulesFile}
+	}
+
+	filter := contentfilter.NewFilter(source)
+	err := filter.Reload(context.Background())
+	if err != nil {
+		logger.Warn("content filter has no rules until the next reload", "error", err)
+	}
+	return filter
+}
+
+// initializeRateLimiter keeps buckets in memory unless store is RateLimitStoreDatabase, which is needed
+// when several instances of the server share the limits (with SQLite it makes limits survive restarts)
+func initializeRateLimiter(queries database.Querier, storeName string, policies []ratelimit.Policy) *ratelimit.Limiter {
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if storeName == RateLimitStoreDatabase {
+		store = ratelimit.DatabaseStore{Queries: queries}
+	}
+	return ratelimit.NewLimiter(store, policies...)
+}
+
+func initializeOIDCProviders(providerConfigs []OIDCProviderConfig) map[string]*auth.OIDCProvider {
+	providers := make(map[string]*auth.OIDCProvider)
+	for _, provider := range providerConfigs {
+		providers[provider.Name] = auth.NewOIDCProvider(provider.Name, provider.Issuer, provider.ClientID, provider.ClientSecret, provider.RedirectURL)
 	}
-	dbQueries := database.New(db)
-	return dbQueries
+	return providers
 }
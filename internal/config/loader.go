@@ -0,0 +1,249 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// loader looks up options by their environment variable name in command-line flags, environment
+// (including .env file) and config file, in that order. The same option is "-server-addr=:8080" flag,
+// SERVER_ADDR variable and "server: {addr: :8080}" or "server_addr: :8080" in the config file.
+type loader struct {
+	flags map[string]string
+	file  map[string]string
+	used  map[string]bool
+	errs  []error
+}
+
+func newLoader(args []string) (*loader, error) {
+	flags, err := parseFlags(args)
+	if err != nil {
+		return nil, err
+	}
+	configLoader := &loader{flags: flags, file: make(map[string]string), used: make(map[string]bool)}
+
+	// .env next to the repository root is optional, explicitly given file must exist
+	envFile, explicit := configLoader.lookup("ENV_FILE")
+	if !explicit {
+		envFile = "../../.env"
+	}
+	err = godotenv.Load(envFile)
+	if err != nil && (explicit || !errors.Is(err, os.ErrNotExist)) {
+		return nil, fmt.Errorf("cannot load env file %s: %w", envFile, err)
+	}
+
+	if configFile, ok := configLoader.lookup("CONFIG_FILE"); ok {
+		configLoader.file, err = readConfigFile(configFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read config file %s: %w", configFile, err)
+		}
+	}
+	return configLoader, nil
+}
+
+// parseFlags reads "-name=value", "-name value" and boolean "-name" flags, names are converted to
+// environment variable names, e.g. "-server-addr" is SERVER_ADDR. In "-name value" form a value starting
+// with "-" is taken only if it does not look like a flag (e.g. "-port -1"), "-name=value" works for any value.
+func parseFlags(args []string) (map[string]string, error) {
+	flags := make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name := strings.TrimLeft(arg, "-")
+		if !strings.HasPrefix(arg, "-") || name == "" {
+			return nil, fmt.Errorf("unexpected argument %q, flags must look like -name=value", arg)
+		}
+
+		name, value, hasValue := strings.Cut(name, "=")
+		if !hasValue {
+			value = "true"
+			if i+1 < len(args) && !looksLikeFlag(args[i+1]) {
+				value = args[i+1]
+				i++
+			}
+		}
+		flags[optionName(name)] = value
+	}
+	return flags, nil
+}
+
+// looksLikeFlag reports whether arg starts with dashes followed by a letter, so negative numbers
+// and durations (e.g. "-1", "-5s") are values
+func looksLikeFlag(arg string) bool {
+	name := strings.TrimLeft(arg, "-")
+	return name != arg && name != "" && unicode.IsLetter(rune(name[0]))
+}
+
+// readConfigFile flattens YAML file into option names, lists become comma separated values
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var root map[string]any
+	err = yaml.Unmarshal(data, &root)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	err = flattenConfig("", root, values)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func flattenConfig(prefix string, value any, values map[string]string) error {
+	switch typedValue := value.(type) {
+	case map[string]any:
+		for key, child := range typedValue {
+			name := optionName(key)
+			if prefix != "" {
+				name = prefix + "_" + name
+			}
+			err := flattenConfig(name, child, values)
+			if err != nil {
+				return err
+			}
+		}
+	case []any:
+		items := make([]string, len(typedValue))
+		for i, item := range typedValue {
+			switch item.(type) {
+			case map[string]any, []any:
+				return fmt.Errorf("%s must be a list of values", prefix)
+			}
+			items[i] = fmt.Sprint(item)
+		}
+		values[prefix] = strings.Join(items, ",")
+	case nil:
+		values[prefix] = ""
+	default:
+		values[prefix] = fmt.Sprint(typedValue)
+	}
+	return nil
+}
+
+func optionName(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+func (configLoader *loader) lookup(name string) (string, bool) {
+	configLoader.used[name] = true
+	if value, ok := configLoader.flags[name]; ok {
+		return value, true
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true
+	}
+	value, ok := configLoader.file[name]
+	return value, ok
+}
+
+func (configLoader *loader) errorf(name, format string, args ...any) {
+	configLoader.errs = append(configLoader.errs, fmt.Errorf("%s: %s", name, fmt.Sprintf(format, args...)))
+}
+
+func (configLoader *loader) String(name, defaultValue string) string {
+	value, ok := configLoader.lookup(name)
+	if !ok || value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func (configLoader *loader) Required(name string) string {
+	value := configLoader.String(name, "")
+	if value == "" {
+		configLoader.errorf(name, "is required")
+	}
+	return value
+}
+
+func (configLoader *loader) Int(name string, defaultValue int) int {
+	value := configLoader.String(name, "")
+	if value == "" {
+		return defaultValue
+	}
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		configLoader.errorf(name, "must be a number, got %q", value)
+		return defaultValue
+	}
+	return intValue
+}
+
+func (configLoader *loader) Bool(name string, defaultValue bool) bool {
+	value := configLoader.String(name, "")
+	if value == "" {
+		return defaultValue
+	}
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		configLoader.errorf(name, "must be a boolean, got %q", value)
+		return defaultValue
+	}
+	return boolValue
+}
+
+// Duration reads duration like "90s" or "1h30m", plain number is counted in unit,
+// so that existing *_SECONDS and *_DAYS variables keep working
+func (configLoader *loader) Duration(name string, defaultValue, unit time.Duration) time.Duration {
+	value := configLoader.String(name, "")
+	if value == "" {
+		return defaultValue
+	}
+	if number, err := strconv.Atoi(value); err == nil {
+		return time.Duration(number) * unit
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		configLoader.errorf(name, "must be a duration like 30s or 1h, got %q", value)
+		return defaultValue
+	}
+	return duration
+}
+
+// List reads comma separated values
+func (configLoader *loader) List(name string) []string {
+	var items []string
+	for _, item := range strings.Split(configLoader.String(name, ""), ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (configLoader *loader) Positive(name string, value int64) {
+	if value <= 0 {
+		configLoader.errorf(name, "must be positive")
+	}
+}
+
+// Err reports all invalid options at once, flags and config file keys that do not match any option are errors too
+func (configLoader *loader) Err() error {
+	for _, name := range slices.Sorted(maps.Keys(configLoader.flags)) {
+		if !configLoader.used[name] {
+			configLoader.errorf(name, "unknown flag -%s", strings.ToLower(strings.ReplaceAll(name, "_", "-")))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(configLoader.file)) {
+		if !configLoader.used[name] {
+			configLoader.errorf(name, "unknown option in config file")
+		}
+	}
+	return errors.Join(configLoader.errs...)
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("cannot write %s: %s", name, err)
+	}
+	return path
+}
+
+func TestParseFlags(t *testing.T) {
+	flags, err := parseFlags([]string{
+		"-server-addr=:9000", "--log-level", "debug", "-demo-mode", "-jwt-secret=a=b",
+		"--port", "-1", "-timeout", "-5s", "-secure", "--payment-key=-key",
+	})
+	if err != nil {
+		t.Fatalf("cannot parse flags: %s", err)
+	}
+	expected := map[string]string{
+		"SERVER_ADDR": ":9000", "LOG_LEVEL": "debug", "DEMO_MODE": "true", "JWT_SECRET": "a=b",
+		"PORT": "-1", "TIMEOUT": "-5s", "SECURE": "true", "PAYMENT_KEY": "-key",
+	}
+	for name, value := range expected {
+		if flags[name] != value {
+			t.Fatalf("expected %s=%q, got %q", name, value, flags[name])
+		}
+	}
+
+	for _, args := range [][]string{{"server-addr=:9000"}, {"-"}} {
+		_, err := parseFlags(args)
+		if err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+}
+
+func TestReadConfigFile(t *testing.T) {
+	path := writeTestFile(t, "config.yaml", `
+server:
+  addr: ":9000"
+  read-timeout-seconds: 5
+password_min_length: 12
+password_banned_substrings: [snserver, qwerty]
+platform:
+`)
+	values, err := readConfigFile(path)
+	if err != nil {
+		t.Fatalf("cannot read config file: %s", err)
+	}
+	expected := map[string]string{
+		"SERVER_ADDR":                 ":9000",
+		"SERVER_READ_TIMEOUT_SECONDS": "5",
+		"PASSWORD_MIN_LENGTH":         "12",
+		"PASSWORD_BANNED_SUBSTRINGS":  "snserver,qwerty",
+		"PLATFORM":                    "",
+	}
+	if len(values) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, values)
+	}
+	for name, value := range expected {
+		if values[name] != value {
+			t.Fatalf("expected %s=%q, got %q", name, value, values[name])
+		}
+	}
+
+	_, err = readConfigFile(writeTestFile(t, "nested.yaml", "providers: [{name: google}]\n"))
+	if err == nil {
+		t.Fatal("expected error for list of maps")
+	}
+}
+
+func TestLoaderPrecedence(t *testing.T) {
+	configFile := writeTestFile(t, "config.yaml", "server_addr: file\nlog_level: file\nplatform: file\n")
+	envFile := writeTestFile(t, ".env", "LOG_LEVEL=dotenv\n")
+	t.Setenv("PLATFORM", "env")
+	// godotenv does not override variables, drop the one it sets when the test ends
+	t.Setenv("LOG_LEVEL", "")
+	os.Unsetenv("LOG_LEVEL")
+
+	configLoader, err := newLoader([]string{"-config-file=" + configFile, "-env-file=" + envFile, "-server-addr=flag"})
+	if err != nil {
+		t.Fatalf("cannot create loader: %s", err)
+	}
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"SERVER_ADDR", "flag"},
+		{"PLATFORM", "env"},
+		{"LOG_LEVEL", "dotenv"},
+		{"MISSING", "default"},
+	}
+	for _, test := range tests {
+		if value := configLoader.String(test.name, "default"); value != test.expected {
+			t.Fatalf("expected %s=%q, got %q", test.name, test.expected, value)
+		}
+	}
+	if err := configLoader.Err(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, err = newLoader([]string{"-env-file=" + filepath.Join(t.TempDir(), "missing.env")})
+	if err == nil {
+		t.Fatal("expected error for missing explicit env file")
+	}
+}
+
+func TestLoaderValues(t *testing.T) {
+	configLoader := &loader{
+		flags: map[string]string{
+			"COUNT":       "12",
+			"BAD_COUNT":   "twelve",
+			"ENABLED":     "yes",
+			"TIMEOUT":     "90",
+			"TTL":         "1h30m",
+			"BAD_TTL":     "soon",
+			"ORIGINS":     " a.com, ,b.com ",
+			"UNKNOWN_OPT": "1",
+		},
+		file: map[string]string{"UNKNOWN_KEY": "1"},
+		used: make(map[string]bool),
+	}
+
+	if value := configLoader.Int("COUNT", 1); value != 12 {
+		t.Fatalf("expected 12, got %d", value)
+	}
+	if value := configLoader.Int("BAD_COUNT", 1); value != 1 {
+		t.Fatalf("invalid number must give default, got %d", value)
+	}
+	if value := configLoader.Bool("ENABLED", true); !value {
+		t.Fatal("invalid boolean must give default")
+	}
+	if value := configLoader.Duration("TIMEOUT", time.Second, time.Second); value != 90*time.Second {
+		t.Fatalf("plain number must be counted in unit, got %s", value)
+	}
+	if value := configLoader.Duration("TTL", time.Second, 24*time.Hour); value != 90*time.Minute {
+		t.Fatalf("expected 1h30m, got %s", value)
+	}
+	if value := configLoader.Duration("BAD_TTL", time.Second, time.Second); value != time.Second {
+		t.Fatalf("invalid duration must give default, got %s", value)
+	}
+	if value := configLoader.List("ORIGINS"); !slices.Equal(value, []string{"a.com", "b.com"}) {
+		t.Fatalf("expected [a.com b.com], got %v", value)
+	}
+	configLoader.Positive("ZERO", 0)
+	configLoader.Required("MISSING_SECRET")
+
+	// every problem is reported at once
+	err := configLoader.Err()
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, name := range []string{"BAD_COUNT", "ENABLED", "BAD_TTL", "ZERO", "MISSING_SECRET", "unknown flag -unknown-opt", "UNKNOWN_KEY: unknown option in config file"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Fatalf("error does not mention %s: %s", name, err)
+		}
+	}
+}
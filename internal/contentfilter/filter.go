@@ -0,0 +1,338 @@
+package contentfilter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	ActionMask   = "mask"
+	ActionReject = "reject"
+	ActionFlag   = "flag"
+
+	mask = "****"
+)
+
+var Actions = []string{ActionMask, ActionReject, ActionFlag}
+
+// Rule is a word or a phrase (words separated by spaces) and what to do with messages containing it
+type Rule struct {
+	Term   string
+	Action string
+}
+
+// RuleSource provides rules for the filter, it is asked again on every reload
+type RuleSource interface {
+	Rules(ctx context.Context) ([]Rule, error)
+}
+
+// Result of checking a text against the rules
+type Result struct {
+	// Text with masked terms
+	Text     string
+	Rejected bool
+	Flagged  bool
+	Matched  []Rule
+}
+
+// Filter matches texts against rules from its source. Rules are swapped atomically on Reload,
+// so the filter can be reloaded while messages are being checked.
+type Filter struct {
+	source RuleSource
+	rules  atomic.Pointer[ruleSet]
+}
+
+func NewFilter(source RuleSource) *Filter {
+	filter := &Filter{source: source}
+	filter.rules.Store(&ruleSet{})
+	return filter
+}
+
+func (filter *Filter) Source() RuleSource {
+	return filter.source
+}
+
+// Reload replaces rules with the current ones from the source, on error the old rules are kept
+func (filter *Filter) Reload(ctx context.Context) error {
+	rules, err := filter.source.Rules(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot load content filter rules: %w", err)
+	}
+
+	compiled, err := compileRules(rules)
+	if err != nil {
+		return err
+	}
+	filter.rules.Store(compiled)
+	return nil
+}
+
+// Check finds rule terms in text. Words are compared after Unicode compatibility normalization,
+// removing diacritics, lowercasing and replacing leetspeak characters, so "F0rnáx!" matches "fornax".
+func (filter *Filter) Check(text string) Result {
+	rules := filter.rules.Load()
+	result := Result{Text: text}
+
+	tokens := tokenize(text)
+	var maskedSpans []span
+	for i := range tokens {
+		// a rule is matched once per occurrence, even if several readings of the token or copies of the rule match it
+		var tokenMatches []Rule
+		for _, candidate := range tokens[i].candidates {
+			for _, rule := range rules.byFirstWord[candidate.word] {
+				if slices.Contains(tokenMatches, rule.Rule) {
+					continue
+				}
+				matchedSpan, ok := matchRule(tokens[i:], rule.words)
+				if !ok {
+					continue
+				}
+				tokenMatches = append(tokenMatches, rule.Rule)
+				result.Matched = append(result.Matched, rule.Rule)
+				switch rule.Action {
+				case ActionReject:
+					result.Rejected = true
+				case ActionFlag:
+					result.Flagged = true
+				case ActionMask:
+					maskedSpans = append(maskedSpans, matchedSpan)
+				}
+			}
+		}
+	}
+
+	result.Text = applyMasks(text, maskedSpans)
+	return result
+}
+
+// ValidateRule checks that rule can be used by the filter
+func ValidateRule(rule Rule) error {
+	_, err := compileRules([]Rule{rule})
+	return err
+}
+
+type compiledRule struct {
+	Rule
+	words []string
+}
+
+type ruleSet struct {
+	byFirstWord map[string][]compiledRule
+}
+
+func compileRules(rules []Rule) (*ruleSet, error) {
+	compiled := &ruleSet{byFirstWord: make(map[string][]compiledRule)}
+	for _, rule := range rules {
+		if !slices.Contains(Actions, rule.Action) {
+			return nil, fmt.Errorf("rule %q has unknown action %q", rule.Term, rule.Action)
+		}
+
+		var words []string
+		for _, token := range tokenize(rule.Term) {
+			words = append(words, token.candidates[0].word)
+		}
+		if len(words) == 0 {
+			return nil, fmt.Errorf("rule %q has no words", rule.Term)
+		}
+		compiled.byFirstWord[words[0]] = append(compiled.byFirstWord[words[0]], compiledRule{Rule: rule, words: words})
+	}
+	return compiled, nil
+}
+
+type span struct {
+	start, end int
+}
+
+// candidate is one way of reading a token, e.g. "$h1t!" is read as "shiti" and, without symbols at one or both
+// of its ends, as "shit", "hiti" and "hit". Letters spelled out with separators ("f.o.r.n.a.x") are also read
+// as one word, such a candidate covers several tokens.
+type candidate struct {
+	word string
+	span span
+	// length is the number of tokens the candidate covers
+	length int
+}
+
+type token struct {
+	candidates []candidate
+}
+
+// leetspeak replacements, symbols among them are also treated as parts of words
+var leetReplacer = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'@': 'a',
+	'$': 's',
+	'!': 'i',
+	'+': 't',
+	'|': 'l',
+}
+
+func isLeetSymbol(char rune) bool {
+	_, ok := leetReplacer[char]
+	return ok && !unicode.IsDigit(char)
+}
+
+func isWordChar(char rune) bool {
+	return unicode.IsLetter(char) || unicode.IsDigit(char) || unicode.IsMark(char) || isLeetSymbol(char)
+}
+
+// tokenize splits text into words on every rune that is not a letter, digit, combining mark or leetspeak symbol
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, char := range text {
+		if isWordChar(char) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = appendToken(tokens, text, span{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = appendToken(tokens, text, span{start, len(text)})
+	}
+	joinSpelledOut(tokens, text)
+	return tokens
+}
+
+func appendToken(tokens []token, text string, fullSpan span) []token {
+	full := text[fullSpan.start:fullSpan.end]
+	if strings.TrimFunc(full, isLeetSymbol) == "" {
+		return tokens
+	}
+
+	var newToken token
+	// symbols can stand for letters at either end of the word, so every combination of trimming them is a reading
+	for _, trimmed := range []string{
+		full,
+		strings.TrimRightFunc(full, isLeetSymbol),
+		strings.TrimLeftFunc(full, isLeetSymbol),
+		strings.TrimFunc(full, isLeetSymbol),
+	} {
+		trimmedStart := fullSpan.start + strings.Index(full, trimmed)
+		newCandidate := candidate{word: normalizeWord(trimmed), span: span{trimmedStart, trimmedStart + len(trimmed)}, length: 1}
+		if !slices.Contains(newToken.candidates, newCandidate) {
+			newToken.candidates = append(newToken.candidates, newCandidate)
+		}
+	}
+	return append(tokens, newToken)
+}
+
+// joinSpelledOut adds candidates for runs of single characters separated by a single non-space rune,
+// e.g. "f.o.r.n.a.x" is read as "fornax". Every character of the run starts such a candidate, which ends with the run.
+func joinSpelledOut(tokens []token, text string) {
+	for runStart := 0; runStart < len(tokens); {
+		runEnd := runStart + 1
+		for runEnd < len(tokens) && isSpelledOut(tokens[runEnd-1], tokens[runEnd], text) {
+			runEnd++
+		}
+		for i := runStart; runEnd-i > 1; i++ {
+			var word strings.Builder
+			for _, spelled := range tokens[i:runEnd] {
+				word.WriteString(spelled.candidates[0].word)
+			}
+			tokens[i].candidates = append(tokens[i].candidates, candidate{
+				word:   word.String(),
+				span:   span{tokens[i].candidates[0].span.start, tokens[runEnd-1].candidates[0].span.end},
+				length: runEnd - i,
+			})
+		}
+		runStart = runEnd
+	}
+}
+
+// isSpelledOut reports whether both tokens are single characters and only one non-space rune is between them
+func isSpelledOut(previous, next token, text string) bool {
+	previousSpan, nextSpan := previous.candidates[0].span, next.candidates[0].span
+	if utf8.RuneCountInString(text[previousSpan.start:previousSpan.end]) != 1 || utf8.RuneCountInString(text[nextSpan.start:nextSpan.end]) != 1 {
+		return false
+	}
+	separator := text[previousSpan.end:nextSpan.start]
+	return utf8.RuneCountInString(separator) == 1 && !strings.ContainsFunc(separator, unicode.IsSpace)
+}
+
+func normalizeWord(word string) string {
+	var builder strings.Builder
+	for _, char := range norm.NFKD.String(word) {
+		if unicode.Is(unicode.Mn, char) {
+			continue
+		}
+		if replacement, ok := leetReplacer[char]; ok {
+			char = replacement
+		}
+		builder.WriteRune(unicode.ToLower(char))
+	}
+	return builder.String()
+}
+
+// matchRule checks whether tokens start with rule words and returns the matched part of the text
+func matchRule(tokens []token, words []string) (span, bool) {
+	var matched span
+	position := 0
+	for i, word := range words {
+		if position >= len(tokens) {
+			return span{}, false
+		}
+		found := false
+		for _, candidate := range tokens[position].candidates {
+			if candidate.word != word {
+				continue
+			}
+			if i == 0 {
+				matched.start = candidate.span.start
+			}
+			matched.end = candidate.span.end
+			position += candidate.length
+			found = true
+			break
+		}
+		if !found {
+			return span{}, false
+		}
+	}
+	return matched, true
+}
+
+// applyMasks replaces every span with the mask, overlapping spans are merged and masked once
+func applyMasks(text string, spans []span) string {
+	if len(spans) == 0 {
+		return text
+	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+
+	var builder strings.Builder
+	last := 0
+	for _, maskedSpan := range spans {
+		if maskedSpan.start < last {
+			last = max(last, maskedSpan.end)
+			continue
+		}
+		builder.WriteString(text[last:maskedSpan.start])
+		builder.WriteString(mask)
+		last = maskedSpan.end
+	}
+	builder.WriteString(text[last:])
+	return builder.String()
+}
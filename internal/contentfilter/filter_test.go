@@ -0,0 +1,170 @@
+package contentfilter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func newTestFilter(t *testing.T, rules ...Rule) *Filter {
+	t.Helper()
+	filter := NewFilter(StaticSource(rules))
+	err := filter.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("cannot load rules: %s", err)
+	}
+	return filter
+}
+
+func TestFilterCheck(t *testing.T) {
+	filter := newTestFilter(t,
+		Rule{Term: "fornax", Action: ActionMask},
+		Rule{Term: "shit", Action: ActionMask},
+		Rule{Term: "sharbert", Action: ActionReject},
+		Rule{Term: "buy now", Action: ActionFlag},
+	)
+
+	tests := []struct {
+		name     string
+		text     string
+		expected Result
+	}{
+		{"clean text", "hello world", Result{Text: "hello world"}},
+		{"masked word", "what the fornax is this", Result{Text: "what the **** is this", Matched: []Rule{{"fornax", ActionMask}}}},
+		{"case and diacritics", "FORNÁX!", Result{Text: "****!", Matched: []Rule{{"fornax", ActionMask}}}},
+		{"leetspeak", "f0rn@x", Result{Text: "****", Matched: []Rule{{"fornax", ActionMask}}}},
+		{"symbols at both ends", "$h1t!", Result{Text: "****!", Matched: []Rule{{"shit", ActionMask}}}},
+		{"symbol at the start", "$hit happens", Result{Text: "**** happens", Matched: []Rule{{"shit", ActionMask}}}},
+		{"symbol at the end", "oh sh!t!", Result{Text: "oh ****!", Matched: []Rule{{"shit", ActionMask}}}},
+		{"spelled out", "f.o.r.n.a.x", Result{Text: "****", Matched: []Rule{{"fornax", ActionMask}}}},
+		{"spelled out in a sentence", "what the f-o-r-n-a-x, buy.now", Result{Text: "what the ****, buy.now", Flagged: true, Matched: []Rule{{"fornax", ActionMask}, {"buy now", ActionFlag}}}},
+		{"spaced letters", "f o r n a x", Result{Text: "f o r n a x"}},
+		{"every occurrence", "fornax and Fornax", Result{Text: "**** and ****", Matched: []Rule{{"fornax", ActionMask}, {"fornax", ActionMask}}}},
+		{"part of another word", "fornaxes", Result{Text: "fornaxes"}},
+		{"rejected word", "sharbert", Result{Text: "sharbert", Rejected: true, Matched: []Rule{{"sharbert", ActionReject}}}},
+		{"flagged phrase", "Buy  NOW, please", Result{Text: "Buy  NOW, please", Flagged: true, Matched: []Rule{{"buy now", ActionFlag}}}},
+		{"words of the phrase apart", "buy it now", Result{Text: "buy it now"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := filter.Check(test.text)
+			if result.Text != test.expected.Text || result.Rejected != test.expected.Rejected || result.Flagged != test.expected.Flagged || !slices.Equal(result.Matched, test.expected.Matched) {
+				t.Fatalf("expected %+v, got %+v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestFilterOverlappingMasks(t *testing.T) {
+	rules := []Rule{
+		{Term: "fornax", Action: ActionMask},
+		{Term: "fornax cluster", Action: ActionMask},
+		{Term: "buy now", Action: ActionMask},
+		{Term: "now please", Action: ActionMask},
+	}
+	reversed := slices.Clone(rules)
+	slices.Reverse(reversed)
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"same start", "the fornax cluster is near", "the **** is near"},
+		{"starting inside another", "buy now please", "****"},
+		{"separate", "fornax, buy now", "****, ****"},
+	}
+	// the longest span has to win whatever the order of rules
+	for _, filter := range []*Filter{newTestFilter(t, rules...), newTestFilter(t, reversed...)} {
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				result := filter.Check(test.text)
+				if result.Text != test.expected {
+					t.Fatalf("expected %q, got %q", test.expected, result.Text)
+				}
+			})
+		}
+	}
+}
+
+func TestFilterMatchesRuleOncePerOccurrence(t *testing.T) {
+	filter := newTestFilter(t,
+		Rule{Term: "fornax", Action: ActionFlag},
+		Rule{Term: "fornax", Action: ActionFlag},
+	)
+
+	result := filter.Check("fornax and fornax")
+	expected := []Rule{{"fornax", ActionFlag}, {"fornax", ActionFlag}}
+	if !slices.Equal(result.Matched, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, result.Matched)
+	}
+}
+
+type failingSource struct{}
+
+func (failingSource) Rules(ctx context.Context) ([]Rule, error) {
+	return nil, errors.New("database is down")
+}
+
+func TestFilterReload(t *testing.T) {
+	filter := newTestFilter(t, Rule{Term: "fornax", Action: ActionMask})
+
+	// rules are kept when the source fails or gives invalid rules
+	filter.source = failingSource{}
+	if err := filter.Reload(context.Background()); err == nil {
+		t.Fatal("expected source error")
+	}
+	filter.source = StaticSource{{Term: "fornax", Action: "delete"}}
+	if err := filter.Reload(context.Background()); err == nil {
+		t.Fatal("expected invalid rule error")
+	}
+	if result := filter.Check("fornax"); result.Text != "****" {
+		t.Fatalf("old rules were not kept: %+v", result)
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	tests := []struct {
+		rule  Rule
+		valid bool
+	}{
+		{Rule{Term: "fornax", Action: ActionMask}, true},
+		{Rule{Term: "buy now", Action: ActionFlag}, true},
+		{Rule{Term: "fornax", Action: "delete"}, false},
+		{Rule{Term: " ... ", Action: ActionReject}, false},
+	}
+	for _, test := range tests {
+		err := ValidateRule(test.rule)
+		if (err == nil) != test.valid {
+			t.Fatalf("rule %+v: expected valid %t, got %v", test.rule, test.valid, err)
+		}
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.txt")
+	err := os.WriteFile(path, []byte("# profanity\nmask fornax\n\nflag  buy now \n"), 0o600)
+	if err != nil {
+		t.Fatalf("cannot write rules: %s", err)
+	}
+	rules, err := FileSource{Path: path}.Rules(context.Background())
+	if err != nil {
+		t.Fatalf("cannot read rules: %s", err)
+	}
+	expected := []Rule{{Term: "fornax", Action: ActionMask}, {Term: "buy now", Action: ActionFlag}}
+	if !slices.Equal(rules, expected) {
+		t.Fatalf("expected %v, got %v", expected, rules)
+	}
+
+	err = os.WriteFile(path, []byte("fornax\n"), 0o600)
+	if err != nil {
+		t.Fatalf("cannot write rules: %s", err)
+	}
+	_, err = FileSource{Path: path}.Rules(context.Background())
+	if err == nil {
+		t.Fatal("expected error for line without action")
+	}
+}
@@ -0,0 +1,77 @@
+package contentfilter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ech00wv/SNserver/internal/database"
+)
+
+// FileSource reads rules from a file with one "<action> <term>" rule per line, e.g. "mask fornax".
+// Empty lines and lines starting with # are skipped.
+type FileSource struct {
+	Path string
+}
+
+func (fileSource FileSource) Rules(ctx context.Context) ([]Rule, error) {
+	file, err := os.Open(fileSource.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules []Rule
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		action, term, found := strings.Cut(line, " ")
+		term = strings.TrimSpace(term)
+		if !found || term == "" {
+			return nil, fmt.Errorf("line %d must be \"<action> <term>\"", lineNumber)
+		}
+		rules = append(rules, Rule{Term: term, Action: action})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// DatabaseSource reads rules from content_filter_rules table
+type DatabaseSource struct {
+	Queries database.Querier
+}
+
+func (databaseSource DatabaseSource) Rules(ctx context.Context) ([]Rule, error) {
+	dbRules, err := databaseSource.Queries.GetContentFilterRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]Rule, len(dbRules))
+	for i, dbRule := range dbRules {
+		rules[i] = Rule{Term: dbRule.Term, Action: dbRule.Action}
+	}
+	return rules, nil
+}
+
+// DefaultRules are the rules databases are seeded with by migrations, sources without a database
+// (e.g. demo mode) start with them too
+var DefaultRules = []Rule{
+	{Term: "kerfuffle", Action: ActionMask},
+	{Term: "sharbert", Action: ActionMask},
+	{Term: "fornax", Action: ActionMask},
+}
+
+// StaticSource provides the same rules on every reload, e.g. DefaultRules in demo mode
+type StaticSource []Rule
+
+func (staticSource StaticSource) Rules(ctx context.Context) ([]Rule, error) {
+	return staticSource, nil
+}
@@ -11,6 +11,46 @@ import (
 	"github.com/google/uuid"
 )
 
+type Block struct {
+	BlockerID uuid.UUID `json:"blocker_id"`
+	BlockedID uuid.UUID `json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ContentFilterRule struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Term      string    `json:"term"`
+	Action    string    `json:"action"`
+}
+
+type DataExport struct {
+	ID          uuid.UUID      `json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	UserID      uuid.UUID      `json:"user_id"`
+	Status      string         `json:"status"`
+	StorageKey  sql.NullString `json:"storage_key"`
+	Error       sql.NullString `json:"error"`
+	CompletedAt sql.NullTime   `json:"completed_at"`
+	ExpiresAt   sql.NullTime   `json:"expires_at"`
+}
+
+type LoginAttempt struct {
+	ID        uuid.UUID     `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	Email     string        `json:"email"`
+	IpAddress string        `json:"ip_address"`
+	UserID    uuid.NullUUID `json:"user_id"`
+	Succeeded bool          `json:"succeeded"`
+}
+
+type LoginThrottle struct {
+	Key           string    `json:"key"`
+	FailedCount   int32     `json:"failed_count"`
+	LastAttemptAt time.Time `json:"last_attempt_at"`
+}
+
 type Message struct {
 	ID        uuid.UUID `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
@@ -19,6 +59,81 @@ type Message struct {
 	UserID    uuid.UUID `json:"user_id"`
 }
 
+type ModerationAction struct {
+	ID           uuid.UUID     `json:"id"`
+	CreatedAt    time.Time     `json:"created_at"`
+	ModeratorID  uuid.NullUUID `json:"moderator_id"`
+	ReportID     uuid.NullUUID `json:"report_id"`
+	Action       string        `json:"action"`
+	TargetUserID uuid.NullUUID `json:"target_user_id"`
+	MessageID    uuid.NullUUID `json:"message_id"`
+	Note         string        `json:"note"`
+}
+
+type Mute struct {
+	MuterID   uuid.UUID `json:"muter_id"`
+	MutedID   uuid.UUID `json:"muted_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OauthAuthorizationCode struct {
+	CodeHash      string    `json:"code_hash"`
+	CreatedAt     time.Time `json:"created_at"`
+	ClientID      string    `json:"client_id"`
+	UserID        uuid.UUID `json:"user_id"`
+	RedirectUri   string    `json:"redirect_uri"`
+	Scopes        []string  `json:"scopes"`
+	CodeChallenge string    `json:"code_challenge"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+type OauthClient struct {
+	ID           string         `json:"id"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	Name         string         `json:"name"`
+	SecretHash   sql.NullString `json:"secret_hash"`
+	RedirectUris []string       `json:"redirect_uris"`
+	Scopes       []string       `json:"scopes"`
+	UserID       uuid.UUID      `json:"user_id"`
+}
+
+type OidcAuthRequest struct {
+	State        string        `json:"state"`
+	CreatedAt    time.Time     `json:"created_at"`
+	Provider     string        `json:"provider"`
+	CodeVerifier string        `json:"code_verifier"`
+	Nonce        string        `json:"nonce"`
+	LinkUserID   uuid.NullUUID `json:"link_user_id"`
+	ExpiresAt    time.Time     `json:"expires_at"`
+}
+
+type PaymentEvent struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	Event     string    `json:"event"`
+}
+
+type PersonalAccessToken struct {
+	ID         uuid.UUID    `json:"id"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+	UserID     uuid.UUID    `json:"user_id"`
+	Name       string       `json:"name"`
+	TokenHash  string       `json:"token_hash"`
+	Scopes     []string     `json:"scopes"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+}
+
+type RateLimitBucket struct {
+	Key       string    `json:"key"`
+	Tokens    float64   `json:"tokens"`
+	Allowed   bool      `json:"allowed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 type RefreshToken struct {
 	Token     string       `json:"token"`
 	CreatedAt time.Time    `json:"created_at"`
@@ -28,11 +143,44 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime `json:"revoked_at"`
 }
 
+type Report struct {
+	ID             uuid.UUID      `json:"id"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	ReporterID     uuid.NullUUID  `json:"reporter_id"`
+	TargetType     string         `json:"target_type"`
+	ReportedUserID uuid.UUID      `json:"reported_user_id"`
+	MessageID      uuid.NullUUID  `json:"message_id"`
+	MessageBody    sql.NullString `json:"message_body"`
+	Reason         string         `json:"reason"`
+	Details        string         `json:"details"`
+	Status         string         `json:"status"`
+	ClaimedBy      uuid.NullUUID  `json:"claimed_by"`
+	ClaimedAt      sql.NullTime   `json:"claimed_at"`
+	Resolution     sql.NullString `json:"resolution"`
+	ResolvedAt     sql.NullTime   `json:"resolved_at"`
+}
+
 type User struct {
-	ID             uuid.UUID    `json:"id"`
-	CreatedAt      time.Time    `json:"created_at"`
-	UpdatedAt      time.Time    `json:"updated_at"`
-	Email          string       `json:"email"`
-	HashedPassword string       `json:"hashed_password"`
-	IsPremium      sql.NullBool `json:"is_premium"`
+	ID                  uuid.UUID    `json:"id"`
+	CreatedAt           time.Time    `json:"created_at"`
+	UpdatedAt           time.Time    `json:"updated_at"`
+	Email               string       `json:"email"`
+	HashedPassword      string       `json:"hashed_password"`
+	IsPremium           sql.NullBool `json:"is_premium"`
+	IsAdmin             bool         `json:"is_admin"`
+	DeletionScheduledAt sql.NullTime `json:"deletion_scheduled_at"`
+	SuspendedUntil      sql.NullTime `json:"suspended_until"`
+	Status              string       `json:"status"`
+	StatusReason        string       `json:"status_reason"`
+}
+
+type UserIdentity struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
 }
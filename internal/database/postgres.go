@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ErrDuplicate is returned by every backend when a unique value (e.g. user's email) is already taken,
+// so services do not need to know driver errors
+var ErrDuplicate = errors.New("duplicate key")
+
+// pqUniqueViolation is SQLSTATE of unique constraint violations in Postgres
+const pqUniqueViolation = "23505"
+
+// PostgresQuerier is Queries returning ErrDuplicate from queries that can violate unique constraints
+type PostgresQuerier struct {
+	*Queries
+}
+
+var _ Querier = (*PostgresQuerier)(nil)
+
+func NewPostgresQuerier(db DBTX) *PostgresQuerier {
+	return &PostgresQuerier{Queries: New(db)}
+}
+
+// postgresDuplicate wraps unique constraint violations with ErrDuplicate, other errors are returned as they are
+func postgresDuplicate(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation {
+		return fmt.Errorf("%w: %w", ErrDuplicate, err)
+	}
+	return err
+}
+
+func (q *PostgresQuerier) CreateContentFilterRule(ctx context.Context, arg CreateContentFilterRuleParams) (ContentFilterRule, error) {
+	rule, err := q.Queries.CreateContentFilterRule(ctx, arg)
+	return rule, postgresDuplicate(err)
+}
+
+func (q *PostgresQuerier) CreatePersonalAccessToken(ctx context.Context, arg CreatePersonalAccessTokenParams) (PersonalAccessToken, error) {
+	token, err := q.Queries.CreatePersonalAccessToken(ctx, arg)
+	return token, postgresDuplicate(err)
+}
+
+func (q *PostgresQuerier) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	user, err := q.Queries.CreateUser(ctx, arg)
+	return user, postgresDuplicate(err)
+}
+
+func (q *PostgresQuerier) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error) {
+	identity, err := q.Queries.CreateUserIdentity(ctx, arg)
+	return identity, postgresDuplicate(err)
+}
+
+func (q *PostgresQuerier) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	user, err := q.Queries.UpdateUser(ctx, arg)
+	return user, postgresDuplicate(err)
+}
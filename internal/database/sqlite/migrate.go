@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	schema "github.com/ech00wv/SNserver/sql/sqlite/schema"
+)
+
+// Migrate applies migrations that are newer than user_version of the database, e.g. 001_init.sql sets it to 1.
+// Every migration runs in its own transaction together with the version update.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	var current int64
+	err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&current)
+	if err != nil {
+		return err
+	}
+
+	// names are sorted, so migrations are applied in order of their versions
+	names, err := fs.Glob(schema.Migrations, "*.sql")
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return fmt.Errorf("migration %s does not start with version number", name)
+		}
+		if version <= current {
+			continue
+		}
+
+		err = applyMigration(ctx, db, name, version)
+		if err != nil {
+			return fmt.Errorf("cannot apply migration %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, name string, version int64) error {
+	migration, err := fs.ReadFile(schema.Migrations, name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, string(migration))
+	if err != nil {
+		return err
+	}
+	// PRAGMA does not take parameters
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", version))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,623 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/google/uuid"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Querier runs queries of the Postgres backend on SQLite, so services do not know which database they use.
+// It generates ids that Postgres generates with gen_random_uuid() and passes times in UTC,
+// because SQLite compares timestamps as text.
+type Querier struct {
+	queries *Queries
+}
+
+var _ database.Querier = (*Querier)(nil)
+
+// NewQuerier expects the database to be opened with _time_format=sqlite, see Migrate for its schema
+func NewQuerier(db DBTX) *Querier {
+	return &Querier{queries: New(db)}
+}
+
+func utc(t time.Time) time.Time {
+	return t.UTC()
+}
+
+func utcNull(t sql.NullTime) sql.NullTime {
+	return sql.NullTime{Time: t.Time.UTC(), Valid: t.Valid}
+}
+
+// duplicate wraps unique constraint violations with database.ErrDuplicate, other errors are returned as they are
+func duplicate(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && (sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY) {
+		return fmt.Errorf("%w: %w", database.ErrDuplicate, err)
+	}
+	return err
+}
+
+func convertAll[T, U any](items []T, convert func(T) U) []U {
+	converted := make([]U, len(items))
+	for i, item := range items {
+		converted[i] = convert(item)
+	}
+	return converted
+}
+
+func oauthClient(client OauthClient) database.OauthClient {
+	return database.OauthClient{
+		ID:           client.ID,
+		CreatedAt:    client.CreatedAt,
+		UpdatedAt:    client.UpdatedAt,
+		Name:         client.Name,
+		SecretHash:   client.SecretHash,
+		RedirectUris: client.RedirectUris,
+		Scopes:       client.Scopes,
+		UserID:       client.UserID,
+	}
+}
+
+func personalAccessToken(token PersonalAccessToken) database.PersonalAccessToken {
+	return database.PersonalAccessToken{
+		ID:         token.ID,
+		CreatedAt:  token.CreatedAt,
+		UpdatedAt:  token.UpdatedAt,
+		UserID:     token.UserID,
+		Name:       token.Name,
+		TokenHash:  token.TokenHash,
+		Scopes:     token.Scopes,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+	}
+}
+
+func (q *Querier) CancelUserDeletion(ctx context.Context, id uuid.UUID) error {
+	return q.queries.CancelUserDeletion(ctx, id)
+}
+
+func (q *Querier) CheckBlockBetween(ctx context.Context, arg database.CheckBlockBetweenParams) (bool, error) {
+	blocked, err := q.queries.CheckBlockBetween(ctx, CheckBlockBetweenParams(arg))
+	return blocked != 0, err
+}
+
+func (q *Querier) CheckOpenReportExists(ctx context.Context, arg database.CheckOpenReportExistsParams) (bool, error) {
+	exists, err := q.queries.CheckOpenReportExists(ctx, CheckOpenReportExistsParams{
+		ReporterID:     uuid.NullUUID{UUID: arg.ReporterID, Valid: true},
+		ReportedUserID: arg.ReportedUserID,
+		MessageID:      arg.MessageID,
+	})
+	return exists != 0, err
+}
+
+func (q *Querier) CheckUserExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	exists, err := q.queries.CheckUserExists(ctx, id)
+	return exists != 0, err
+}
+
+func (q *Querier) ClaimDataExport(ctx context.Context, staleBefore time.Time) (database.DataExport, error) {
+	export, err := q.queries.ClaimDataExport(ctx, utc(staleBefore))
+	return database.DataExport(export), err
+}
+
+func (q *Querier) ClaimReport(ctx context.Context, arg database.ClaimReportParams) (database.Report, error) {
+	report, err := q.queries.ClaimReport(ctx, ClaimReportParams{
+		ModeratorID: uuid.NullUUID{UUID: arg.ModeratorID, Valid: true},
+		ID:          arg.ID,
+	})
+	return database.Report(report), err
+}
+
+func (q *Querier) CompleteDataExport(ctx context.Context, arg database.CompleteDataExportParams) (int64, error) {
+	return q.queries.CompleteDataExport(ctx, CompleteDataExportParams{
+		StorageKey: sql.NullString{String: arg.StorageKey, Valid: true},
+		ExpiresAt:  sql.NullTime{Time: utc(arg.ExpiresAt), Valid: true},
+		ID:         arg.ID,
+	})
+}
+
+func (q *Querier) ConsumeOAuthAuthorizationCode(ctx context.Context, codeHash string) (database.OauthAuthorizationCode, error) {
+	code, err := q.queries.ConsumeOAuthAuthorizationCode(ctx, codeHash)
+	return database.OauthAuthorizationCode{
+		CodeHash:      code.CodeHash,
+		CreatedAt:     code.CreatedAt,
+		ClientID:      code.ClientID,
+		UserID:        code.UserID,
+		RedirectUri:   code.RedirectUri,
+		Scopes:        code.Scopes,
+		CodeChallenge: code.CodeChallenge,
+		ExpiresAt:     code.ExpiresAt,
+	}, err
+}
+
+func (q *Querier) ConsumeOIDCAuthRequest(ctx context.Context, state string) (database.OidcAuthRequest, error) {
+	authRequest, err := q.queries.ConsumeOIDCAuthRequest(ctx, state)
+	return database.OidcAuthRequest(authRequest), err
+}
+
+func (q *Querier) CreateBlock(ctx context.Context, arg database.CreateBlockParams) error {
+	return q.queries.CreateBlock(ctx, CreateBlockParams(arg))
+}
+
+func (q *Querier) CreateContentFilterRule(ctx context.Context, arg database.CreateContentFilterRuleParams) (database.ContentFilterRule, error) {
+	rule, err := q.queries.CreateContentFilterRule(ctx, CreateContentFilterRuleParams{
+		ID:     uuid.New(),
+		Term:   arg.Term,
+		Action: arg.Action,
+	})
+	return database.ContentFilterRule(rule), duplicate(err)
+}
+
+func (q *Querier) CreateDataExport(ctx context.Context, arg database.CreateDataExportParams) (database.DataExport, error) {
+	export, err := q.queries.CreateDataExport(ctx, CreateDataExportParams{
+		ID:     uuid.New(),
+		UserID: arg.UserID,
+		Status: arg.Status,
+	})
+	return database.DataExport(export), err
+}
+
+func (q *Querier) CreateLoginAttempt(ctx context.Context, arg database.CreateLoginAttemptParams) error {
+	return q.queries.CreateLoginAttempt(ctx, CreateLoginAttemptParams{
+		ID:        uuid.New(),
+		Email:     arg.Email,
+		IpAddress: arg.IpAddress,
+		UserID:    arg.UserID,
+		Succeeded: arg.Succeeded,
+	})
+}
+
+func (q *Querier) CreateMessage(ctx context.Context, arg database.CreateMessageParams) (database.Message, error) {
+	message, err := q.queries.CreateMessage(ctx, CreateMessageParams{
+		ID:     uuid.New(),
+		Body:   arg.Body,
+		UserID: arg.UserID,
+	})
+	return database.Message(message), err
+}
+
+func (q *Querier) CreateModerationAction(ctx context.Context, arg database.CreateModerationActionParams) error {
+	return q.queries.CreateModerationAction(ctx, CreateModerationActionParams{
+		ID:           uuid.New(),
+		ModeratorID:  arg.ModeratorID,
+		ReportID:     arg.ReportID,
+		Action:       arg.Action,
+		TargetUserID: arg.TargetUserID,
+		MessageID:    arg.MessageID,
+		Note:         arg.Note,
+	})
+}
+
+func (q *Querier) CreateMute(ctx context.Context, arg database.CreateMuteParams) error {
+	return q.queries.CreateMute(ctx, CreateMuteParams(arg))
+}
+
+func (q *Querier) CreateOAuthAuthorizationCode(ctx context.Context, arg database.CreateOAuthAuthorizationCodeParams) error {
+	return q.queries.CreateOAuthAuthorizationCode(ctx, CreateOAuthAuthorizationCodeParams{
+		CodeHash:      arg.CodeHash,
+		ClientID:      arg.ClientID,
+		UserID:        arg.UserID,
+		RedirectUri:   arg.RedirectUri,
+		Scopes:        arg.Scopes,
+		CodeChallenge: arg.CodeChallenge,
+		ExpiresAt:     utc(arg.ExpiresAt),
+	})
+}
+
+func (q *Querier) CreateOAuthClient(ctx context.Context, arg database.CreateOAuthClientParams) (database.OauthClient, error) {
+	client, err := q.queries.CreateOAuthClient(ctx, CreateOAuthClientParams{
+		ID:           arg.ID,
+		Name:         arg.Name,
+		SecretHash:   arg.SecretHash,
+		RedirectUris: arg.RedirectUris,
+		Scopes:       arg.Scopes,
+		UserID:       arg.UserID,
+	})
+	return oauthClient(client), err
+}
+
+func (q *Querier) CreateOIDCAuthRequest(ctx context.Context, arg database.CreateOIDCAuthRequestParams) error {
+	arg.ExpiresAt = utc(arg.ExpiresAt)
+	return q.queries.CreateOIDCAuthRequest(ctx, CreateOIDCAuthRequestParams(arg))
+}
+
+func (q *Querier) CreatePaymentEvent(ctx context.Context, arg database.CreatePaymentEventParams) error {
+	return q.queries.CreatePaymentEvent(ctx, CreatePaymentEventParams{
+		ID:     uuid.New(),
+		UserID: arg.UserID,
+		Event:  arg.Event,
+	})
+}
+
+func (q *Querier) CreatePersonalAccessToken(ctx context.Context, arg database.CreatePersonalAccessTokenParams) (database.PersonalAccessToken, error) {
+	token, err := q.queries.CreatePersonalAccessToken(ctx, CreatePersonalAccessTokenParams{
+		ID:        uuid.New(),
+		UserID:    arg.UserID,
+		Name:      arg.Name,
+		TokenHash: arg.TokenHash,
+		Scopes:    arg.Scopes,
+		ExpiresAt: utcNull(arg.ExpiresAt),
+	})
+	return personalAccessToken(token), duplicate(err)
+}
+
+func (q *Querier) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) error {
+	arg.ExpiresAt = utc(arg.ExpiresAt)
+	return q.queries.CreateRefreshToken(ctx, CreateRefreshTokenParams(arg))
+}
+
+func (q *Querier) CreateReport(ctx context.Context, arg database.CreateReportParams) (database.Report, error) {
+	report, err := q.queries.CreateReport(ctx, CreateReportParams{
+		ID:             uuid.New(),
+		ReporterID:     arg.ReporterID,
+		TargetType:     arg.TargetType,
+		ReportedUserID: arg.ReportedUserID,
+		MessageID:      arg.MessageID,
+		MessageBody:    arg.MessageBody,
+		Reason:         arg.Reason,
+		Details:        arg.Details,
+	})
+	return database.Report(report), err
+}
+
+func (q *Querier) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	user, err := q.queries.CreateUser(ctx, CreateUserParams{
+		ID:             uuid.New(),
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+	})
+	return database.User(user), duplicate(err)
+}
+
+func (q *Querier) CreateUserIdentity(ctx context.Context, arg database.CreateUserIdentityParams) (database.UserIdentity, error) {
+	identity, err := q.queries.CreateUserIdentity(ctx, CreateUserIdentityParams{
+		ID:       uuid.New(),
+		UserID:   arg.UserID,
+		Provider: arg.Provider,
+		Subject:  arg.Subject,
+		Email:    arg.Email,
+	})
+	return database.UserIdentity(identity), duplicate(err)
+}
+
+func (q *Querier) DeleteAllDataExports(ctx context.Context) ([]sql.NullString, error) {
+	return q.queries.DeleteAllDataExports(ctx)
+}
+
+func (q *Querier) DeleteAllLoginAttempts(ctx context.Context) error {
+	return q.queries.DeleteAllLoginAttempts(ctx)
+}
+
+func (q *Querier) DeleteBlock(ctx context.Context, arg database.DeleteBlockParams) error {
+	return q.queries.DeleteBlock(ctx, DeleteBlockParams(arg))
+}
+
+func (q *Querier) DeleteContentFilterRule(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	return q.queries.DeleteContentFilterRule(ctx, id)
+}
+
+func (q *Querier) DeleteDataExportsOfScheduledUsers(ctx context.Context, now time.Time) ([]sql.NullString, error) {
+	return q.queries.DeleteDataExportsOfScheduledUsers(ctx, sql.NullTime{Time: utc(now), Valid: true})
+}
+
+func (q *Querier) DeleteExpiredDataExports(ctx context.Context, now time.Time) ([]sql.NullString, error) {
+	return q.queries.DeleteExpiredDataExports(ctx, sql.NullTime{Time: utc(now), Valid: true})
+}
+
+func (q *Querier) DeleteExpiredOAuthAuthorizationCodes(ctx context.Context) error {
+	return q.queries.DeleteExpiredOAuthAuthorizationCodes(ctx)
+}
+
+func (q *Querier) DeleteExpiredOIDCAuthRequests(ctx context.Context) error {
+	return q.queries.DeleteExpiredOIDCAuthRequests(ctx)
+}
+
+func (q *Querier) DeleteIdleLoginThrottles(ctx context.Context, idleSeconds float64) error {
+	return q.queries.DeleteIdleLoginThrottles(ctx, idleSeconds)
+}
+
+func (q *Querier) DeleteIdleRateLimitBuckets(ctx context.Context, idleSeconds float64) error {
+	return q.queries.DeleteIdleRateLimitBuckets(ctx, idleSeconds)
+}
+
+func (q *Querier) DeleteLoginAttemptsOfScheduledUsers(ctx context.Context, now time.Time) error {
+	return q.queries.DeleteLoginAttemptsOfScheduledUsers(ctx, sql.NullTime{Time: utc(now), Valid: true})
+}
+
+func (q *Querier) DeleteLoginThrottle(ctx context.Context, key string) error {
+	return q.queries.DeleteLoginThrottle(ctx, key)
+}
+
+func (q *Querier) DeleteMessage(ctx context.Context, arg database.DeleteMessageParams) (uuid.UUID, error) {
+	return q.queries.DeleteMessage(ctx, DeleteMessageParams(arg))
+}
+
+func (q *Querier) DeleteMute(ctx context.Context, arg database.DeleteMuteParams) error {
+	return q.queries.DeleteMute(ctx, DeleteMuteParams(arg))
+}
+
+func (q *Querier) DeleteOAuthClient(ctx context.Context, arg database.DeleteOAuthClientParams) (string, error) {
+	return q.queries.DeleteOAuthClient(ctx, DeleteOAuthClientParams(arg))
+}
+
+func (q *Querier) DeletePersonalAccessToken(ctx context.Context, arg database.DeletePersonalAccessTokenParams) (uuid.UUID, error) {
+	return q.queries.DeletePersonalAccessToken(ctx, DeletePersonalAccessTokenParams(arg))
+}
+
+func (q *Querier) DeleteUsers(ctx context.Context) error {
+	return q.queries.DeleteUsers(ctx)
+}
+
+func (q *Querier) DeleteUsersScheduledForDeletion(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	return q.queries.DeleteUsersScheduledForDeletion(ctx, sql.NullTime{Time: utc(now), Valid: true})
+}
+
+func (q *Querier) FailDataExport(ctx context.Context, arg database.FailDataExportParams) error {
+	return q.queries.FailDataExport(ctx, FailDataExportParams{
+		Error: sql.NullString{String: arg.Error, Valid: true},
+		ID:    arg.ID,
+	})
+}
+
+func (q *Querier) GetActiveDataExportForUser(ctx context.Context, userID uuid.UUID) (database.DataExport, error) {
+	export, err := q.queries.GetActiveDataExportForUser(ctx, userID)
+	return database.DataExport(export), err
+}
+
+func (q *Querier) GetActivePersonalAccessToken(ctx context.Context, tokenHash string) (database.PersonalAccessToken, error) {
+	token, err := q.queries.GetActivePersonalAccessToken(ctx, tokenHash)
+	return personalAccessToken(token), err
+}
+
+func (q *Querier) GetAllMessages(ctx context.Context) ([]database.Message, error) {
+	messages, err := q.queries.GetAllMessages(ctx)
+	return convertAll(messages, func(message Message) database.Message { return database.Message(message) }), err
+}
+
+func (q *Querier) GetAllMessagesForAuthor(ctx context.Context, userID uuid.UUID) ([]database.Message, error) {
+	messages, err := q.queries.GetAllMessagesForAuthor(ctx, userID)
+	return convertAll(messages, func(message Message) database.Message { return database.Message(message) }), err
+}
+
+func (q *Querier) GetBlocksForUser(ctx context.Context, blockerID uuid.UUID) ([]database.Block, error) {
+	blocks, err := q.queries.GetBlocksForUser(ctx, blockerID)
+	return convertAll(blocks, func(block Block) database.Block { return database.Block(block) }), err
+}
+
+func (q *Querier) GetContentFilterRules(ctx context.Context) ([]database.ContentFilterRule, error) {
+	rules, err := q.queries.GetContentFilterRules(ctx)
+	return convertAll(rules, func(rule ContentFilterRule) database.ContentFilterRule { return database.ContentFilterRule(rule) }), err
+}
+
+func (q *Querier) GetDataExport(ctx context.Context, id uuid.UUID) (database.DataExport, error) {
+	export, err := q.queries.GetDataExport(ctx, id)
+	return database.DataExport(export), err
+}
+
+func (q *Querier) GetHiddenAuthorsForUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	return q.queries.GetHiddenAuthorsForUser(ctx, userID)
+}
+
+func (q *Querier) GetLoginAttempts(ctx context.Context, limit int32) ([]database.LoginAttempt, error) {
+	attempts, err := q.queries.GetLoginAttempts(ctx, int64(limit))
+	return convertAll(attempts, func(attempt LoginAttempt) database.LoginAttempt { return database.LoginAttempt(attempt) }), err
+}
+
+func (q *Querier) GetLoginAttemptsForEmail(ctx context.Context, arg database.GetLoginAttemptsForEmailParams) ([]database.LoginAttempt, error) {
+	attempts, err := q.queries.GetLoginAttemptsForEmail(ctx, GetLoginAttemptsForEmailParams{
+		Email: arg.Email,
+		Limit: int64(arg.Limit),
+	})
+	return convertAll(attempts, func(attempt LoginAttempt) database.LoginAttempt { return database.LoginAttempt(attempt) }), err
+}
+
+func (q *Querier) GetLoginLockout(ctx context.Context, arg database.GetLoginLockoutParams) (float64, error) {
+	return q.queries.GetLoginLockout(ctx, GetLoginLockoutParams{
+		FreeFailures:      int64(arg.FreeFailures),
+		MaxLockoutSeconds: arg.MaxLockoutSeconds,
+		Key:               arg.Key,
+	})
+}
+
+func (q *Querier) GetMessage(ctx context.Context, id uuid.UUID) (database.Message, error) {
+	message, err := q.queries.GetMessage(ctx, id)
+	return database.Message(message), err
+}
+
+func (q *Querier) GetModerationActions(ctx context.Context, limit int32) ([]database.ModerationAction, error) {
+	actions, err := q.queries.GetModerationActions(ctx, int64(limit))
+	return convertAll(actions, func(action ModerationAction) database.ModerationAction { return database.ModerationAction(action) }), err
+}
+
+func (q *Querier) GetModerationActionsForReport(ctx context.Context, reportID uuid.NullUUID) ([]database.ModerationAction, error) {
+	actions, err := q.queries.GetModerationActionsForReport(ctx, reportID)
+	return convertAll(actions, func(action ModerationAction) database.ModerationAction { return database.ModerationAction(action) }), err
+}
+
+func (q *Querier) GetMutesForUser(ctx context.Context, muterID uuid.UUID) ([]database.Mute, error) {
+	mutes, err := q.queries.GetMutesForUser(ctx, muterID)
+	return convertAll(mutes, func(mute Mute) database.Mute { return database.Mute(mute) }), err
+}
+
+func (q *Querier) GetOAuthClient(ctx context.Context, id string) (database.OauthClient, error) {
+	client, err := q.queries.GetOAuthClient(ctx, id)
+	return oauthClient(client), err
+}
+
+func (q *Querier) GetOAuthClientsForUser(ctx context.Context, userID uuid.UUID) ([]database.OauthClient, error) {
+	clients, err := q.queries.GetOAuthClientsForUser(ctx, userID)
+	return convertAll(clients, oauthClient), err
+}
+
+func (q *Querier) GetPaymentEventsForUser(ctx context.Context, userID uuid.UUID) ([]database.PaymentEvent, error) {
+	events, err := q.queries.GetPaymentEventsForUser(ctx, userID)
+	return convertAll(events, func(event PaymentEvent) database.PaymentEvent { return database.PaymentEvent(event) }), err
+}
+
+func (q *Querier) GetPersonalAccessTokensForUser(ctx context.Context, userID uuid.UUID) ([]database.PersonalAccessToken, error) {
+	tokens, err := q.queries.GetPersonalAccessTokensForUser(ctx, userID)
+	return convertAll(tokens, personalAccessToken), err
+}
+
+func (q *Querier) GetRefreshTokensForUser(ctx context.Context, userID uuid.UUID) ([]database.GetRefreshTokensForUserRow, error) {
+	tokens, err := q.queries.GetRefreshTokensForUser(ctx, userID)
+	return convertAll(tokens, func(token GetRefreshTokensForUserRow) database.GetRefreshTokensForUserRow {
+		return database.GetRefreshTokensForUserRow(token)
+	}), err
+}
+
+func (q *Querier) GetReport(ctx context.Context, id uuid.UUID) (database.Report, error) {
+	report, err := q.queries.GetReport(ctx, id)
+	return database.Report(report), err
+}
+
+func (q *Querier) GetReportsByStatus(ctx context.Context, arg database.GetReportsByStatusParams) ([]database.Report, error) {
+	reports, err := q.queries.GetReportsByStatus(ctx, GetReportsByStatusParams{
+		Status: arg.Status,
+		Limit:  int64(arg.Limit),
+	})
+	return convertAll(reports, func(report Report) database.Report { return database.Report(report) }), err
+}
+
+func (q *Querier) GetUserAccess(ctx context.Context, id uuid.UUID) (database.GetUserAccessRow, error) {
+	access, err := q.queries.GetUserAccess(ctx, id)
+	return database.GetUserAccessRow(access), err
+}
+
+func (q *Querier) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	user, err := q.queries.GetUserByEmail(ctx, email)
+	return database.User(user), err
+}
+
+func (q *Querier) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	user, err := q.queries.GetUserByID(ctx, id)
+	return database.User(user), err
+}
+
+func (q *Querier) GetUserFromRefreshToken(ctx context.Context, token string) (uuid.UUID, error) {
+	return q.queries.GetUserFromRefreshToken(ctx, token)
+}
+
+func (q *Querier) GetUserIdentitiesForUser(ctx context.Context, userID uuid.UUID) ([]database.UserIdentity, error) {
+	identities, err := q.queries.GetUserIdentitiesForUser(ctx, userID)
+	return convertAll(identities, func(identity UserIdentity) database.UserIdentity { return database.UserIdentity(identity) }), err
+}
+
+func (q *Querier) GetUserIdentity(ctx context.Context, arg database.GetUserIdentityParams) (database.UserIdentity, error) {
+	identity, err := q.queries.GetUserIdentity(ctx, GetUserIdentityParams(arg))
+	return database.UserIdentity(identity), err
+}
+
+func (q *Querier) ReleaseLoginAttempt(ctx context.Context, key string) error {
+	return q.queries.ReleaseLoginAttempt(ctx, key)
+}
+
+func (q *Querier) ResolveReport(ctx context.Context, arg database.ResolveReportParams) (database.Report, error) {
+	report, err := q.queries.ResolveReport(ctx, ResolveReportParams{
+		Resolution:  sql.NullString{String: arg.Resolution, Valid: true},
+		ID:          arg.ID,
+		ModeratorID: uuid.NullUUID{UUID: arg.ModeratorID, Valid: true},
+	})
+	return database.Report(report), err
+}
+
+func (q *Querier) RevokeRefreshToken(ctx context.Context, token string) error {
+	return q.queries.RevokeRefreshToken(ctx, token)
+}
+
+func (q *Querier) RevokeRefreshTokensForUser(ctx context.Context, userID uuid.UUID) error {
+	return q.queries.RevokeRefreshTokensForUser(ctx, userID)
+}
+
+func (q *Querier) ScheduleUserDeletion(ctx context.Context, arg database.ScheduleUserDeletionParams) error {
+	return q.queries.ScheduleUserDeletion(ctx, ScheduleUserDeletionParams{
+		DeletionScheduledAt: sql.NullTime{Time: utc(arg.DeletionScheduledAt), Valid: true},
+		ID:                  arg.ID,
+	})
+}
+
+func (q *Querier) SetUserStatus(ctx context.Context, arg database.SetUserStatusParams) (database.SetUserStatusRow, error) {
+	status, err := q.queries.SetUserStatus(ctx, SetUserStatusParams{
+		Status:         arg.Status,
+		StatusReason:   arg.StatusReason,
+		SuspendedUntil: utcNull(arg.SuspendedUntil),
+		ID:             arg.ID,
+	})
+	return database.SetUserStatusRow(status), err
+}
+
+// TakeLoginAttempt creates the counter on the first attempt in two queries like TakeRateLimitToken.
+// There is no row if the counter exists and cannot be taken, i.e. the key is locked out.
+func (q *Querier) TakeLoginAttempt(ctx context.Context, arg database.TakeLoginAttemptParams) (int32, error) {
+	takeParams := TakeLoginAttemptParams{
+		WindowSeconds:     arg.WindowSeconds,
+		Key:               arg.Key,
+		FreeFailures:      int64(arg.FreeFailures),
+		MaxLockoutSeconds: arg.MaxLockoutSeconds,
+	}
+	failedCount, err := q.queries.TakeLoginAttempt(ctx, takeParams)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return int32(failedCount), err
+	}
+
+	failedCount, err = q.queries.CreateLoginThrottle(ctx, arg.Key)
+	if errors.Is(err, sql.ErrNoRows) {
+		failedCount, err = q.queries.TakeLoginAttempt(ctx, takeParams)
+	}
+	return int32(failedCount), err
+}
+
+// TakeRateLimitToken creates the bucket on the first request. SQLite cannot take parameters in the update part
+// of an upsert, so it is done in two queries, which cannot both miss the bucket unless it was created in between.
+func (q *Querier) TakeRateLimitToken(ctx context.Context, arg database.TakeRateLimitTokenParams) (database.TakeRateLimitTokenRow, error) {
+	takeParams := TakeRateLimitTokenParams{
+		Capacity:   arg.Capacity,
+		RefillRate: arg.RefillRate,
+		Key:        arg.Key,
+	}
+	bucket, err := q.queries.TakeRateLimitToken(ctx, takeParams)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.TakeRateLimitTokenRow(bucket), err
+	}
+
+	created, err := q.queries.CreateRateLimitBucket(ctx, CreateRateLimitBucketParams{
+		Key:      arg.Key,
+		Capacity: arg.Capacity,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		bucket, err = q.queries.TakeRateLimitToken(ctx, takeParams)
+		return database.TakeRateLimitTokenRow(bucket), err
+	}
+	return database.TakeRateLimitTokenRow(created), err
+}
+
+func (q *Querier) TouchPersonalAccessToken(ctx context.Context, id uuid.UUID) error {
+	return q.queries.TouchPersonalAccessToken(ctx, id)
+}
+
+func (q *Querier) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
+	user, err := q.queries.UpdateUser(ctx, UpdateUserParams{
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+		ID:             arg.ID,
+	})
+	return database.User(user), duplicate(err)
+}
+
+func (q *Querier) UpdateUserPasswordHash(ctx context.Context, arg database.UpdateUserPasswordHashParams) error {
+	return q.queries.UpdateUserPasswordHash(ctx, UpdateUserPasswordHashParams{
+		HashedPassword: arg.HashedPassword,
+		ID:             arg.ID,
+	})
+}
+
+func (q *Querier) UpgradeToPremium(ctx context.Context, id uuid.UUID) error {
+	return q.queries.UpgradeToPremium(ctx, id)
+}
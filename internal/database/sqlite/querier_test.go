@@ -0,0 +1,203 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/database"
+	schema "github.com/ech00wv/SNserver/sql/sqlite/schema"
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+)
+
+// openTestDB opens migrated in-memory database the way the server opens database files
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:?_pragma=foreign_keys(1)&_time_format=sqlite")
+	if err != nil {
+		t.Fatalf("cannot open database: %s", err)
+	}
+	// every connection to ":memory:" is a new database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	err = Migrate(context.Background(), db)
+	if err != nil {
+		t.Fatalf("cannot migrate database: %s", err)
+	}
+	return db
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	names, err := fs.Glob(schema.Migrations, "*.sql")
+	if err != nil {
+		t.Fatalf("cannot list migrations: %s", err)
+	}
+
+	var version int
+	err = db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version)
+	if err != nil {
+		t.Fatalf("cannot read version: %s", err)
+	}
+	if version != len(names) {
+		t.Fatalf("expected version %d, got %d", len(names), version)
+	}
+
+	// applied migrations are skipped, so the server can be restarted
+	err = Migrate(ctx, db)
+	if err != nil {
+		t.Fatalf("cannot migrate database again: %s", err)
+	}
+}
+
+func TestQuerierRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	queries := NewQuerier(openTestDB(t))
+
+	user, err := queries.CreateUser(ctx, database.CreateUserParams{Email: "user@example.com", HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+	if user.ID == uuid.Nil || user.CreatedAt.IsZero() {
+		t.Fatalf("user is not filled like in Postgres: %+v", user)
+	}
+
+	t.Run("user", func(t *testing.T) {
+		dbUser, err := queries.GetUserByEmail(ctx, user.Email)
+		if err != nil {
+			t.Fatalf("cannot get user: %s", err)
+		}
+		if dbUser.ID != user.ID || !dbUser.CreatedAt.Equal(user.CreatedAt) || dbUser.Status != "active" {
+			t.Fatalf("expected %+v, got %+v", user, dbUser)
+		}
+	})
+
+	t.Run("personal access token", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+		scopes := []string{"messages:read", "messages:write"}
+		_, err := queries.CreatePersonalAccessToken(ctx, database.CreatePersonalAccessTokenParams{
+			UserID:    user.ID,
+			Name:      "bot",
+			TokenHash: "hash",
+			Scopes:    scopes,
+			ExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("cannot create token: %s", err)
+		}
+
+		token, err := queries.GetActivePersonalAccessToken(ctx, "hash")
+		if err != nil {
+			t.Fatalf("cannot get token: %s", err)
+		}
+		if !slices.Equal(token.Scopes, scopes) || !token.ExpiresAt.Time.Equal(expiresAt) {
+			t.Fatalf("token was not read as written: %+v", token)
+		}
+	})
+
+	t.Run("report without message", func(t *testing.T) {
+		reporter, err := queries.CreateUser(ctx, database.CreateUserParams{Email: "reporter@example.com", HashedPassword: "hash"})
+		if err != nil {
+			t.Fatalf("cannot create reporter: %s", err)
+		}
+		arg := database.CheckOpenReportExistsParams{ReporterID: reporter.ID, ReportedUserID: user.ID}
+		_, err = queries.CreateReport(ctx, database.CreateReportParams{
+			ReporterID:     uuid.NullUUID{UUID: reporter.ID, Valid: true},
+			TargetType:     "user",
+			ReportedUserID: user.ID,
+			Reason:         "spam",
+		})
+		if err != nil {
+			t.Fatalf("cannot create report: %s", err)
+		}
+
+		exists, err := queries.CheckOpenReportExists(ctx, arg)
+		if err != nil {
+			t.Fatalf("cannot check report: %s", err)
+		}
+		if !exists {
+			t.Fatal("report with null message was not found")
+		}
+	})
+
+	t.Run("login throttle", func(t *testing.T) {
+		takeParams := database.TakeLoginAttemptParams{Key: "email:user@example.com", WindowSeconds: 60, FreeFailures: 3, MaxLockoutSeconds: 60}
+		for expected := int32(1); expected <= 3; expected++ {
+			failedCount, err := queries.TakeLoginAttempt(ctx, takeParams)
+			if err != nil || failedCount != expected {
+				t.Fatalf("expected attempt %d to be taken, got %d, %v", expected, failedCount, err)
+			}
+		}
+
+		_, err := queries.TakeLoginAttempt(ctx, takeParams)
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("expected lockout, got %v", err)
+		}
+		wait, err := queries.GetLoginLockout(ctx, database.GetLoginLockoutParams{Key: takeParams.Key, FreeFailures: 3, MaxLockoutSeconds: 60})
+		if err != nil || wait <= 0 || wait > 1 {
+			t.Fatalf("expected lockout of up to a second, got %f, %v", wait, err)
+		}
+	})
+
+	t.Run("foreign keys cascade", func(t *testing.T) {
+		_, err := queries.CreateMessage(ctx, database.CreateMessageParams{Body: "hello", UserID: user.ID})
+		if err != nil {
+			t.Fatalf("cannot create message: %s", err)
+		}
+		err = queries.DeleteUsers(ctx)
+		if err != nil {
+			t.Fatalf("cannot delete users: %s", err)
+		}
+
+		messages, err := queries.GetAllMessagesForAuthor(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("cannot get messages: %s", err)
+		}
+		if len(messages) != 0 {
+			t.Fatalf("messages of deleted user were kept: %+v", messages)
+		}
+		_, err = queries.GetActivePersonalAccessToken(ctx, "hash")
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("token of deleted user was kept: %v", err)
+		}
+	})
+}
+
+func TestQuerierDuplicate(t *testing.T) {
+	ctx := context.Background()
+	querier := NewQuerier(openTestDB(t))
+	user, err := querier.CreateUser(ctx, database.CreateUserParams{Email: "user@example.com"})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+	other, err := querier.CreateUser(ctx, database.CreateUserParams{Email: "other@example.com"})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+
+	_, err = querier.CreateUser(ctx, database.CreateUserParams{Email: user.Email})
+	if !errors.Is(err, database.ErrDuplicate) {
+		t.Fatalf("expected duplicate error for taken email, got %v", err)
+	}
+	_, err = querier.UpdateUser(ctx, database.UpdateUserParams{ID: other.ID, Email: user.Email})
+	if !errors.Is(err, database.ErrDuplicate) {
+		t.Fatalf("expected duplicate error for update to taken email, got %v", err)
+	}
+	// the rule is seeded by migrations
+	_, err = querier.CreateContentFilterRule(ctx, database.CreateContentFilterRuleParams{Term: "fornax", Action: "mask"})
+	if !errors.Is(err, database.ErrDuplicate) {
+		t.Fatalf("expected duplicate error for existing rule, got %v", err)
+	}
+	// other constraint violations are not duplicates
+	_, err = querier.CreateUserIdentity(ctx, database.CreateUserIdentityParams{UserID: uuid.New(), Provider: "google", Subject: "subject"})
+	if err == nil || errors.Is(err, database.ErrDuplicate) {
+		t.Fatalf("expected foreign key error, got %v", err)
+	}
+}
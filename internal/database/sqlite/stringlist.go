@@ -0,0 +1,32 @@
+package sqlite
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// StringList is a TEXT[] column of Postgres, SQLite keeps it as JSON array
+type StringList []string
+
+func (list StringList) Value() (driver.Value, error) {
+	if list == nil {
+		return "[]", nil
+	}
+	encoded, err := json.Marshal([]string(list))
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+func (list *StringList) Scan(src any) error {
+	switch src := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(src), list)
+	case []byte:
+		return json.Unmarshal(src, list)
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", src)
+	}
+}
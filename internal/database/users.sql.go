@@ -7,10 +7,23 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+const cancelUserDeletion = `-- name: CancelUserDeletion :exec
+UPDATE users
+SET deletion_scheduled_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND deletion_scheduled_at IS NOT NULL
+`
+
+func (q *Queries) CancelUserDeletion(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, cancelUserDeletion, id)
+	return err
+}
+
 const checkUserExists = `-- name: CheckUserExists :one
 SELECT EXISTS(
     SELECT 1
@@ -34,7 +47,7 @@ VALUES (
     CURRENT_TIMESTAMP,
     $1,
     $2
-) RETURNING id, created_at, updated_at, email, hashed_password, is_premium
+) RETURNING id, created_at, updated_at, email, hashed_password, is_premium, is_admin, deletion_scheduled_at, suspended_until, status, status_reason
 `
 
 type CreateUserParams struct {
@@ -52,6 +65,11 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsPremium,
+		&i.IsAdmin,
+		&i.DeletionScheduledAt,
+		&i.SuspendedUntil,
+		&i.Status,
+		&i.StatusReason,
 	)
 	return i, err
 }
@@ -65,8 +83,61 @@ func (q *Queries) DeleteUsers(ctx context.Context) error {
 	return err
 }
 
+const deleteUsersScheduledForDeletion = `-- name: DeleteUsersScheduledForDeletion :many
+DELETE FROM users
+WHERE deletion_scheduled_at <= $1::timestamp
+RETURNING id
+`
+
+func (q *Queries) DeleteUsersScheduledForDeletion(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, deleteUsersScheduledForDeletion, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserAccess = `-- name: GetUserAccess :one
+SELECT is_admin, deletion_scheduled_at, status, suspended_until FROM users
+WHERE id = $1
+`
+
+type GetUserAccessRow struct {
+	IsAdmin             bool         `json:"is_admin"`
+	DeletionScheduledAt sql.NullTime `json:"deletion_scheduled_at"`
+	Status              string       `json:"status"`
+	SuspendedUntil      sql.NullTime `json:"suspended_until"`
+}
+
+func (q *Queries) GetUserAccess(ctx context.Context, id uuid.UUID) (GetUserAccessRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserAccess, id)
+	var i GetUserAccessRow
+	err := row.Scan(
+		&i.IsAdmin,
+		&i.DeletionScheduledAt,
+		&i.Status,
+		&i.SuspendedUntil,
+	)
+	return i, err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_premium FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_premium, is_admin, deletion_scheduled_at, suspended_until, status, status_reason FROM users
 WHERE users.email = $1
 `
 
@@ -80,6 +151,91 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsPremium,
+		&i.IsAdmin,
+		&i.DeletionScheduledAt,
+		&i.SuspendedUntil,
+		&i.Status,
+		&i.StatusReason,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, is_premium, is_admin, deletion_scheduled_at, suspended_until, status, status_reason FROM users
+WHERE users.id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsPremium,
+		&i.IsAdmin,
+		&i.DeletionScheduledAt,
+		&i.SuspendedUntil,
+		&i.Status,
+		&i.StatusReason,
+	)
+	return i, err
+}
+
+const scheduleUserDeletion = `-- name: ScheduleUserDeletion :exec
+UPDATE users
+SET deletion_scheduled_at = $1::timestamp, updated_at = CURRENT_TIMESTAMP
+WHERE id = $2
+`
+
+type ScheduleUserDeletionParams struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+	ID                  uuid.UUID `json:"id"`
+}
+
+func (q *Queries) ScheduleUserDeletion(ctx context.Context, arg ScheduleUserDeletionParams) error {
+	_, err := q.db.ExecContext(ctx, scheduleUserDeletion, arg.DeletionScheduledAt, arg.ID)
+	return err
+}
+
+const setUserStatus = `-- name: SetUserStatus :one
+UPDATE users
+SET status = $1, status_reason = $2, suspended_until = $3, updated_at = CURRENT_TIMESTAMP
+WHERE id = $4
+RETURNING id, updated_at, status, status_reason, suspended_until
+`
+
+type SetUserStatusParams struct {
+	Status         string       `json:"status"`
+	StatusReason   string       `json:"status_reason"`
+	SuspendedUntil sql.NullTime `json:"suspended_until"`
+	ID             uuid.UUID    `json:"id"`
+}
+
+type SetUserStatusRow struct {
+	ID             uuid.UUID    `json:"id"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	Status         string       `json:"status"`
+	StatusReason   string       `json:"status_reason"`
+	SuspendedUntil sql.NullTime `json:"suspended_until"`
+}
+
+func (q *Queries) SetUserStatus(ctx context.Context, arg SetUserStatusParams) (SetUserStatusRow, error) {
+	row := q.db.QueryRowContext(ctx, setUserStatus,
+		arg.Status,
+		arg.StatusReason,
+		arg.SuspendedUntil,
+		arg.ID,
+	)
+	var i SetUserStatusRow
+	err := row.Scan(
+		&i.ID,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.StatusReason,
+		&i.SuspendedUntil,
 	)
 	return i, err
 }
@@ -88,7 +244,7 @@ const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET email = $2, hashed_password = $3, updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_premium
+RETURNING id, created_at, updated_at, email, hashed_password, is_premium, is_admin, deletion_scheduled_at, suspended_until, status, status_reason
 `
 
 type UpdateUserParams struct {
@@ -107,10 +263,31 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsPremium,
+		&i.IsAdmin,
+		&i.DeletionScheduledAt,
+		&i.SuspendedUntil,
+		&i.Status,
+		&i.StatusReason,
 	)
 	return i, err
 }
 
+const updateUserPasswordHash = `-- name: UpdateUserPasswordHash :exec
+UPDATE users
+SET hashed_password = $2
+WHERE id = $1
+`
+
+type UpdateUserPasswordHashParams struct {
+	ID             uuid.UUID `json:"id"`
+	HashedPassword string    `json:"hashed_password"`
+}
+
+func (q *Queries) UpdateUserPasswordHash(ctx context.Context, arg UpdateUserPasswordHashParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPasswordHash, arg.ID, arg.HashedPassword)
+	return err
+}
+
 const upgradeToPremium = `-- name: UpgradeToPremium :exec
 UPDATE users
 SET is_premium = true
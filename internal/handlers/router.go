@@ -1,59 +1,289 @@
 package handler
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
 	"net/http"
-	"sync/atomic"
+	"net/netip"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ech00wv/SNserver/internal/auth"
 	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/health"
+	"github.com/ech00wv/SNserver/internal/httpstatus"
+	"github.com/ech00wv/SNserver/internal/logging"
 	"github.com/ech00wv/SNserver/internal/models"
 	service "github.com/ech00wv/SNserver/internal/services"
+	"github.com/ech00wv/SNserver/internal/tracing"
 )
 
 type ApiHandler struct {
 	ApiCfg *config.ApiConfig
+	// services are built once by NewApiHandler
+	authServ    *service.AuthService
+	userServ    *service.UserService
+	messageServ *service.MessageService
+	tokenServ   *service.TokenService
+	paymentServ *service.PaymentService
+	reportServ  *service.ReportService
+	// personalTokenServ manages personal access tokens, tokenServ issues tokens on login
+	personalTokenServ *service.PersonalTokenService
+	blockServ         *service.BlockService
+	adminServ         *service.AdminService
+	// services below keep (some of) their data in the database only, it is read through ac.Queries
+	// or written in transactions of ac.InTx, so they answer 501 in demo mode
+	statusServ     *service.AccountStatusService
+	exportServ     *service.DataExportService
+	oidcServ       *service.OIDCService
+	moderationServ *service.ModerationService
+	filterServ     *service.ContentFilterService
+	oauthServ      *service.OAuthService
 }
 
-type responseError struct {
-	Err string `json:"error"`
+// NewApiHandler builds services on top of ac's repositories, database-only data is read through ac.Queries
+// and ac.InTx
+func NewApiHandler(ac *config.ApiConfig) *ApiHandler {
+	repos := ac.Repositories
+	userServ := service.NewUserService(ac, repos.Users, repos.LoginAttempts, repos.RefreshTokens)
+	return &ApiHandler{
+		ApiCfg:            ac,
+		authServ:          service.NewAuthService(ac, repos.Users, repos.PersonalAccessTokens, ac.Queries),
+		userServ:          userServ,
+		messageServ:       service.NewMessageService(ac, repos.Messages, repos.Users, repos.Reports),
+		tokenServ:         service.NewTokenService(ac, repos.RefreshTokens, repos.Users),
+		paymentServ:       service.NewPaymentService(ac, repos.Users, repos.Payments),
+		reportServ:        service.NewReportService(ac, repos.Messages, repos.Users, repos.Reports),
+		personalTokenServ: service.NewPersonalTokenService(ac, repos.PersonalAccessTokens),
+		blockServ:         service.NewBlockService(ac, repos.Blocks, repos.Users),
+		adminServ:         service.NewAdminService(ac, repos.LoginAttempts),
+		statusServ:        service.NewAccountStatusService(ac, repos.Users),
+		exportServ:        service.NewDataExportService(ac, ac.Queries, repos),
+		oidcServ:          service.NewOIDCService(ac, ac.Queries, repos.Users, userServ),
+		moderationServ:    service.NewModerationService(ac, ac.Queries),
+		filterServ:        service.NewContentFilterService(ac, ac.Queries),
+		oauthServ:         service.NewOAuthService(ac, ac.Queries),
+	}
+}
+
+// problemDetails is an error response in RFC 7807 format with machine-readable error code
+// and request ID to find the request in logs
+type problemDetails struct {
+	Type       string                   `json:"type"`
+	Title      string                   `json:"title"`
+	Status     int                      `json:"status"`
+	Detail     string                   `json:"detail,omitempty"`
+	Instance   string                   `json:"instance,omitempty"`
+	Code       string                   `json:"code"`
+	RequestID  string                   `json:"request_id,omitempty"`
+	Violations []auth.PasswordViolation `json:"violations,omitempty"`
 }
 
-func InitializeMux(ac *config.ApiConfig) *http.ServeMux {
+const (
+	problemContentType = "application/problem+json"
+	// details of internal errors (e.g. database errors) are only logged
+	internalErrorDetail = "the server failed to handle the request, report the request ID if it keeps happening"
+
+	errorCodePasswordPolicy = "password_policy_violation"
 
-	ah := &ApiHandler{ApiCfg: ac}
+	oidcStateCookieName = "oidc_state"
+	// matches lifetime of the auth request stored by the service
+	oidcStateCookieTTL = 10 * time.Minute
+)
+
+// InitializeMux registers routes and wraps them with tracing, request logging and metrics
+func InitializeMux(ac *config.ApiConfig) http.Handler {
+
+	ah := NewApiHandler(ac)
 	serveMux := http.NewServeMux()
 
-	serveMux.Handle("/app/", ah.middlewareMetrics(
+	serveMux.Handle("/app/", ah.middlewareMetrics(denyFraming(
 		http.StripPrefix("/app", http.FileServer(http.Dir("../../assets"))),
-	))
+	)))
 
 	serveMux.HandleFunc("GET /admin/metrics", ah.serveMetrics)
+	serveMux.HandleFunc("GET /metrics", ah.servePrometheusMetrics)
 	serveMux.HandleFunc("POST /admin/reset", ah.resetApp)
+	serveMux.HandleFunc("GET /admin/login-attempts", ah.requireAuth(ah.getLoginAttempts))
+	serveMux.HandleFunc("GET /admin/reports", ah.requireAuth(ah.getReports))
+	serveMux.HandleFunc("POST /admin/reports/{reportID}/claim", ah.requireAuth(ah.claimReport))
+	serveMux.HandleFunc("POST /admin/reports/{reportID}/resolve", ah.requireAuth(ah.resolveReport))
+	serveMux.HandleFunc("GET /admin/moderation-actions", ah.requireAuth(ah.getModerationActions))
+	serveMux.HandleFunc("PUT /admin/users/{userID}/status", ah.requireAuth(ah.setUserStatus))
+	serveMux.HandleFunc("GET /admin/content-filter/rules", ah.requireAuth(ah.getContentFilterRules))
+	serveMux.HandleFunc("POST /admin/content-filter/rules", ah.requireAuth(ah.createContentFilterRule))
+	serveMux.HandleFunc("DELETE /admin/content-filter/rules/{ruleID}", ah.requireAuth(ah.deleteContentFilterRule))
+	serveMux.HandleFunc("POST /admin/content-filter/reload", ah.requireAuth(ah.reloadContentFilter))
 	serveMux.HandleFunc("GET /api/status", handleStatus)
-	serveMux.HandleFunc("POST /api/users", ah.createUser)
-	serveMux.HandleFunc("PUT /api/users", ah.updateUser)
-	serveMux.HandleFunc("POST /api/messages", ah.createMessage)
-	serveMux.HandleFunc("GET /api/messages", ah.getAllMessages)
-	serveMux.HandleFunc("GET /api/messages/{messageID}", ah.getMessage)
-	serveMux.HandleFunc("POST /api/login", ah.loginUser)
-	serveMux.HandleFunc("POST /api/refresh", ah.refreshAccessToken)
+	serveMux.HandleFunc("GET /healthz", ah.checkLiveness)
+	serveMux.HandleFunc("GET /readyz", ah.checkReadiness)
+	serveMux.HandleFunc("POST /api/users", ah.rateLimit(config.RateLimitSignup, ah.createUser))
+	serveMux.HandleFunc("PUT /api/users", ah.requireAuth(ah.updateUser))
+	serveMux.HandleFunc("DELETE /api/users/me", ah.requireAuth(ah.deleteAccount))
+	serveMux.HandleFunc("POST /api/users/me/export", ah.requireAuth(ah.requestDataExport))
+	serveMux.HandleFunc("GET /api/users/me/export/{exportID}", ah.requireAuth(ah.getDataExport))
+	serveMux.HandleFunc("GET /api/exports/{exportID}/download", ah.downloadDataExport)
+	serveMux.HandleFunc("POST /api/messages", ah.requireAuth(ah.rateLimit(config.RateLimitMessages, ah.createMessage)))
+	serveMux.HandleFunc("GET /api/messages", ah.optionalAuth(ah.getAllMessages))
+	serveMux.HandleFunc("GET /api/messages/{messageID}", ah.optionalAuth(ah.getMessage))
+	serveMux.HandleFunc("POST /api/login", ah.rateLimit(config.RateLimitLogin, ah.loginUser))
+	serveMux.HandleFunc("POST /api/refresh", ah.rateLimit(config.RateLimitRefresh, ah.refreshAccessToken))
 	serveMux.HandleFunc("POST /api/revoke", ah.revokeRefreshToken)
-	serveMux.HandleFunc("DELETE /api/messages/{messageID}", ah.deleteMessage)
+	serveMux.HandleFunc("DELETE /api/messages/{messageID}", ah.requireAuth(ah.deleteMessage))
+	serveMux.HandleFunc("POST /api/messages/{messageID}/report", ah.requireAuth(ah.rateLimit(config.RateLimitReports, ah.reportMessage)))
+	serveMux.HandleFunc("POST /api/users/{userID}/report", ah.requireAuth(ah.rateLimit(config.RateLimitReports, ah.reportUser)))
 	serveMux.HandleFunc("POST /api/payment/webhook", ah.proceedPayment)
-	return serveMux
+	serveMux.HandleFunc("GET /api/auth/{provider}/start", ah.optionalAuth(ah.startOIDCAuth))
+	serveMux.HandleFunc("GET /api/auth/{provider}/callback", ah.finishOIDCAuth)
+	serveMux.HandleFunc("GET /api/auth/identities", ah.requireAuth(ah.getUserIdentities))
+	serveMux.HandleFunc("POST /api/oauth/clients", ah.requireAuth(ah.registerOAuthClient))
+	serveMux.HandleFunc("GET /api/oauth/clients", ah.requireAuth(ah.getOAuthClients))
+	serveMux.HandleFunc("GET /api/oauth/clients/{clientID}", ah.getOAuthClient)
+	serveMux.HandleFunc("DELETE /api/oauth/clients/{clientID}", ah.requireAuth(ah.deleteOAuthClient))
+	serveMux.HandleFunc("GET /api/oauth/authorize", ah.showOAuthConsent)
+	serveMux.HandleFunc("POST /api/oauth/authorize", ah.requireAuth(ah.authorizeOAuthClient))
+	serveMux.HandleFunc("POST /api/oauth/login", ah.rateLimit(config.RateLimitLogin, ah.loginForConsent))
+	serveMux.HandleFunc("POST /api/oauth/token", ah.rateLimit(config.RateLimitOAuthToken, ah.issueOAuthToken))
+	serveMux.HandleFunc("GET /api/tokens", ah.requireAuth(ah.getPersonalTokens))
+	serveMux.HandleFunc("POST /api/tokens", ah.requireAuth(ah.createPersonalToken))
+	serveMux.HandleFunc("DELETE /api/tokens/{tokenID}", ah.requireAuth(ah.deletePersonalToken))
+	serveMux.HandleFunc("GET /api/blocks", ah.requireAuth(ah.getBlockedUsers))
+	serveMux.HandleFunc("PUT /api/blocks/{userID}", ah.requireAuth(ah.blockUser))
+	serveMux.HandleFunc("DELETE /api/blocks/{userID}", ah.requireAuth(ah.unblockUser))
+	serveMux.HandleFunc("GET /api/mutes", ah.requireAuth(ah.getMutedUsers))
+	serveMux.HandleFunc("PUT /api/mutes/{userID}", ah.requireAuth(ah.muteUser))
+	serveMux.HandleFunc("DELETE /api/mutes/{userID}", ah.requireAuth(ah.unmuteUser))
+	handler := recoverPanics(serveMux)
+	return tracing.Middleware(serveMux, logging.Middleware(ac.Logger, ac.Metrics.Instrument(serveMux, handler)))
 }
 
-func respondWithError(rw http.ResponseWriter, code int, errorMessage string) {
+// denyFraming forbids other sites to embed the pages in frames, so they cannot trick users into clicking
+// buttons of a hidden page (e.g. "Allow" on the OAuth consent page)
+func denyFraming(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("X-Frame-Options", "DENY")
+		rw.Header().Set("Content-Security-Policy", "frame-ancestors 'none'")
+		next.ServeHTTP(rw, req)
+	})
+}
 
-	rw.Header().Set("Content-Type", "application/json")
+// recoverPanics responds with 500 instead of dropping the connection when a handler panics,
+// the panic is logged with its stack trace
+func recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		recorder := httpstatus.NewRecorder(rw)
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// http.ErrAbortHandler is the way to abort the response on purpose
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
 
-	rw.WriteHeader(code)
+			logging.FromContext(req.Context()).Error("handler panicked", "panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+			if recorder.WroteHeader() {
+				// the client gets truncated response, nothing else can be sent
+				return
+			}
+			writeProblem(rw, newProblem(req, http.StatusInternalServerError, errorCode(http.StatusInternalServerError), internalErrorDetail))
+		}()
+		next.ServeHTTP(recorder, req)
+	})
+}
+
+// errorCode is the default code for status, e.g. "not_found" for 404
+func errorCode(status int) string {
+	return strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+}
+
+func newProblem(req *http.Request, status int, code, detail string) problemDetails {
+	return problemDetails{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  req.URL.Path,
+		Code:      code,
+		RequestID: logging.RequestIDFromContext(req.Context()),
+	}
+}
+
+// respondWithError sends error with the default code of its status, details of internal errors
+// are logged with request's logger and replaced in the response
+func respondWithError(rw http.ResponseWriter, req *http.Request, code int, errorMessage string) {
+	respondWithProblem(rw, req, newProblem(req, code, errorCode(code), errorMessage))
+}
 
-	responseError := responseError{Err: errorMessage}
-	jsonErr, _ := json.Marshal(responseError)
+func respondWithProblem(rw http.ResponseWriter, req *http.Request, problem problemDetails) {
+	// features that are not implemented in the server's configuration are not failures of the server
+	if problem.Status >= http.StatusInternalServerError && problem.Status != http.StatusNotImplemented {
+		logging.FromContext(req.Context()).Error("request failed", "status", problem.Status, "error", problem.Detail)
+		problem.Detail = internalErrorDetail
+	}
+	writeProblem(rw, problem)
+}
 
-	rw.Write(jsonErr)
+func writeProblem(rw http.ResponseWriter, problem problemDetails) {
+	encodedProblem, _ := json.Marshal(problem)
+	rw.Header().Set("Content-Type", problemContentType)
+	rw.WriteHeader(problem.Status)
+	rw.Write(encodedProblem)
+}
+
+// errorStatus maps kind of service error to HTTP status
+func errorStatus(kind service.ErrorKind) int {
+	switch kind {
+	case service.ErrorValidation:
+		return http.StatusBadRequest
+	case service.ErrorUnauthenticated:
+		return http.StatusUnauthorized
+	case service.ErrorForbidden:
+		return http.StatusForbidden
+	case service.ErrorNotFound:
+		return http.StatusNotFound
+	case service.ErrorConflict:
+		return http.StatusConflict
+	case service.ErrorTooManyAttempts:
+		return http.StatusTooManyRequests
+	case service.ErrorUpstream:
+		return http.StatusBadGateway
+	case service.ErrorNotImplemented:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondWithServiceError sends error returned by a service with status of its kind and its code
+// (if it has one), password policy violations are added to the response if err has them
+func respondWithServiceError(rw http.ResponseWriter, req *http.Request, err error) {
+	err = service.DescribeError(err)
+	status := errorStatus(service.ErrorKindOf(err))
+	code := errorCode(status)
+	var serviceErr *service.Error
+	if errors.As(err, &serviceErr) && serviceErr.Code != "" {
+		code = serviceErr.Code
+	}
+
+	var policyErr *auth.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		code = errorCodePasswordPolicy
+	}
+	problem := newProblem(req, status, code, err.Error())
+	if policyErr != nil {
+		problem.Violations = policyErr.Violations
+	}
+	respondWithProblem(rw, req, problem)
 }
 
 func respondWithJson(rw http.ResponseWriter, code int, payload interface{}) {
@@ -62,7 +292,9 @@ func respondWithJson(rw http.ResponseWriter, code int, payload interface{}) {
 
 	encodedJson, err := json.Marshal(payload)
 	if err != nil {
-		respondWithError(rw, http.StatusInternalServerError, fmt.Sprintf("error marshalling json: %s", err))
+		slog.Error("cannot marshal response", "error", err)
+		status := http.StatusInternalServerError
+		writeProblem(rw, problemDetails{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: internalErrorDetail, Code: errorCode(status)})
 		return
 	}
 
@@ -70,6 +302,53 @@ func respondWithJson(rw http.ResponseWriter, code int, payload interface{}) {
 	rw.Write(encodedJson)
 }
 
+// clientIP returns the address of the client without the port. Behind trusted proxies it is the rightmost
+// X-Forwarded-For address that is not a trusted proxy, addresses left of it can be forged by the client.
+func (ah *ApiHandler) clientIP(req *http.Request) string {
+	return clientIP(req, ah.ApiCfg.TrustedProxies)
+}
+
+func clientIP(req *http.Request, trustedProxies []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	if !isTrustedProxy(host, trustedProxies) {
+		return host
+	}
+
+	forwardedFor := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwardedFor) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwardedFor[i])
+		if hop == "" {
+			continue
+		}
+		if _, err := netip.ParseAddr(hop); err != nil {
+			// the proxy did not add a valid address, so the chain cannot be followed further
+			return host
+		}
+		host = hop
+		if !isTrustedProxy(hop, trustedProxies) {
+			return hop
+		}
+	}
+	return host
+}
+
+func isTrustedProxy(host string, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
 // @Summary Checking server status
 // @Description Returns just an "OK"
 // @Produce text/html
@@ -81,6 +360,45 @@ func handleStatus(rw http.ResponseWriter, req *http.Request) {
 	rw.Write([]byte("OK"))
 }
 
+// @Summary Liveness check
+// @Description Checks that the process works (background jobs are running), the process should be restarted if it fails
+// @Produce json
+// @Success 200 {object} models.HealthResponse "Process is alive"
+// @Failure 503 {object} models.HealthResponse "Some of the checks failed"
+// @Router /healthz [get]
+func (ah *ApiHandler) checkLiveness(rw http.ResponseWriter, req *http.Request) {
+	respondWithHealthReport(rw, ah.ApiCfg.Health.Liveness(req.Context()))
+}
+
+// @Summary Readiness check
+// @Description Checks that the server can handle requests (database is reachable and migrated), the server should not get traffic if it fails or while it is draining on shutdown
+// @Produce json
+// @Success 200 {object} models.HealthResponse "Server is ready"
+// @Failure 503 {object} models.HealthResponse "Some of the checks failed or the server is draining"
+// @Router /readyz [get]
+func (ah *ApiHandler) checkReadiness(rw http.ResponseWriter, req *http.Request) {
+	respondWithHealthReport(rw, ah.ApiCfg.Health.Readiness(req.Context()))
+}
+
+func respondWithHealthReport(rw http.ResponseWriter, report health.Report) {
+	response := models.HealthResponse{Status: report.Status, Checks: make([]models.HealthCheckResponse, len(report.Checks))}
+	for i, check := range report.Checks {
+		response.Checks[i] = models.HealthCheckResponse{
+			Name:       check.Name,
+			Status:     check.Status,
+			Error:      check.Error,
+			DurationMs: float64(check.Duration.Microseconds()) / 1000,
+		}
+	}
+
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+	}
+	rw.Header().Set("Cache-Control", "no-store")
+	respondWithJson(rw, status, response)
+}
+
 func (ah *ApiHandler) middlewareMetrics(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		ah.ApiCfg.FileserverHits.Add(1)
@@ -88,48 +406,395 @@ func (ah *ApiHandler) middlewareMetrics(next http.Handler) http.Handler {
 	})
 }
 
+// rateLimit takes a token from the caller's bucket of the policy, authenticated callers are limited by user
+// and anonymous ones by ip address. Requests are let through if the limiter's store fails.
+func (ah *ApiHandler) rateLimit(policyName string, next http.HandlerFunc) http.HandlerFunc {
+	policy, ok := ah.ApiCfg.RateLimiter.Policy(policyName)
+	if !ok {
+		// config.Load configures every policy, so a missing one is a typo in the routes, like an invalid pattern
+		panic(fmt.Sprintf("rate limit policy %s is not configured", policyName))
+	}
+
+	return func(rw http.ResponseWriter, req *http.Request) {
+		key := "ip:" + ah.clientIP(req)
+		if principal := auth.PrincipalFromContext(req.Context()); principal.IsAuthenticated() {
+			key = "user:" + principal.UserID.String()
+		}
+
+		decision, err := ah.ApiCfg.RateLimiter.Take(req.Context(), policy, key)
+		if err != nil {
+			logging.FromContext(req.Context()).Error("rate limiter failed, letting request through", "error", err)
+			next(rw, req)
+			return
+		}
+
+		rw.Header().Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+		rw.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		rw.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+		rw.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, ceilSeconds(policy.Period)))
+		if !decision.Allowed {
+			rw.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter)))
+			respondWithError(rw, req, http.StatusTooManyRequests, "too many requests, try again later")
+			return
+		}
+		next(rw, req)
+	}
+}
+
+func ceilSeconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
+
+// requireAuth rejects requests without valid access token and puts the caller into request's context
+func (ah *ApiHandler) requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		principal, err := ah.authServ.Authenticate(req.Context(), req.Header)
+		if err != nil {
+			respondWithServiceError(rw, req, err)
+			return
+		}
+		logging.With(req.Context(), "user_id", principal.UserID)
+		next(rw, req.WithContext(auth.ContextWithPrincipal(req.Context(), principal)))
+	}
+}
+
+// optionalAuth lets anonymous requests through, but rejects invalid access tokens
+func (ah *ApiHandler) optionalAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") == "" {
+			next(rw, req)
+			return
+		}
+		ah.requireAuth(next)(rw, req)
+	}
+}
+
 // @Summary Fileservers metrics
-// @Description Returns an html with visitors counter
+// @Description Returns an html with visitors counter and database connection pool statistics
 // @Produce text/html
 // @Success 200 {string} string "html page with metrics"
-// @Router /metrics [get]
+// @Router /admin/metrics [get]
 func (ah *ApiHandler) serveMetrics(rw http.ResponseWriter, req *http.Request) {
+	dbStats := ah.ApiCfg.DB.Stats()
 	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
 	rw.WriteHeader(200)
 	fmt.Fprintf(rw, `<html>
 					<body>
 						<h1>Welcome, Admin</h1>
 						<p>Social Network has been visited %d times!</p>
+						<h2>Database connections</h2>
+						<ul>
+							<li>Open: %d of %d</li>
+							<li>In use: %d</li>
+							<li>Idle: %d</li>
+							<li>Waited for a connection: %d times, %s in total</li>
+							<li>Closed because of idle limit: %d</li>
+							<li>Closed because of idle time: %d</li>
+							<li>Closed because of lifetime: %d</li>
+						</ul>
 					</body>
-				</html>`, ah.ApiCfg.FileserverHits.Load())
+				</html>`, ah.ApiCfg.FileserverHits.Load(),
+		dbStats.OpenConnections, dbStats.MaxOpenConnections,
+		dbStats.InUse,
+		dbStats.Idle,
+		dbStats.WaitCount, dbStats.WaitDuration,
+		dbStats.MaxIdleClosed,
+		dbStats.MaxIdleTimeClosed,
+		dbStats.MaxLifetimeClosed)
+}
+
+// @Summary Prometheus metrics
+// @Description Returns request counts, latencies and in-flight requests per route, domain counters (messages, logins, payment webhooks), database pool and Go runtime metrics in Prometheus exposition format
+// @Produce plain
+// @Param Authorization header string false "Bearer METRICS_TOKEN, required when the token is configured"
+// @Success 200 {string} string "metrics in Prometheus exposition format"
+// @Failure 401 {object} handler.problemDetails "Metrics token is missing or wrong"
+// @Router /metrics [get]
+func (ah *ApiHandler) servePrometheusMetrics(rw http.ResponseWriter, req *http.Request) {
+	if ah.ApiCfg.MetricsToken != "" {
+		token, err := auth.GetBearerToken(req.Header)
+		if err != nil || subtle.ConstantTimeCompare([]byte(token), []byte(ah.ApiCfg.MetricsToken)) != 1 {
+			respondWithError(rw, req, http.StatusUnauthorized, "metrics token is missing or wrong")
+			return
+		}
+	}
+	ah.ApiCfg.Metrics.Handler().ServeHTTP(rw, req)
 }
 
 // @Summary Reset app
 // @Description Reset app and clear all the users (hence messages, etc.)
 // @Success 200 {string} string "app successfully resetted!"
-// @Failure 403
-// @Failure 500 {object} handler.responseError "error in deleting users"
+// @Failure 403 {object} handler.problemDetails "Platform is not dev"
+// @Failure 500 {object} handler.problemDetails "error in deleting users"
 // @Router /admin/reset [post]
 func (ah *ApiHandler) resetApp(rw http.ResponseWriter, req *http.Request) {
 	if ah.ApiCfg.Platfrom != "dev" {
-		rw.WriteHeader(http.StatusForbidden)
+		respondWithError(rw, req, http.StatusForbidden, "reset is only allowed on dev platform")
 		return
 	}
 
-	userService := service.UserService{ApiConfig: ah.ApiCfg}
-	err := userService.DeleteUsers(req.Context())
+	err := ah.userServ.DeleteUsers(req.Context())
 	if err != nil {
-		respondWithError(rw, http.StatusInternalServerError, fmt.Sprintf("error in deleting users: %s", err))
+		respondWithError(rw, req, http.StatusInternalServerError, fmt.Sprintf("error in deleting users: %s", err))
 		return
 	}
 
-	ah.ApiCfg.FileserverHits = atomic.Int64{}
+	ah.ApiCfg.FileserverHits.Store(0)
 	rw.Header().Set("Content-Type", "text/plain; charset=utf8")
 	rw.WriteHeader(http.StatusOK)
 	rw.Write([]byte("app successfuly resetted!"))
 
 }
 
+// @Summary Login attempts
+// @Description Get latest login attempts (either all of them or for specific email)
+// @Produce json
+// @Param Authorization header string true "Admin's access token"
+// @Param email query string false "email"
+// @Param limit query int false "Maximum number of attempts (100 by default)"
+// @Success 200 {array} models.LoginAttemptResponse "List of login attempts"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "User is not an admin"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /admin/login-attempts [get]
+func (ah *ApiHandler) getLoginAttempts(rw http.ResponseWriter, req *http.Request) {
+	email := req.URL.Query().Get("email")
+	limit := req.URL.Query().Get("limit")
+
+	attempts, err := ah.adminServ.GetLoginAttempts(req.Context(), auth.PrincipalFromContext(req.Context()), email, limit)
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, attempts)
+}
+
+// @Summary Moderation queue
+// @Description Get reports with given status, oldest first
+// @Produce json
+// @Param Authorization header string true "Admin's access token"
+// @Param status query string false "open (default), claimed or resolved"
+// @Param limit query int false "Maximum number of reports (50 by default)"
+// @Success 200 {array} models.ReportResponse "List of reports"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "User is not an admin"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /admin/reports [get]
+func (ah *ApiHandler) getReports(rw http.ResponseWriter, req *http.Request) {
+	reports, err := ah.moderationServ.GetReports(req.Context(), auth.PrincipalFromContext(req.Context()), req.URL.Query().Get("status"), req.URL.Query().Get("limit"))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, reports)
+}
+
+// @Summary Claim report
+// @Description Assign open report to the moderator
+// @Produce json
+// @Param Authorization header string true "Admin's access token"
+// @Param reportID path string true "ID of the report"
+// @Success 200 {object} models.ReportResponse "Claimed report"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "User is not an admin"
+// @Failure 404 {object} handler.problemDetails "Report not found"
+// @Failure 409 {object} handler.problemDetails "Report is already claimed or resolved"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /admin/reports/{reportID}/claim [post]
+func (ah *ApiHandler) claimReport(rw http.ResponseWriter, req *http.Request) {
+	report, err := ah.moderationServ.ClaimReport(req.Context(), auth.PrincipalFromContext(req.Context()), req.PathValue("reportID"))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, report)
+}
+
+// @Summary Resolve report
+// @Description Resolve report claimed by the moderator: dismiss it, delete reported message or suspend reported user
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Admin's access token"
+// @Param reportID path string true "ID of the report"
+// @Param resolution body models.ResolveReportRequest true "Action (dismiss, delete_message or suspend_user), note and suspension length in days (7 by default)"
+// @Success 200 {object} models.ReportResponse "Resolved report"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "User is not an admin"
+// @Failure 404 {object} handler.problemDetails "Report not found"
+// @Failure 409 {object} handler.problemDetails "Report is not claimed by the moderator or action cannot be applied"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /admin/reports/{reportID}/resolve [post]
+func (ah *ApiHandler) resolveReport(rw http.ResponseWriter, req *http.Request) {
+	var reqBodyData models.ResolveReportRequest
+	err := json.NewDecoder(req.Body).Decode(&reqBodyData)
+	defer req.Body.Close()
+	if err != nil {
+		respondWithError(rw, req, http.StatusBadRequest, fmt.Sprintf("cannot decode resolution: %s", err))
+		return
+	}
+
+	report, err := ah.moderationServ.ResolveReport(req.Context(), auth.PrincipalFromContext(req.Context()), req.PathValue("reportID"), reqBodyData)
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, report)
+}
+
+// @Summary Moderation audit trail
+// @Description Get moderator decisions, either latest ones or all decisions for specific report
+// @Produce json
+// @Param Authorization header string true "Admin's access token"
+// @Param report_id query string false "ID of the report"
+// @Param limit query int false "Maximum number of actions (50 by default)"
+// @Success 200 {array} models.ModerationActionResponse "List of moderation actions"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "User is not an admin"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /admin/moderation-actions [get]
+func (ah *ApiHandler) getModerationActions(rw http.ResponseWriter, req *http.Request) {
+	actions, err := ah.moderationServ.GetModerationActions(req.Context(), auth.PrincipalFromContext(req.Context()), req.URL.Query().Get("report_id"), req.URL.Query().Get("limit"))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, actions)
+}
+
+// @Summary Change account status
+// @Description Suspend, shadow-ban, ban or restore user's account. Shadow-banned user's messages are visible only to the user
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Admin's access token"
+// @Param userID path string true "ID of the user"
+// @Param status body models.UserStatusRequest true "Status (active, suspended, shadow_banned or banned), reason and suspension length in days (7 by default)"
+// @Success 200 {object} models.UserStatusResponse "New account status"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "User is not an admin"
+// @Failure 404 {object} handler.problemDetails "User not found"
+// @Failure 409 {object} handler.problemDetails "Admins cannot be restricted"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /admin/users/{userID}/status [put]
+func (ah *ApiHandler) setUserStatus(rw http.ResponseWriter, req *http.Request) {
+	var reqBodyData models.UserStatusRequest
+	err := json.NewDecoder(req.Body).Decode(&reqBodyData)
+	defer req.Body.Close()
+	if err != nil {
+		respondWithError(rw, req, http.StatusBadRequest, fmt.Sprintf("cannot decode status: %s", err))
+		return
+	}
+
+	userStatus, err := ah.statusServ.SetUserStatus(req.Context(), auth.PrincipalFromContext(req.Context()), req.PathValue("userID"), reqBodyData)
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, userStatus)
+}
+
+// @Summary Content filter rules
+// @Description Get content filter rules stored in the database
+// @Produce json
+// @Param Authorization header string true "Admin's access token"
+// @Success 200 {array} models.ContentFilterRuleResponse "List of rules"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "User is not an admin"
+// @Failure 409 {object} handler.problemDetails "Rules are loaded from a file"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /admin/content-filter/rules [get]
+func (ah *ApiHandler) getContentFilterRules(rw http.ResponseWriter, req *http.Request) {
+	rules, err := ah.filterServ.GetRules(req.Context(), auth.PrincipalFromContext(req.Context()))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, rules)
+}
+
+// @Summary Create content filter rule
+// @Description Add word or phrase to the content filter, the rule applies to new messages right away
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Admin's access token"
+// @Param rule body models.ContentFilterRuleRequest true "Word or phrase and action (mask, reject or flag)"
+// @Success 201 {object} models.ContentFilterRuleResponse "Created rule"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "User is not an admin"
+// @Failure 409 {object} handler.problemDetails "Rules are loaded from a file or the term already has a rule"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /admin/content-filter/rules [post]
+func (ah *ApiHandler) createContentFilterRule(rw http.ResponseWriter, req *http.Request) {
+	var reqBodyData models.ContentFilterRuleRequest
+	err := json.NewDecoder(req.Body).Decode(&reqBodyData)
+	defer req.Body.Close()
+	if err != nil {
+		respondWithError(rw, req, http.StatusBadRequest, fmt.Sprintf("cannot decode rule: %s", err))
+		return
+	}
+
+	rule, err := ah.filterServ.CreateRule(req.Context(), auth.PrincipalFromContext(req.Context()), reqBodyData)
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusCreated, rule)
+}
+
+// @Summary Delete content filter rule
+// @Description Remove rule from the content filter
+// @Param Authorization header string true "Admin's access token"
+// @Param ruleID path string true "ID of the rule"
+// @Success 204
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "User is not an admin"
+// @Failure 404 {object} handler.problemDetails "Rule not found"
+// @Failure 409 {object} handler.problemDetails "Rules are loaded from a file"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /admin/content-filter/rules/{ruleID} [delete]
+func (ah *ApiHandler) deleteContentFilterRule(rw http.ResponseWriter, req *http.Request) {
+	err := ah.filterServ.DeleteRule(req.Context(), auth.PrincipalFromContext(req.Context()), req.PathValue("ruleID"))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusNoContent, nil)
+}
+
+// @Summary Reload content filter
+// @Description Reload content filter rules from the file or the database without waiting for periodic reload
+// @Param Authorization header string true "Admin's access token"
+// @Success 204
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "User is not an admin"
+// @Failure 500 {object} handler.problemDetails "Rules cannot be loaded, previous rules are kept"
+// @Router /admin/content-filter/reload [post]
+func (ah *ApiHandler) reloadContentFilter(rw http.ResponseWriter, req *http.Request) {
+	err := ah.filterServ.Reload(req.Context(), auth.PrincipalFromContext(req.Context()))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusNoContent, nil)
+}
+
 // @Summary User creation
 // @Description Create a user with provided email and password
 // @Accept  json
@@ -137,102 +802,107 @@ func (ah *ApiHandler) resetApp(rw http.ResponseWriter, req *http.Request) {
 // @Param email body string true "User's email"
 // @Param password body string true "User's password"
 // @Success 201 {object} models.UserResponse "Created user's information"
-// @Failure 400 {object} handler.responseError "User credentials is incorrect"
-// @Failure 500 {object} handler.responseError "Internal server error"
+// @Failure 400 {object} handler.problemDetails "User credentials is incorrect"
+// @Failure 409 {object} handler.problemDetails "User with this email already exists"
+// @Failure 429 {object} handler.problemDetails "Too many requests"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
 // @Router /api/users [post]
 func (ah *ApiHandler) createUser(rw http.ResponseWriter, req *http.Request) {
 
-	userService := service.UserService{ApiConfig: ah.ApiCfg}
 	var reqBodyData models.UserRequest
 
 	err := json.NewDecoder(req.Body).Decode(&reqBodyData)
 	defer req.Body.Close()
 	if err != nil {
-		respondWithError(rw, http.StatusBadRequest, fmt.Sprintf("error marshalling json: %s", err))
+		respondWithError(rw, req, http.StatusBadRequest, fmt.Sprintf("error marshalling json: %s", err))
 		return
 	}
 
-	user, status, err := userService.CreateUser(req.Context(), reqBodyData)
+	user, err := ah.userServ.CreateUser(req.Context(), reqBodyData)
 	if err != nil {
-		respondWithError(rw, status, err.Error())
+		respondWithServiceError(rw, req, err)
 		return
 	}
 
-	respondWithJson(rw, status, user)
+	respondWithJson(rw, http.StatusCreated, user)
 }
 
 // @Summary Message creation
-// @Description Create a message for given user
+// @Description Create a message for given user. Words from content filter are masked, messages with rejected words are not created and messages with flagged words are sent to moderation queue
 // @Accept  json
 // @Produce json
 // @Param body body string true "Message content"
 // @Param Authorization header string true "Access token"
 // @Success 201 {object} models.MessageResponse "Created message information"
-// @Failure 400 {object} handler.responseError "Something is wrong in provided information"
-// @Failure 401 {object} handler.responseError "User is unauthorized"
-// @Failure 500 {object} handler.responseError "Internal server error"
+// @Failure 400 {object} handler.problemDetails "Message is too long or contains prohibited content"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "Token does not have required scope or account is suspended or banned"
+// @Failure 429 {object} handler.problemDetails "Too many requests"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
 // @Router /api/messages [post]
 func (ah *ApiHandler) createMessage(rw http.ResponseWriter, req *http.Request) {
-	messageService := service.MessageService{ApiConfig: ah.ApiCfg}
-
 	var reqBodyData models.MessageRequest
 	err := json.NewDecoder(req.Body).Decode(&reqBodyData)
 	defer req.Body.Close()
 	if err != nil {
-		respondWithError(rw, http.StatusInternalServerError, err.Error())
+		respondWithError(rw, req, http.StatusBadRequest, fmt.Sprintf("cannot decode message: %s", err))
 		return
 	}
-	message, status, err := messageService.CreateMessage(req.Context(), req.Header, reqBodyData)
+	message, err := ah.messageServ.CreateMessage(req.Context(), auth.PrincipalFromContext(req.Context()), reqBodyData)
 	if err != nil {
-		respondWithError(rw, status, err.Error())
+		respondWithServiceError(rw, req, err)
 		return
 	}
-	respondWithJson(rw, status, message)
+	respondWithJson(rw, http.StatusCreated, message)
 
 }
 
 // @Summary Get all messages
-// @Description Get all messages (either all of them or from specific author)
+// @Description Get all messages (either all of them or from specific author). Messages of blocked and muted users are left out for authenticated viewer
 // @Produce json
+// @Param Authorization header string false "Access token"
 // @Param author_id query string false "author_id"
 // @Param sort query string false "Sorting order ('asc', 'desc' or nothing)"
 // @Success 200 {array} models.MessageResponse "List of messages"
-// @Failure 400 {object} handler.responseError "Something is wrong in provided information"
-// @Failure 404 {object} handler.responseError "Messages not found"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "Access token is not valid"
+// @Failure 403 {object} handler.problemDetails "Token does not have messages:read scope"
+// @Failure 404 {object} handler.problemDetails "Messages not found"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
 // @Router /api/messages [get]
 func (ah *ApiHandler) getAllMessages(rw http.ResponseWriter, req *http.Request) {
-	messageService := service.MessageService{ApiConfig: ah.ApiCfg}
 	authorID := req.URL.Query().Get("author_id")
 	sortingOrder := req.URL.Query().Get("sort")
-	messages, status, err := messageService.GetAllMessages(req.Context(), authorID, sortingOrder)
+	messages, err := ah.messageServ.GetAllMessages(req.Context(), auth.PrincipalFromContext(req.Context()), authorID, sortingOrder)
 
 	if err != nil {
-		respondWithError(rw, status, err.Error())
+		respondWithServiceError(rw, req, err)
 		return
 	}
 
-	respondWithJson(rw, status, messages)
+	respondWithJson(rw, http.StatusOK, messages)
 }
 
 // @Summary Get message
-// @Description Get one specific message by it's id
+// @Description Get one specific message by it's id. Messages are not found between users who blocked each other
 // @Produce json
+// @Param Authorization header string false "Access token"
 // @Param messageID path string true "messageID"
 // @Success 200 {object} models.MessageResponse "Message content"
-// @Failure 400 {object} handler.responseError "Something is wrong in provided information"
-// @Failure 404 {object} handler.responseError "Message not found"
-// @Failure 500 {object} handler.responseError "Internal server error"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "Access token is not valid"
+// @Failure 403 {object} handler.problemDetails "Token does not have messages:read scope"
+// @Failure 404 {object} handler.problemDetails "Message not found"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
 // @Router /api/messages/{messageID} [get]
 func (ah *ApiHandler) getMessage(rw http.ResponseWriter, req *http.Request) {
-	messageService := service.MessageService{ApiConfig: ah.ApiCfg}
-
 	messageID := req.PathValue("messageID")
-	message, status, err := messageService.GetMessage(req.Context(), messageID)
+	message, err := ah.messageServ.GetMessage(req.Context(), auth.PrincipalFromContext(req.Context()), messageID)
 	if err != nil {
-		respondWithError(rw, status, err.Error())
+		respondWithServiceError(rw, req, err)
 		return
 	}
-	respondWithJson(rw, status, message)
+	respondWithJson(rw, http.StatusOK, message)
 }
 
 // @Summary Login user
@@ -242,9 +912,11 @@ func (ah *ApiHandler) getMessage(rw http.ResponseWriter, req *http.Request) {
 // @Param email body string true "User's email"
 // @Param password body string true "User's password"
 // @Success 200 {object} models.UserResponse "User's data"
-// @Failure 400 {object} handler.responseError "Something is wrong in provided information"
-// @Failure 401 {object} handler.responseError "User is unauthorized"
-// @Failure 500 {object} handler.responseError "Internal server error"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "Account is suspended or banned"
+// @Failure 429 {object} handler.problemDetails "Too many requests or failed login attempts"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
 // @Router /api/login [post]
 func (ah *ApiHandler) loginUser(rw http.ResponseWriter, req *http.Request) {
 	var reqBodyData models.UserRequest
@@ -252,19 +924,48 @@ func (ah *ApiHandler) loginUser(rw http.ResponseWriter, req *http.Request) {
 	err := json.NewDecoder(req.Body).Decode(&reqBodyData)
 	defer req.Body.Close()
 	if err != nil {
-		respondWithError(rw, http.StatusInternalServerError, fmt.Sprintf("cannot decode user: %s", err))
+		respondWithError(rw, req, http.StatusBadRequest, fmt.Sprintf("cannot decode user: %s", err))
+		return
+	}
+
+	user, err := ah.userServ.LoginUser(req.Context(), reqBodyData, ah.clientIP(req))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
 		return
 	}
-	userService := service.UserService{ApiConfig: ah.ApiCfg}
 
-	user, status, err := userService.LoginUser(req.Context(), reqBodyData)
+	respondWithJson(rw, http.StatusOK, user)
+
+}
+
+// @Summary Login on the consent page
+// @Description Login user with email and password on the OAuth consent page, only an access token is issued
+// @Accept json
+// @Produce json
+// @Param user body models.UserRequest true "User's email and password"
+// @Success 200 {object} models.UserResponse "User's data with access token"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "Account is suspended or banned"
+// @Failure 429 {object} handler.problemDetails "Too many requests or failed login attempts"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/oauth/login [post]
+func (ah *ApiHandler) loginForConsent(rw http.ResponseWriter, req *http.Request) {
+	var reqBodyData models.UserRequest
+	err := json.NewDecoder(req.Body).Decode(&reqBodyData)
+	defer req.Body.Close()
 	if err != nil {
-		respondWithError(rw, status, err.Error())
+		respondWithError(rw, req, http.StatusBadRequest, fmt.Sprintf("cannot decode user: %s", err))
 		return
 	}
 
-	respondWithJson(rw, status, user)
+	user, err := ah.userServ.LoginForConsent(req.Context(), reqBodyData, ah.clientIP(req))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
 
+	respondWithJson(rw, http.StatusOK, user)
 }
 
 type jsonTokenResponse struct {
@@ -276,97 +977,265 @@ type jsonTokenResponse struct {
 // @Produce json
 // @Param Authorization header string true "Refresh token"
 // @Success 200 {object} handler.jsonTokenResponse "New access token"
-// @Failure 400 {object} handler.responseError "Something is wrong in provided information"
-// @Failure 401 {object} handler.responseError "User is unauthorized"
-// @Failure 500 {object} handler.responseError "Internal server error"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "Account is suspended or banned"
+// @Failure 429 {object} handler.problemDetails "Too many requests"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
 // @Router /api/refresh [post]
 func (ah *ApiHandler) refreshAccessToken(rw http.ResponseWriter, req *http.Request) {
-	tokenServ := service.TokenService{Queries: ah.ApiCfg.Queries}
-	newToken, status, err := tokenServ.RefreshAccessToken(req.Context(), req.Header, ah.ApiCfg)
+	newToken, err := ah.tokenServ.RefreshAccessToken(req.Context(), req.Header)
 	if err != nil {
-		respondWithError(rw, status, fmt.Sprintf("error in token refreshing: %s", err))
+		respondWithServiceError(rw, req, err)
 		return
 	}
 	jsonToken := jsonTokenResponse{Token: newToken}
-	respondWithJson(rw, status, jsonToken)
+	respondWithJson(rw, http.StatusOK, jsonToken)
 }
 
 // @Summary Revoke refresh token
 // @Description Revoke specific refresh token
 // @Param Authorization header string true "Refresh token"
 // @Success 204
-// @Failure 400 {object} handler.responseError "Something is wrong in provided information"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
 // @Router /api/revoke [post]
 func (ah *ApiHandler) revokeRefreshToken(rw http.ResponseWriter, req *http.Request) {
-	tokenServ := service.TokenService{Queries: ah.ApiCfg.Queries}
-	status, err := tokenServ.RevokeRefreshToken(req.Context(), req.Header)
+	err := ah.tokenServ.RevokeRefreshToken(req.Context(), req.Header)
 	if err != nil {
-		respondWithError(rw, status, fmt.Sprintf("cannot revoke token: %s", err))
+		respondWithServiceError(rw, req, err)
 		return
 	}
-	respondWithJson(rw, status, nil)
+	respondWithJson(rw, http.StatusNoContent, nil)
 }
 
 // @Summary Update user's credentials
-// @Description Update specific user's credentials by it's access token
+// @Description Update user's email and password after current password confirmation (accounts created through social login have no password and skip it). Requires user's own access token, third-party and personal tokens are rejected
+// @Accept json
 // @Produce json
 // @Param Authorization header string true "Access token"
-// @Param email body string true "User's new email"
-// @Param password body string true "User's new password"
+// @Param request body models.UpdateUserRequest true "User's new email and password and current password"
 // @Success 200 {object} models.UserResponse "User with updated credentials"
-// @Failure 400 {object} handler.responseError "Something is wrong in provided information"
-// @Failure 401 {object} handler.responseError "User is unauthorized"
-// @Failure 500 {object} handler.responseError "Internal server error"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized or current password is incorrect"
+// @Failure 403 {object} handler.problemDetails "Action requires user's own access token"
+// @Failure 409 {object} handler.problemDetails "User with this email already exists"
+// @Failure 429 {object} handler.problemDetails "Too many failed attempts"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
 // @Router /api/users [put]
 func (ah *ApiHandler) updateUser(rw http.ResponseWriter, req *http.Request) {
-	var reqBodyData models.UserRequest
+	var reqBodyData models.UpdateUserRequest
+	err := json.NewDecoder(req.Body).Decode(&reqBodyData)
+	defer req.Body.Close()
+	if err != nil {
+		respondWithError(rw, req, http.StatusBadRequest, fmt.Sprintf("cannot decode user: %s", err))
+		return
+	}
+
+	user, err := ah.userServ.UpdateUser(req.Context(), auth.PrincipalFromContext(req.Context()), reqBodyData, ah.clientIP(req))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, user)
+}
 
+// @Summary Delete account
+// @Description Schedule deletion of the user's account after password confirmation (accounts created through social login have no password and skip it). Logging in during the grace period cancels the deletion
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Access token"
+// @Param request body models.DeleteAccountRequest true "User's current password, empty for accounts created through social login"
+// @Success 202 {object} models.AccountDeletionResponse "Time when the account will be deleted"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized or password is incorrect"
+// @Failure 403 {object} handler.problemDetails "Action requires user's own access token"
+// @Failure 429 {object} handler.problemDetails "Too many failed attempts"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/users/me [delete]
+func (ah *ApiHandler) deleteAccount(rw http.ResponseWriter, req *http.Request) {
+	var reqBodyData models.DeleteAccountRequest
 	err := json.NewDecoder(req.Body).Decode(&reqBodyData)
+	defer req.Body.Close()
 	if err != nil {
-		respondWithError(rw, http.StatusInternalServerError, "cannot decode json")
+		respondWithError(rw, req, http.StatusBadRequest, fmt.Sprintf("cannot decode request: %s", err))
 		return
 	}
 
-	userServ := service.UserService{ApiConfig: ah.ApiCfg}
+	deletion, err := ah.userServ.DeleteAccount(req.Context(), auth.PrincipalFromContext(req.Context()), reqBodyData.Password, ah.clientIP(req))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusAccepted, deletion)
+}
+
+// @Summary Report message
+// @Description Send message to moderation queue
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Access token"
+// @Param messageID path string true "ID of the message"
+// @Param report body models.ReportRequest true "Reason (spam, harassment, hate_speech, violence, sexual_content or other) and details"
+// @Success 201 {object} models.ReportResponse "Created report"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "Token does not have required scope"
+// @Failure 404 {object} handler.problemDetails "Message not found"
+// @Failure 409 {object} handler.problemDetails "Message is already reported"
+// @Failure 429 {object} handler.problemDetails "Too many requests"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/messages/{messageID}/report [post]
+func (ah *ApiHandler) reportMessage(rw http.ResponseWriter, req *http.Request) {
+	var reqBodyData models.ReportRequest
+	err := json.NewDecoder(req.Body).Decode(&reqBodyData)
+	defer req.Body.Close()
+	if err != nil {
+		respondWithError(rw, req, http.StatusBadRequest, fmt.Sprintf("cannot decode report: %s", err))
+		return
+	}
 
-	dbUser, status, err := userServ.UpdateUser(req.Context(), req.Header, reqBodyData.Email, reqBodyData.Password)
+	report, err := ah.reportServ.ReportMessage(req.Context(), auth.PrincipalFromContext(req.Context()), req.PathValue("messageID"), reqBodyData)
 	if err != nil {
-		respondWithError(rw, status, fmt.Sprintf("cannot update user: %s", err))
+		respondWithServiceError(rw, req, err)
 		return
 	}
 
-	respondWithJson(rw, status, dbUser)
+	respondWithJson(rw, http.StatusCreated, report)
+}
+
+// @Summary Report user
+// @Description Send user to moderation queue
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Access token"
+// @Param userID path string true "ID of the user"
+// @Param report body models.ReportRequest true "Reason (spam, harassment, hate_speech, violence, sexual_content or other) and details"
+// @Success 201 {object} models.ReportResponse "Created report"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "Token does not have required scope"
+// @Failure 404 {object} handler.problemDetails "User not found"
+// @Failure 409 {object} handler.problemDetails "User is already reported"
+// @Failure 429 {object} handler.problemDetails "Too many requests"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/users/{userID}/report [post]
+func (ah *ApiHandler) reportUser(rw http.ResponseWriter, req *http.Request) {
+	var reqBodyData models.ReportRequest
+	err := json.NewDecoder(req.Body).Decode(&reqBodyData)
+	defer req.Body.Close()
+	if err != nil {
+		respondWithError(rw, req, http.StatusBadRequest, fmt.Sprintf("cannot decode report: %s", err))
+		return
+	}
+
+	report, err := ah.reportServ.ReportUser(req.Context(), auth.PrincipalFromContext(req.Context()), req.PathValue("userID"), reqBodyData)
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusCreated, report)
+}
+
+// @Summary Request data export
+// @Description Start building zip archive with user's profile, messages, sessions and payment history. If an export is already in progress, it is returned
+// @Produce json
+// @Param Authorization header string true "Access token"
+// @Success 202 {object} models.DataExportResponse "Export status"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "Action requires user's own access token"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/users/me/export [post]
+func (ah *ApiHandler) requestDataExport(rw http.ResponseWriter, req *http.Request) {
+	export, err := ah.exportServ.RequestExport(req.Context(), auth.PrincipalFromContext(req.Context()))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusAccepted, export)
+}
+
+// @Summary Get data export
+// @Description Get status of user's data export. Completed export contains signed download url valid for an hour
+// @Produce json
+// @Param Authorization header string true "Access token"
+// @Param exportID path string true "ID of the export"
+// @Success 200 {object} models.DataExportResponse "Export status"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "Action requires user's own access token"
+// @Failure 404 {object} handler.problemDetails "Export not found"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/users/me/export/{exportID} [get]
+func (ah *ApiHandler) getDataExport(rw http.ResponseWriter, req *http.Request) {
+	export, err := ah.exportServ.GetExport(req.Context(), auth.PrincipalFromContext(req.Context()), req.PathValue("exportID"))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, export)
+}
+
+// @Summary Download data export
+// @Description Download zip archive of completed data export by signed url from export status
+// @Produce application/zip
+// @Param exportID path string true "ID of the export"
+// @Param expires query string true "Link expiration time"
+// @Param signature query string true "Link signature"
+// @Success 200 {file} file "Zip archive"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 403 {object} handler.problemDetails "Link is not valid or has expired"
+// @Failure 404 {object} handler.problemDetails "Export not found"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/exports/{exportID}/download [get]
+func (ah *ApiHandler) downloadDataExport(rw http.ResponseWriter, req *http.Request) {
+	exportID := req.PathValue("exportID")
+
+	archive, err := ah.exportServ.OpenExport(req.Context(), exportID, req.URL.Query())
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+	defer archive.Close()
+
+	rw.Header().Set("Content-Type", "application/zip")
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"export-%s.zip\"", exportID))
+	rw.WriteHeader(http.StatusOK)
+	io.Copy(rw, archive)
 }
 
 // @Summary Delete message
 // @Description Delete specific message by it's id
 // @Param messageID path string true "ID of message that needs to be deleted"
 // @Success 204
-// @Failure 400 {object} handler.responseError "Something is wrong in provided information"
-// @Failure 401 {object} handler.responseError "User is unauthorized"
-// @Failure 403 {object} handler.responseError "User cannot delete this message"
-// @Failure 404 {object} handler.responseError "Message is not found"
-// @Failure 500 {object} handler.responseError "Internal server error"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "User cannot delete this message"
+// @Failure 404 {object} handler.problemDetails "Message is not found"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
 // @Router /api/messages/{messageID} [delete]
 func (ah *ApiHandler) deleteMessage(rw http.ResponseWriter, req *http.Request) {
-	messageServ := service.MessageService{ApiConfig: ah.ApiCfg}
 	messageID := req.PathValue("messageID")
 
-	status, err := messageServ.DeleteMessage(req.Context(), req.Header, messageID)
+	err := ah.messageServ.DeleteMessage(req.Context(), auth.PrincipalFromContext(req.Context()), messageID)
 	if err != nil {
-		respondWithError(rw, status, fmt.Sprintf("error in message deletion: %s", err))
+		respondWithServiceError(rw, req, err)
 		return
 	}
 
-	respondWithJson(rw, status, nil)
+	respondWithJson(rw, http.StatusNoContent, nil)
 }
 
 // @Summary Delete message
 // @Description Delete specific message by it's id
 // @Param messageID path string true "ID of message that needs to be deleted"
 // @Success 204
-// @Failure 401 {object} handler.responseError "Wrong api key"
-// @Failure 500 {object} handler.responseError "Internal server error"
+// @Failure 401 {object} handler.problemDetails "Wrong api key"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
 // @Router /api/payment/webhook [post]
 func (ah *ApiHandler) proceedPayment(rw http.ResponseWriter, req *http.Request) {
 	var reqBodyData models.PaymentProviderWebhook
@@ -377,7 +1246,490 @@ func (ah *ApiHandler) proceedPayment(rw http.ResponseWriter, req *http.Request)
 	}
 
 	reqHeader := req.Header
-	paymentServ := service.PaymentService{ApiConfig: ah.ApiCfg}
-	status := paymentServ.UpgradeToPremium(req.Context(), reqBodyData, reqHeader)
-	rw.WriteHeader(status)
+	err = ah.paymentServ.UpgradeToPremium(req.Context(), reqBodyData, reqHeader)
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Start social login
+// @Description Redirect to OpenID Connect provider. If access token is provided, the provider's identity will be linked to the user
+// @Param provider path string true "Provider name"
+// @Param Authorization header string false "Access token (for account linking)"
+// @Success 302
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 404 {object} handler.problemDetails "Provider is not found"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Failure 502 {object} handler.problemDetails "Provider is unavailable"
+// @Router /api/auth/{provider}/start [get]
+func (ah *ApiHandler) startOIDCAuth(rw http.ResponseWriter, req *http.Request) {
+	authURL, state, err := ah.oidcServ.StartAuth(req.Context(), auth.PrincipalFromContext(req.Context()), req.PathValue("provider"))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	http.SetCookie(rw, oidcStateCookie(req, auth.HashOIDCState(state), int(oidcStateCookieTTL.Seconds())))
+	http.Redirect(rw, req, authURL, http.StatusFound)
+}
+
+// @Summary Finish social login
+// @Description Callback for OpenID Connect provider. Logs the user in (creating an account if needed) or links the identity
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 200 {object} models.UserResponse "User's data (with tokens when logging in)"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "ID token is not valid"
+// @Failure 404 {object} handler.problemDetails "Provider is not found"
+// @Failure 409 {object} handler.problemDetails "Identity or email is already used by another user"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Failure 502 {object} handler.problemDetails "Provider is unavailable"
+// @Router /api/auth/{provider}/callback [get]
+func (ah *ApiHandler) finishOIDCAuth(rw http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	stateHash := ""
+	cookie, err := req.Cookie(oidcStateCookieName)
+	if err == nil {
+		stateHash = cookie.Value
+	}
+	// the state can be used only once, so the cookie is not needed anymore
+	http.SetCookie(rw, oidcStateCookie(req, "", -1))
+
+	user, err := ah.oidcServ.HandleCallback(req.Context(), req.PathValue("provider"), query.Get("code"), query.Get("state"), stateHash, query.Get("error"))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, user)
+}
+
+// oidcStateCookie binds the social login flow to the browser that started it. The cookie is
+// sent on the provider's top-level redirect back to the callback, so SameSite=Lax is enough.
+func oidcStateCookie(req *http.Request, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    value,
+		Path:     "/api/auth/" + req.PathValue("provider"),
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   req.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// @Summary Linked identities
+// @Description Get external identities linked to the user
+// @Produce json
+// @Param Authorization header string true "Access token"
+// @Success 200 {array} models.UserIdentityResponse "List of identities"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/auth/identities [get]
+func (ah *ApiHandler) getUserIdentities(rw http.ResponseWriter, req *http.Request) {
+	identities, err := ah.oidcServ.GetUserIdentities(req.Context(), auth.PrincipalFromContext(req.Context()))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, identities)
+}
+
+// @Summary Register OAuth client
+// @Description Register third-party application that can act on behalf of users. Secret is returned only once and only for confidential clients
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Access token"
+// @Param client body models.OAuthClientRequest true "Client's name, redirect uris and scopes"
+// @Success 201 {object} models.OAuthClientResponse "Registered client"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/oauth/clients [post]
+func (ah *ApiHandler) registerOAuthClient(rw http.ResponseWriter, req *http.Request) {
+	var reqBodyData models.OAuthClientRequest
+	err := json.NewDecoder(req.Body).Decode(&reqBodyData)
+	defer req.Body.Close()
+	if err != nil {
+		respondWithError(rw, req, http.StatusBadRequest, fmt.Sprintf("cannot decode client: %s", err))
+		return
+	}
+
+	client, err := ah.oauthServ.RegisterClient(req.Context(), auth.PrincipalFromContext(req.Context()), reqBodyData)
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusCreated, client)
+}
+
+// @Summary User's OAuth clients
+// @Description Get OAuth clients registered by the user
+// @Produce json
+// @Param Authorization header string true "Access token"
+// @Success 200 {array} models.OAuthClientResponse "List of clients"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/oauth/clients [get]
+func (ah *ApiHandler) getOAuthClients(rw http.ResponseWriter, req *http.Request) {
+	clients, err := ah.oauthServ.GetClientsForUser(req.Context(), auth.PrincipalFromContext(req.Context()))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, clients)
+}
+
+// @Summary Get OAuth client
+// @Description Get public information about OAuth client
+// @Produce json
+// @Param clientID path string true "clientID"
+// @Success 200 {object} models.OAuthClientResponse "Client information"
+// @Failure 404 {object} handler.problemDetails "Client not found"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/oauth/clients/{clientID} [get]
+func (ah *ApiHandler) getOAuthClient(rw http.ResponseWriter, req *http.Request) {
+	client, err := ah.oauthServ.GetClient(req.Context(), req.PathValue("clientID"))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, client)
+}
+
+// @Summary Delete OAuth client
+// @Description Delete OAuth client registered by the user, access tokens issued to the client stop working
+// @Param Authorization header string true "Access token"
+// @Param clientID path string true "clientID"
+// @Success 204
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 404 {object} handler.problemDetails "Client not found"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/oauth/clients/{clientID} [delete]
+func (ah *ApiHandler) deleteOAuthClient(rw http.ResponseWriter, req *http.Request) {
+	err := ah.oauthServ.DeleteClient(req.Context(), auth.PrincipalFromContext(req.Context()), req.PathValue("clientID"))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusNoContent, nil)
+}
+
+// @Summary OAuth authorization endpoint
+// @Description Validate authorization request and redirect user to the consent screen
+// @Param response_type query string true "Must be 'code'"
+// @Param client_id query string true "Client's id"
+// @Param redirect_uri query string true "One of client's redirect uris"
+// @Param scope query string false "Space separated scopes"
+// @Param state query string false "State"
+// @Param code_challenge query string true "PKCE code challenge"
+// @Param code_challenge_method query string true "Must be 'S256'"
+// @Success 302
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/oauth/authorize [get]
+func (ah *ApiHandler) showOAuthConsent(rw http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	_, err := ah.oauthServ.ValidateAuthorizeRequest(req.Context(), models.OAuthAuthorizeRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	})
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	http.Redirect(rw, req, "/app/consent.html?"+req.URL.RawQuery, http.StatusFound)
+}
+
+// @Summary OAuth consent
+// @Description Approve or deny authorization request on behalf of the user, returns where the user should be redirected
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Access token"
+// @Param request body models.OAuthAuthorizeRequest true "Authorization request and user's decision"
+// @Success 200 {object} models.OAuthAuthorizeResponse "Client's redirect uri with code or error"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/oauth/authorize [post]
+func (ah *ApiHandler) authorizeOAuthClient(rw http.ResponseWriter, req *http.Request) {
+	var reqBodyData models.OAuthAuthorizeRequest
+	err := json.NewDecoder(req.Body).Decode(&reqBodyData)
+	defer req.Body.Close()
+	if err != nil {
+		respondWithError(rw, req, http.StatusBadRequest, fmt.Sprintf("cannot decode authorization request: %s", err))
+		return
+	}
+
+	redirect, err := ah.oauthServ.Authorize(req.Context(), auth.PrincipalFromContext(req.Context()), reqBodyData)
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, redirect)
+}
+
+// @Summary OAuth token endpoint
+// @Description Exchange authorization code for scoped access token. Confidential clients authenticate with HTTP Basic or client_secret parameter
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "Must be 'authorization_code'"
+// @Param code formData string true "Authorization code"
+// @Param redirect_uri formData string true "Redirect uri used in authorization request"
+// @Param client_id formData string true "Client's id"
+// @Param client_secret formData string false "Client's secret"
+// @Param code_verifier formData string true "PKCE code verifier"
+// @Success 200 {object} models.OAuthTokenResponse "Access token"
+// @Failure 400 {object} models.OAuthErrorResponse "Request is invalid"
+// @Failure 401 {object} models.OAuthErrorResponse "Client authentication failed"
+// @Failure 429 {object} handler.problemDetails "Too many requests"
+// @Failure 500 {object} models.OAuthErrorResponse "Internal server error"
+// @Router /api/oauth/token [post]
+func (ah *ApiHandler) issueOAuthToken(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Cache-Control", "no-store")
+
+	err := req.ParseForm()
+	if err != nil {
+		respondWithJson(rw, http.StatusBadRequest, models.OAuthErrorResponse{Error: "invalid_request", ErrorDescription: "cannot parse form"})
+		return
+	}
+
+	tokenRequest := models.OAuthTokenRequest{
+		GrantType:    req.PostForm.Get("grant_type"),
+		Code:         req.PostForm.Get("code"),
+		RedirectURI:  req.PostForm.Get("redirect_uri"),
+		ClientID:     req.PostForm.Get("client_id"),
+		ClientSecret: req.PostForm.Get("client_secret"),
+		CodeVerifier: req.PostForm.Get("code_verifier"),
+	}
+	if clientID, clientSecret, ok := req.BasicAuth(); ok {
+		tokenRequest.ClientID = clientID
+		tokenRequest.ClientSecret = clientSecret
+	}
+
+	token, err := ah.oauthServ.ExchangeCode(req.Context(), tokenRequest)
+	if err != nil {
+		status := errorStatus(service.ErrorKindOf(err))
+		var oauthErr *service.OAuthError
+		if errors.As(err, &oauthErr) {
+			respondWithJson(rw, status, models.OAuthErrorResponse{Error: oauthErr.Code, ErrorDescription: oauthErr.Description})
+			return
+		}
+		logging.FromContext(req.Context()).Error("cannot issue oauth token", "error", err)
+		respondWithJson(rw, status, models.OAuthErrorResponse{Error: "server_error"})
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, token)
+}
+
+// @Summary Personal access tokens
+// @Description Get user's personal access tokens (without token values)
+// @Produce json
+// @Param Authorization header string true "Access token"
+// @Success 200 {array} models.PersonalTokenResponse "List of tokens"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/tokens [get]
+func (ah *ApiHandler) getPersonalTokens(rw http.ResponseWriter, req *http.Request) {
+	tokens, err := ah.personalTokenServ.GetTokens(req.Context(), auth.PrincipalFromContext(req.Context()))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, tokens)
+}
+
+// @Summary Create personal access token
+// @Description Create long-lived scoped token for scripts and bots. Token value is returned only once
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Access token"
+// @Param token body models.PersonalTokenRequest true "Token's name, scopes and lifetime in days (0 means never expires)"
+// @Success 201 {object} models.PersonalTokenResponse "Created token"
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 409 {object} handler.problemDetails "Too many tokens"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/tokens [post]
+func (ah *ApiHandler) createPersonalToken(rw http.ResponseWriter, req *http.Request) {
+	var reqBodyData models.PersonalTokenRequest
+	err := json.NewDecoder(req.Body).Decode(&reqBodyData)
+	defer req.Body.Close()
+	if err != nil {
+		respondWithError(rw, req, http.StatusBadRequest, fmt.Sprintf("cannot decode token: %s", err))
+		return
+	}
+
+	token, err := ah.personalTokenServ.CreateToken(req.Context(), auth.PrincipalFromContext(req.Context()), reqBodyData)
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusCreated, token)
+}
+
+// @Summary Delete personal access token
+// @Description Revoke user's personal access token
+// @Param Authorization header string true "Access token"
+// @Param tokenID path string true "tokenID"
+// @Success 204
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 404 {object} handler.problemDetails "Token not found"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/tokens/{tokenID} [delete]
+func (ah *ApiHandler) deletePersonalToken(rw http.ResponseWriter, req *http.Request) {
+	err := ah.personalTokenServ.DeleteToken(req.Context(), auth.PrincipalFromContext(req.Context()), req.PathValue("tokenID"))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusNoContent, nil)
+}
+
+// @Summary Get blocked users
+// @Description Get users blocked by the user
+// @Produce json
+// @Param Authorization header string true "Access token"
+// @Success 200 {array} models.UserRelationResponse "Blocked users"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "Action requires user's own access token"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/blocks [get]
+func (ah *ApiHandler) getBlockedUsers(rw http.ResponseWriter, req *http.Request) {
+
+	users, err := ah.blockServ.GetBlockedUsers(req.Context(), auth.PrincipalFromContext(req.Context()))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, users)
+}
+
+// @Summary Block user
+// @Description Block the user. Blocked user and the blocker do not see each other's messages
+// @Param Authorization header string true "Access token"
+// @Param userID path string true "ID of the user"
+// @Success 204
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "Token does not have required scope"
+// @Failure 404 {object} handler.problemDetails "User not found"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/blocks/{userID} [put]
+func (ah *ApiHandler) blockUser(rw http.ResponseWriter, req *http.Request) {
+
+	err := ah.blockServ.BlockUser(req.Context(), auth.PrincipalFromContext(req.Context()), req.PathValue("userID"))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusNoContent, nil)
+}
+
+// @Summary Unblock user
+// @Description Remove block of the user
+// @Param Authorization header string true "Access token"
+// @Param userID path string true "ID of the user"
+// @Success 204
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "Token does not have required scope"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/blocks/{userID} [delete]
+func (ah *ApiHandler) unblockUser(rw http.ResponseWriter, req *http.Request) {
+
+	err := ah.blockServ.UnblockUser(req.Context(), auth.PrincipalFromContext(req.Context()), req.PathValue("userID"))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusNoContent, nil)
+}
+
+// @Summary Get muted users
+// @Description Get users muted by the user
+// @Produce json
+// @Param Authorization header string true "Access token"
+// @Success 200 {array} models.UserRelationResponse "Muted users"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "Action requires user's own access token"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/mutes [get]
+func (ah *ApiHandler) getMutedUsers(rw http.ResponseWriter, req *http.Request) {
+
+	users, err := ah.blockServ.GetMutedUsers(req.Context(), auth.PrincipalFromContext(req.Context()))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusOK, users)
+}
+
+// @Summary Mute user
+// @Description Mute the user. Messages of muted user are left out of the user's message lists
+// @Param Authorization header string true "Access token"
+// @Param userID path string true "ID of the user"
+// @Success 204
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "Token does not have required scope"
+// @Failure 404 {object} handler.problemDetails "User not found"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/mutes/{userID} [put]
+func (ah *ApiHandler) muteUser(rw http.ResponseWriter, req *http.Request) {
+
+	err := ah.blockServ.MuteUser(req.Context(), auth.PrincipalFromContext(req.Context()), req.PathValue("userID"))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusNoContent, nil)
+}
+
+// @Summary Unmute user
+// @Description Remove mute of the user
+// @Param Authorization header string true "Access token"
+// @Param userID path string true "ID of the user"
+// @Success 204
+// @Failure 400 {object} handler.problemDetails "Something is wrong in provided information"
+// @Failure 401 {object} handler.problemDetails "User is unauthorized"
+// @Failure 403 {object} handler.problemDetails "Token does not have required scope"
+// @Failure 500 {object} handler.problemDetails "Internal server error"
+// @Router /api/mutes/{userID} [delete]
+func (ah *ApiHandler) unmuteUser(rw http.ResponseWriter, req *http.Request) {
+
+	err := ah.blockServ.UnmuteUser(req.Context(), auth.PrincipalFromContext(req.Context()), req.PathValue("userID"))
+	if err != nil {
+		respondWithServiceError(rw, req, err)
+		return
+	}
+
+	respondWithJson(rw, http.StatusNoContent, nil)
 }
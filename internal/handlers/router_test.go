@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/models"
+	_ "modernc.org/sqlite"
+)
+
+// newTestApiConfig returns handlers' dependencies backed by in-memory SQLite database
+func newTestApiConfig(t *testing.T) *config.ApiConfig {
+	t.Helper()
+
+	cfg := config.Config{
+		DBDriver:                    config.DBDriverSQLite,
+		DBURL:                       ":memory:",
+		JWTSecret:                   "test-secret",
+		AccessTokenTTL:              time.Hour,
+		RefreshTokenTTL:             time.Hour,
+		AccountDeletionGracePeriod:  time.Hour,
+		StorageDir:                  t.TempDir(),
+		ContentFilterReloadInterval: time.Minute,
+		RateLimitStore:              config.RateLimitStoreMemory,
+		PasswordPolicy:              auth.DefaultPasswordPolicy(),
+		PasswordHash:                auth.Argon2Params{MemoryKiB: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+	}
+	apiCfg, err := config.InitializeApiConfig(context.Background(), cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("cannot initialize api config: %s", err)
+	}
+	t.Cleanup(func() { apiCfg.DB.Close() })
+	return apiCfg
+}
+
+func TestClientIP(t *testing.T) {
+	trustedProxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		expected     string
+	}{
+		{"direct request", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"header from untrusted address", "203.0.113.7:5000", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted proxy", "10.0.0.1:5000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"forged addresses left of the client", "10.0.0.1:5000", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"chain of trusted proxies", "10.0.0.1:5000", []string{"198.51.100.1, 10.0.0.2", "10.0.0.3"}, "198.51.100.1"},
+		{"trusted proxy without header", "10.0.0.1:5000", nil, "10.0.0.1"},
+		{"invalid address in header", "10.0.0.1:5000", []string{"198.51.100.1, unknown"}, "10.0.0.1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = test.remoteAddr
+			for _, value := range test.forwardedFor {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			ip := clientIP(req, trustedProxies)
+			if ip != test.expected {
+				t.Fatalf("expected %s, got %s", test.expected, ip)
+			}
+		})
+	}
+}
+
+func TestConsentPageCannotBeFramed(t *testing.T) {
+	handler := denyFraming(http.StripPrefix("/app", http.FileServer(http.Dir("../../assets"))))
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest("GET", "/app/consent.html", nil))
+	if rw.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rw.Code)
+	}
+	if rw.Header().Get("X-Frame-Options") != "DENY" || rw.Header().Get("Content-Security-Policy") != "frame-ancestors 'none'" {
+		t.Fatalf("consent page can be framed: %v", rw.Header())
+	}
+}
+
+func TestMalformedBodyIsBadRequest(t *testing.T) {
+	ah := &ApiHandler{}
+	handlers := map[string]http.HandlerFunc{
+		"create message": ah.createMessage,
+		"login":          ah.loginUser,
+		"update user":    ah.updateUser,
+	}
+	for name, handler := range handlers {
+		t.Run(name, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			handler(rw, httptest.NewRequest("POST", "/", strings.NewReader("{not json")))
+			if rw.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", rw.Code, rw.Body)
+			}
+			if contentType := rw.Header().Get("Content-Type"); contentType != "application/problem+json" {
+				t.Fatalf("expected problem details, got %s", contentType)
+			}
+			if !strings.Contains(rw.Body.String(), "cannot decode") {
+				t.Fatalf("detail does not explain the error: %s", rw.Body)
+			}
+		})
+	}
+}
+
+func TestDelegatedTokensCannotChangeCredentials(t *testing.T) {
+	apiCfg := newTestApiConfig(t)
+	ah := NewApiHandler(apiCfg)
+	ctx := context.Background()
+
+	user, err := ah.userServ.CreateUser(ctx, models.UserRequest{Email: "user@example.com", Password: "Correct-horse-98battery"})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+	session := auth.Principal{UserID: user.ID, TokenType: auth.TokenTypeSession}
+	personalToken, err := ah.personalTokenServ.CreateToken(ctx, session, models.PersonalTokenRequest{Name: "bot", Scopes: []string{auth.ScopeProfileWrite}})
+	if err != nil {
+		t.Fatalf("cannot create personal token: %s", err)
+	}
+	client, err := ah.oauthServ.RegisterClient(ctx, session, models.OAuthClientRequest{Name: "app", RedirectURIs: []string{"https://app.example.com/callback"}, Scopes: []string{auth.ScopeProfileWrite}})
+	if err != nil {
+		t.Fatalf("cannot register client: %s", err)
+	}
+	oauthToken, err := auth.MakeClientJWT(user.ID, client.ID, []string{auth.ScopeProfileWrite}, apiCfg.JWTSecret, time.Hour)
+	if err != nil {
+		t.Fatalf("cannot create oauth token: %s", err)
+	}
+
+	body := `{"email": "attacker@example.com", "password": "Battery-staple-42horse", "current_password": "Correct-horse-98battery"}`
+	for name, token := range map[string]string{"personal token": personalToken.Token, "oauth token": oauthToken} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", "/api/users", strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			rw := httptest.NewRecorder()
+			ah.requireAuth(ah.updateUser)(rw, req)
+			if rw.Code != http.StatusForbidden {
+				t.Fatalf("expected 403, got %d: %s", rw.Code, rw.Body)
+			}
+		})
+	}
+
+	dbUser, err := apiCfg.Queries.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("cannot get user: %s", err)
+	}
+	if dbUser.Email != "user@example.com" {
+		t.Fatalf("email was changed to %s", dbUser.Email)
+	}
+}
+
+func TestUpdateUserResponseHasNoPrivateFields(t *testing.T) {
+	apiCfg := newTestApiConfig(t)
+	ah := NewApiHandler(apiCfg)
+	user, err := ah.userServ.CreateUser(context.Background(), models.UserRequest{Email: "user@example.com", Password: "Correct-horse-98battery"})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+	token, err := auth.MakeJWT(user.ID, apiCfg.JWTSecret, time.Hour)
+	if err != nil {
+		t.Fatalf("cannot create token: %s", err)
+	}
+
+	body := `{"email": "new@example.com", "password": "Battery-staple-42horse", "current_password": "Correct-horse-98battery"}`
+	req := httptest.NewRequest("PUT", "/api/users", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rw := httptest.NewRecorder()
+	ah.requireAuth(ah.updateUser)(rw, req)
+	if rw.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rw.Code, rw.Body)
+	}
+
+	var response map[string]any
+	err = json.Unmarshal(rw.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatalf("cannot decode response: %s", err)
+	}
+	if response["email"] != "new@example.com" {
+		t.Fatalf("unexpected response: %s", rw.Body)
+	}
+	for _, field := range []string{"hashed_password", "is_admin", "status_reason", "suspended_until", "deletion_scheduled_at"} {
+		if _, ok := response[field]; ok {
+			t.Fatalf("response contains %s: %s", field, rw.Body)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DatabaseCheck pings the database
+func DatabaseCheck(db *sql.DB) Check {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// MigrationsCheck fails until goose applied at least expectedVersion, newer versions are fine
+// because migrations are applied before new instances of the server are started
+func MigrationsCheck(db *sql.DB, expectedVersion int64) Check {
+	return func(ctx context.Context) error {
+		var version int64
+		err := db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied").Scan(&version)
+		if err != nil {
+			return fmt.Errorf("cannot get migration version: %w", err)
+		}
+		if version < expectedVersion {
+			return fmt.Errorf("database is at migration %d, expected %d", version, expectedVersion)
+		}
+		return nil
+	}
+}
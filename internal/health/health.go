@@ -0,0 +1,121 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusFailing  = "failing"
+	StatusDraining = "draining"
+
+	checkTimeout = 3 * time.Second
+)
+
+// Check returns error when the dependency is not usable
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Registry keeps liveness checks (the process must be restarted if they fail) and readiness checks
+// (the process must not get traffic while they fail). Once draining, the process is not ready anymore.
+type Registry struct {
+	mu        sync.RWMutex
+	liveness  []namedCheck
+	readiness []namedCheck
+	draining  atomic.Bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (registry *Registry) AddLiveness(name string, check Check) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.liveness = append(registry.liveness, namedCheck{name: name, check: check})
+}
+
+func (registry *Registry) AddReadiness(name string, check Check) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.readiness = append(registry.readiness, namedCheck{name: name, check: check})
+}
+
+// SetDraining marks the process as shutting down, readiness fails from now on
+func (registry *Registry) SetDraining() {
+	registry.draining.Store(true)
+}
+
+type CheckResult struct {
+	Name     string
+	Status   string
+	Error    string
+	Duration time.Duration
+}
+
+type Report struct {
+	Status string
+	Checks []CheckResult
+}
+
+func (report Report) OK() bool {
+	return report.Status == StatusOK
+}
+
+func (registry *Registry) Liveness(ctx context.Context) Report {
+	registry.mu.RLock()
+	checks := registry.liveness
+	registry.mu.RUnlock()
+	return runChecks(ctx, checks)
+}
+
+func (registry *Registry) Readiness(ctx context.Context) Report {
+	registry.mu.RLock()
+	checks := registry.readiness
+	registry.mu.RUnlock()
+
+	report := runChecks(ctx, checks)
+	if registry.draining.Load() {
+		report.Status = StatusDraining
+	}
+	return report
+}
+
+// runChecks runs checks concurrently, each of them gets checkTimeout to finish
+func runChecks(ctx context.Context, checks []namedCheck) Report {
+	report := Report{Status: StatusOK, Checks: make([]CheckResult, len(checks))}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.check(checkCtx)
+			result := CheckResult{Name: check.name, Status: StatusOK, Duration: time.Since(start)}
+			if err != nil {
+				result.Status = StatusFailing
+				result.Error = err.Error()
+			}
+			report.Checks[i] = result
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusFailing
+		}
+	}
+	return report
+}
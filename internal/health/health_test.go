@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	registry := NewRegistry()
+	registry.AddLiveness("jobs", func(ctx context.Context) error { return nil })
+	registry.AddReadiness("database", func(ctx context.Context) error { return nil })
+	registry.AddReadiness("cache", func(ctx context.Context) error { return errors.New("cache is down") })
+
+	liveness := registry.Liveness(ctx)
+	if !liveness.OK() || len(liveness.Checks) != 1 || liveness.Checks[0].Name != "jobs" {
+		t.Fatalf("expected passing liveness, got %+v", liveness)
+	}
+
+	readiness := registry.Readiness(ctx)
+	if readiness.OK() || readiness.Status != StatusFailing {
+		t.Fatalf("expected failing readiness, got %+v", readiness)
+	}
+	// results keep the order checks were added in
+	expected := []CheckResult{{Name: "database", Status: StatusOK}, {Name: "cache", Status: StatusFailing, Error: "cache is down"}}
+	for i, result := range readiness.Checks {
+		result.Duration = 0
+		if result != expected[i] {
+			t.Fatalf("expected %+v, got %+v", expected[i], result)
+		}
+	}
+
+	registry.SetDraining()
+	if readiness := registry.Readiness(ctx); readiness.Status != StatusDraining {
+		t.Fatalf("expected draining, got %+v", readiness)
+	}
+	if liveness := registry.Liveness(ctx); !liveness.OK() {
+		t.Fatalf("draining process is still alive, got %+v", liveness)
+	}
+}
+
+func TestChecksUseRequestContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	registry := NewRegistry()
+	registry.AddLiveness("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	report := registry.Liveness(ctx)
+	if report.OK() || report.Checks[0].Error != context.Canceled.Error() {
+		t.Fatalf("check must get context of the request, got %+v", report)
+	}
+}
+
+func TestMigrationsCheck(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("cannot open database: %s", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if err := DatabaseCheck(db)(ctx); err != nil {
+		t.Fatalf("database is not reachable: %s", err)
+	}
+	if err := MigrationsCheck(db, 1)(ctx); err == nil {
+		t.Fatal("expected error without goose table")
+	}
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE goose_db_version (version_id INTEGER, is_applied BOOLEAN);
+		INSERT INTO goose_db_version VALUES (0, true), (1, true), (2, true), (3, false)`)
+	if err != nil {
+		t.Fatalf("cannot create goose table: %s", err)
+	}
+	tests := []struct {
+		expectedVersion int64
+		ok              bool
+	}{
+		{1, true},
+		{2, true},
+		{3, false},
+	}
+	for _, test := range tests {
+		err := MigrationsCheck(db, test.expectedVersion)(ctx)
+		if (err == nil) != test.ok {
+			t.Fatalf("expected version %d: expected ok %t, got %v", test.expectedVersion, test.ok, err)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package httpstatus
+
+import "net/http"
+
+// Recorder remembers the status code written by a handler, for middlewares that report it after the request
+type Recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func NewRecorder(rw http.ResponseWriter) *Recorder {
+	return &Recorder{ResponseWriter: rw, status: http.StatusOK}
+}
+
+// Status returns the written status code, 200 if the handler did not write it explicitly
+func (recorder *Recorder) Status() int {
+	return recorder.status
+}
+
+// WroteHeader reports whether the response has been started
+func (recorder *Recorder) WroteHeader() bool {
+	return recorder.wroteHeader
+}
+
+func (recorder *Recorder) WriteHeader(status int) {
+	if !recorder.wroteHeader {
+		recorder.status = status
+		recorder.wroteHeader = true
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *Recorder) Write(data []byte) (int, error) {
+	recorder.wroteHeader = true
+	return recorder.ResponseWriter.Write(data)
+}
+
+// Unwrap lets http.ResponseController reach the original writer
+func (recorder *Recorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
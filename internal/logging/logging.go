@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+var Formats = []string{FormatJSON, FormatText}
+
+// New creates a logger writing records of at least level to w in one of Formats
+func New(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	options := &slog.HandlerOptions{Level: level}
+	switch format {
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+type loggerContextKey struct{}
+
+// contextLogger is shared by everyone holding the request's context, so attributes added deeper
+// in the handler chain (e.g. user ID after authentication) end up in the access log too
+type contextLogger struct {
+	mu     sync.Mutex
+	logger *slog.Logger
+}
+
+// NewContext returns ctx carrying logger, use With to add attributes to it later
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, &contextLogger{logger: logger})
+}
+
+// FromContext returns logger of ctx or the default one if ctx has none
+func FromContext(ctx context.Context) *slog.Logger {
+	holder, ok := ctx.Value(loggerContextKey{}).(*contextLogger)
+	if !ok {
+		return slog.Default()
+	}
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	return holder.logger
+}
+
+// With adds attributes to the logger of ctx, it does nothing if ctx has no logger
+func With(ctx context.Context, args ...any) {
+	holder, ok := ctx.Value(loggerContextKey{}).(*contextLogger)
+	if !ok {
+		return
+	}
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	holder.logger = holder.logger.With(args...)
+}
@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/httpstatus"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// incoming request IDs are kept only if they cannot break log lines or be used to flood them
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type requestIDContextKey struct{}
+
+// RequestIDFromContext returns ID of the request being handled or an empty string outside of requests
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// Middleware takes request ID from X-Request-ID header (or generates one), returns it in the response,
+// puts logger with the ID into request's context and logs every request once it is handled.
+// Route is the pattern matched by http.ServeMux, so next must pass the request to the mux as is.
+func Middleware(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requestID := req.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		rw.Header().Set(RequestIDHeader, requestID)
+
+		ctx := context.WithValue(req.Context(), requestIDContextKey{}, requestID)
+		requestLogger := logger.With("request_id", requestID)
+		// trace ID links the logs with the request's trace, it is valid if the request is traced or came with traceparent header
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+			requestLogger = requestLogger.With("trace_id", spanContext.TraceID().String())
+		}
+		ctx = NewContext(ctx, requestLogger)
+		req = req.WithContext(ctx)
+
+		recorder := httpstatus.NewRecorder(rw)
+		start := time.Now()
+		next.ServeHTTP(recorder, req)
+
+		level := slog.LevelInfo
+		if recorder.Status() >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		FromContext(ctx).LogAttrs(ctx, level, "request handled",
+			slog.String("method", req.Method),
+			slog.String("route", req.Pattern),
+			slog.String("path", req.URL.Path),
+			slog.Int("status", recorder.Status()),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("remote_addr", req.RemoteAddr),
+		)
+	})
+}
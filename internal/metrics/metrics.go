@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/httpstatus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// unmatchedRoute labels requests that did not match any route, so scanners cannot create new series
+	unmatchedRoute = "unmatched"
+	// otherMethod labels requests with non-standard methods for the same reason
+	otherMethod = "other"
+)
+
+var standardMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
+
+// Metrics holds the server's Prometheus collectors. HTTP metrics are labeled by route pattern
+// (e.g. "GET /api/messages/{messageID}") rather than path, so their number stays bounded.
+type Metrics struct {
+	handler http.Handler
+
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+
+	MessagesCreated   prometheus.Counter
+	Logins            prometheus.Counter
+	FailedLogins      prometheus.Counter
+	WebhooksProcessed *prometheus.CounterVec
+}
+
+// New registers HTTP, domain, database pool and Go runtime metrics, dbName labels the pool metrics
+func New(db *sql.DB, dbName string) *Metrics {
+	registry := prometheus.NewRegistry()
+	factory := promauto.With(registry)
+
+	metrics := &Metrics{
+		handler: promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry}),
+		requests: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of handled HTTP requests.",
+		}, []string{"method", "route", "status"}),
+		duration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time spent handling HTTP requests.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		inFlight: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests being handled right now.",
+		}, []string{"method", "route"}),
+		MessagesCreated: factory.NewCounter(prometheus.CounterOpts{
+			Name: "snserver_messages_created_total",
+			Help: "Number of created messages.",
+		}),
+		Logins: factory.NewCounter(prometheus.CounterOpts{
+			Name: "snserver_logins_total",
+			Help: "Number of sessions created by logging in with password or identity provider.",
+		}),
+		FailedLogins: factory.NewCounter(prometheus.CounterOpts{
+			Name: "snserver_failed_logins_total",
+			Help: "Number of logins rejected because of incorrect email or password.",
+		}),
+		WebhooksProcessed: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "snserver_payment_webhooks_processed_total",
+			Help: "Number of authenticated payment provider webhooks by result (processed, user_not_found or failed).",
+		}, []string{"result"}),
+	}
+
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(db, dbName),
+	)
+	return metrics
+}
+
+// Handler serves metrics in Prometheus exposition format
+func (metrics *Metrics) Handler() http.Handler {
+	return metrics.handler
+}
+
+// Instrument records requests served by next, labeled by the pattern of routes matching the request.
+// The route is looked up before serving so that in-flight requests are labeled by it too.
+func (metrics *Metrics) Instrument(routes *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, route := routes.Handler(req)
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := methodLabel(req.Method)
+
+		inFlight := metrics.inFlight.WithLabelValues(method, route)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		recorder := httpstatus.NewRecorder(rw)
+		start := time.Now()
+		next.ServeHTTP(recorder, req)
+
+		status := strconv.Itoa(recorder.Status())
+		metrics.requests.WithLabelValues(method, route, status).Inc()
+		metrics.duration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+func methodLabel(method string) string {
+	if slices.Contains(standardMethods, method) {
+		return method
+	}
+	return otherMethod
+}
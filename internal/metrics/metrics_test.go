@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestInstrumentLabels(t *testing.T) {
+	metrics := New(&sql.DB{}, "test")
+	routes := http.NewServeMux()
+	routes.HandleFunc("GET /api/messages/{messageID}", func(rw http.ResponseWriter, req *http.Request) {})
+	handler := metrics.Instrument(routes, routes)
+
+	for _, request := range []struct{ method, path string }{
+		{http.MethodGet, "/api/messages/1"},
+		{http.MethodGet, "/api/messages/2"},
+		{"BREW", "/api/messages/1"},
+		{"PROPFIND", "/unknown"},
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(request.method, request.path, nil))
+	}
+
+	tests := []struct {
+		method, route, status string
+		count                 float64
+	}{
+		{http.MethodGet, "GET /api/messages/{messageID}", "200", 2},
+		{otherMethod, unmatchedRoute, "405", 1},
+		{otherMethod, unmatchedRoute, "404", 1},
+	}
+	for _, test := range tests {
+		count := testutil.ToFloat64(metrics.requests.WithLabelValues(test.method, test.route, test.status))
+		if count != test.count {
+			t.Errorf("requests{%s, %s, %s} = %v, want %v", test.method, test.route, test.status, count, test.count)
+		}
+	}
+	if series := testutil.CollectAndCount(metrics.requests); series != len(tests) {
+		t.Errorf("got %d request series, want %d", series, len(tests))
+	}
+}
+
+func TestMethodLabel(t *testing.T) {
+	tests := map[string]string{
+		http.MethodGet:    http.MethodGet,
+		http.MethodDelete: http.MethodDelete,
+		"get":             otherMethod,
+		"X-CUSTOM":        otherMethod,
+	}
+	for method, want := range tests {
+		if got := methodLabel(method); got != want {
+			t.Errorf("methodLabel(%q) = %q, want %q", method, got, want)
+		}
+	}
+}
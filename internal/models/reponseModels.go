@@ -23,3 +23,130 @@ type UserResponse struct {
 	RefreshToken string    `json:"refresh_token,omitempty"`
 	IsPremium    bool      `json:"is_premium"`
 }
+
+type LoginAttemptResponse struct {
+	ID        uuid.UUID     `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	Email     string        `json:"email"`
+	IPAddress string        `json:"ip_address"`
+	UserID    uuid.NullUUID `json:"user_id" swaggertype:"string"`
+	Succeeded bool          `json:"succeeded"`
+}
+
+type UserIdentityResponse struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+}
+
+type OAuthClientResponse struct {
+	ID           string    `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	Name         string    `json:"name"`
+	Secret       string    `json:"secret,omitempty"`
+	Confidential bool      `json:"confidential"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+}
+
+type OAuthAuthorizeResponse struct {
+	RedirectURI string `json:"redirect_uri"`
+}
+
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type PersonalTokenResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Name       string     `json:"name"`
+	Token      string     `json:"token,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type AccountDeletionResponse struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+type DataExportResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+}
+
+type UserRelationResponse struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ReportResponse struct {
+	ID             uuid.UUID     `json:"id"`
+	CreatedAt      time.Time     `json:"created_at"`
+	ReporterID     uuid.NullUUID `json:"reporter_id" swaggertype:"string"`
+	TargetType     string        `json:"target_type"`
+	ReportedUserID uuid.UUID     `json:"reported_user_id"`
+	MessageID      uuid.NullUUID `json:"message_id" swaggertype:"string"`
+	MessageBody    string        `json:"message_body,omitempty"`
+	Reason         string        `json:"reason"`
+	Details        string        `json:"details"`
+	Status         string        `json:"status"`
+	ClaimedBy      uuid.NullUUID `json:"claimed_by" swaggertype:"string"`
+	ClaimedAt      *time.Time    `json:"claimed_at,omitempty"`
+	Resolution     string        `json:"resolution,omitempty"`
+	ResolvedAt     *time.Time    `json:"resolved_at,omitempty"`
+}
+
+type ModerationActionResponse struct {
+	ID           uuid.UUID     `json:"id"`
+	CreatedAt    time.Time     `json:"created_at"`
+	ModeratorID  uuid.NullUUID `json:"moderator_id" swaggertype:"string"`
+	ReportID     uuid.NullUUID `json:"report_id" swaggertype:"string"`
+	Action       string        `json:"action"`
+	TargetUserID uuid.NullUUID `json:"target_user_id" swaggertype:"string"`
+	MessageID    uuid.NullUUID `json:"message_id" swaggertype:"string"`
+	Note         string        `json:"note"`
+}
+
+type ContentFilterRuleResponse struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Term      string    `json:"term"`
+	Action    string    `json:"action"`
+}
+
+type UserStatusResponse struct {
+	UserID         uuid.UUID  `json:"user_id"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	Status         string     `json:"status"`
+	Reason         string     `json:"reason"`
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+}
+
+type HealthResponse struct {
+	Status string                `json:"status"`
+	Checks []HealthCheckResponse `json:"checks"`
+}
+
+type HealthCheckResponse struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
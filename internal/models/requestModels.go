@@ -9,9 +9,75 @@ type UserRequest struct {
 	Password string `json:"password"`
 }
 
+// UpdateUserRequest sets new credentials, CurrentPassword confirms them and is empty for accounts created through social login
+type UpdateUserRequest struct {
+	Email           string `json:"email"`
+	Password        string `json:"password"`
+	CurrentPassword string `json:"current_password"`
+}
+
 type PaymentProviderWebhook struct {
 	Event string `json:"event"`
 	Data  struct {
 		UserID string `json:"user_id"`
 	} `json:"data"`
 }
+
+type OAuthClientRequest struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	Confidential bool     `json:"confidential"`
+}
+
+type OAuthAuthorizeRequest struct {
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+	Approve             bool   `json:"approve"`
+}
+
+type OAuthTokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	ClientID     string
+	ClientSecret string
+	CodeVerifier string
+}
+
+type PersonalTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+type ReportRequest struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+type ResolveReportRequest struct {
+	Action      string `json:"action"`
+	Note        string `json:"note"`
+	SuspendDays int    `json:"suspend_days"`
+}
+
+type ContentFilterRuleRequest struct {
+	Term   string `json:"term"`
+	Action string `json:"action"`
+}
+
+type UserStatusRequest struct {
+	Status      string `json:"status"`
+	Reason      string `json:"reason"`
+	SuspendDays int    `json:"suspend_days"`
+}
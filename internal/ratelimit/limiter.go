@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy is a token bucket holding up to Limit requests, an empty bucket is refilled completely in Period
+type Policy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+}
+
+func (policy Policy) refillRate() float64 {
+	return float64(policy.Limit) / policy.Period.Seconds()
+}
+
+// ParsePolicy reads policy from "<limit>/<period>" string, e.g. "10/1m"
+func ParsePolicy(name, value string) (Policy, error) {
+	limitPart, periodPart, found := strings.Cut(value, "/")
+	if !found {
+		return Policy{}, fmt.Errorf("rate limit %q must be \"<limit>/<period>\"", value)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(limitPart))
+	if err != nil || limit <= 0 {
+		return Policy{}, fmt.Errorf("rate limit %q must have positive limit", value)
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(periodPart))
+	if err != nil || period <= 0 {
+		return Policy{}, fmt.Errorf("rate limit %q must have positive period", value)
+	}
+	return Policy{Name: name, Limit: limit, Period: period}, nil
+}
+
+// Decision about a single request
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long it takes for the bucket to be full again
+	Reset time.Duration
+	// RetryAfter is how long to wait before the next request is allowed, zero for allowed requests
+	RetryAfter time.Duration
+}
+
+// newDecision builds decision from tokens left in the bucket after the request
+func newDecision(policy Policy, tokens float64, allowed bool) Decision {
+	rate := policy.refillRate()
+	decision := Decision{
+		Allowed:   allowed,
+		Limit:     policy.Limit,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     time.Duration((float64(policy.Limit) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		decision.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return decision
+}
+
+// Store keeps token buckets, it must take tokens atomically
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Decision, error)
+	// DeleteIdle removes buckets that were not used for longer than idle
+	DeleteIdle(ctx context.Context, idle time.Duration) error
+}
+
+// Limiter applies named policies, buckets are kept per policy and key (e.g. user id or ip address)
+type Limiter struct {
+	store    Store
+	policies map[string]Policy
+}
+
+func NewLimiter(store Store, policies ...Policy) *Limiter {
+	limiter := &Limiter{store: store, policies: make(map[string]Policy)}
+	for _, policy := range policies {
+		limiter.policies[policy.Name] = policy
+	}
+	return limiter
+}
+
+func (limiter *Limiter) Policy(name string) (Policy, bool) {
+	policy, ok := limiter.policies[name]
+	return policy, ok
+}
+
+func (limiter *Limiter) Take(ctx context.Context, policy Policy, key string) (Decision, error) {
+	return limiter.store.Take(ctx, policy.Name+":"+key, policy)
+}
+
+// Cleanup removes buckets idle for longer than the longest period, such buckets are full anyway
+func (limiter *Limiter) Cleanup(ctx context.Context) error {
+	var longestPeriod time.Duration
+	for _, policy := range limiter.policies {
+		longestPeriod = max(longestPeriod, policy.Period)
+	}
+	return limiter.store.DeleteIdle(ctx, longestPeriod)
+}
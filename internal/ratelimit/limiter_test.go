@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected Policy
+		invalid  bool
+	}{
+		{value: "10/1m", expected: Policy{Name: "test", Limit: 10, Period: time.Minute}},
+		{value: " 5 / 1h ", expected: Policy{Name: "test", Limit: 5, Period: time.Hour}},
+		{value: "10", invalid: true},
+		{value: "0/1m", invalid: true},
+		{value: "-1/1m", invalid: true},
+		{value: "ten/1m", invalid: true},
+		{value: "10/0s", invalid: true},
+		{value: "10/minute", invalid: true},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			policy, err := ParsePolicy("test", test.value)
+			if test.invalid {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", policy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("cannot parse policy: %s", err)
+			}
+			if policy != test.expected {
+				t.Fatalf("expected %+v, got %+v", test.expected, policy)
+			}
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	policy := Policy{Name: "test", Limit: 3, Period: time.Hour}
+	limiter := NewLimiter(NewMemoryStore(), policy)
+
+	for remaining := 2; remaining >= 0; remaining-- {
+		decision, err := limiter.Take(ctx, policy, "ip:127.0.0.1")
+		if err != nil {
+			t.Fatalf("cannot take token: %s", err)
+		}
+		if !decision.Allowed || decision.Remaining != remaining || decision.Limit != 3 {
+			t.Fatalf("expected allowed request with %d remaining, got %+v", remaining, decision)
+		}
+	}
+
+	decision, err := limiter.Take(ctx, policy, "ip:127.0.0.1")
+	if err != nil {
+		t.Fatalf("cannot take token: %s", err)
+	}
+	// a token is refilled every 20 minutes
+	if decision.Allowed || decision.RetryAfter <= 0 || decision.RetryAfter > 20*time.Minute {
+		t.Fatalf("expected rejected request, got %+v", decision)
+	}
+
+	decision, err = limiter.Take(ctx, policy, "ip:127.0.0.2")
+	if err != nil || !decision.Allowed {
+		t.Fatalf("buckets of different keys must be separate, got %+v, %v", decision, err)
+	}
+}
+
+func TestMemoryStoreDeleteIdle(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	policy := Policy{Name: "test", Limit: 1, Period: time.Hour}
+	_, err := store.Take(ctx, "idle", policy)
+	if err != nil {
+		t.Fatalf("cannot take token: %s", err)
+	}
+	store.buckets["idle"].updatedAt = time.Now().Add(-2 * time.Hour)
+	_, err = store.Take(ctx, "active", policy)
+	if err != nil {
+		t.Fatalf("cannot take token: %s", err)
+	}
+
+	err = store.DeleteIdle(ctx, time.Hour)
+	if err != nil {
+		t.Fatalf("cannot delete idle buckets: %s", err)
+	}
+	if _, ok := store.buckets["idle"]; ok {
+		t.Fatal("idle bucket was kept")
+	}
+	if _, ok := store.buckets["active"]; !ok {
+		t.Fatal("active bucket was deleted")
+	}
+}
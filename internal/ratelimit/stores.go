@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/database"
+)
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// MemoryStore keeps buckets in the process memory, every instance of the server limits requests on its own
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (memoryStore *MemoryStore) Take(ctx context.Context, key string, policy Policy) (Decision, error) {
+	memoryStore.mu.Lock()
+	defer memoryStore.mu.Unlock()
+
+	now := time.Now()
+	currentBucket, ok := memoryStore.buckets[key]
+	if !ok {
+		currentBucket = &bucket{tokens: float64(policy.Limit), updatedAt: now}
+		memoryStore.buckets[key] = currentBucket
+	}
+
+	elapsed := now.Sub(currentBucket.updatedAt).Seconds()
+	currentBucket.tokens = min(float64(policy.Limit), currentBucket.tokens+elapsed*policy.refillRate())
+	currentBucket.updatedAt = now
+
+	allowed := currentBucket.tokens >= 1
+	if allowed {
+		currentBucket.tokens--
+	}
+	return newDecision(policy, currentBucket.tokens, allowed), nil
+}
+
+func (memoryStore *MemoryStore) DeleteIdle(ctx context.Context, idle time.Duration) error {
+	memoryStore.mu.Lock()
+	defer memoryStore.mu.Unlock()
+
+	idleSince := time.Now().Add(-idle)
+	for key, currentBucket := range memoryStore.buckets {
+		if currentBucket.updatedAt.Before(idleSince) {
+			delete(memoryStore.buckets, key)
+		}
+	}
+	return nil
+}
+
+// DatabaseStore keeps buckets in rate_limit_buckets table of the server's database (Postgres or SQLite),
+// so limits are shared by all instances of the server
+type DatabaseStore struct {
+	Queries database.Querier
+}
+
+func (databaseStore DatabaseStore) Take(ctx context.Context, key string, policy Policy) (Decision, error) {
+	dbBucket, err := databaseStore.Queries.TakeRateLimitToken(ctx, database.TakeRateLimitTokenParams{
+		Key:        key,
+		Capacity:   float64(policy.Limit),
+		RefillRate: policy.refillRate(),
+	})
+	if err != nil {
+		return Decision{}, err
+	}
+	return newDecision(policy, dbBucket.Tokens, dbBucket.Allowed), nil
+}
+
+func (databaseStore DatabaseStore) DeleteIdle(ctx context.Context, idle time.Duration) error {
+	return databaseStore.Queries.DeleteIdleRateLimitBuckets(ctx, idle.Seconds())
+}
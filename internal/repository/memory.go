@@ -0,0 +1,781 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/google/uuid"
+)
+
+// Memory keeps all the repositories in the process memory, data is lost when the process stops.
+// It is meant for tests and demo mode.
+type Memory struct {
+	mu             sync.Mutex
+	users          []database.User
+	loginAttempts  []database.LoginAttempt
+	messages       []database.Message
+	refreshTokens  []database.RefreshToken
+	paymentEvents  []database.PaymentEvent
+	personalTokens []database.PersonalAccessToken
+	reports        []database.Report
+	blocks         []database.Block
+	mutes          []database.Mute
+	loginThrottles map[string]database.LoginThrottle
+}
+
+func NewMemory() *Memory {
+	return &Memory{loginThrottles: map[string]database.LoginThrottle{}}
+}
+
+// Repositories returns memory as every repository
+func (memory *Memory) Repositories() Repositories {
+	return Repositories{
+		Users:                memory,
+		LoginAttempts:        memory,
+		Messages:             memory,
+		RefreshTokens:        memory,
+		Payments:             memory,
+		PersonalAccessTokens: memory,
+		Reports:              memory,
+		Blocks:               memory,
+	}
+}
+
+// now is truncated like timestamps stored in the database
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func (memory *Memory) userIndex(id uuid.UUID) int {
+	return slices.IndexFunc(memory.users, func(user database.User) bool { return user.ID == id })
+}
+
+func (memory *Memory) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	if slices.ContainsFunc(memory.users, func(user database.User) bool { return user.Email == arg.Email }) {
+		return database.User{}, fmt.Errorf("user with email %s already exists: %w", arg.Email, ErrDuplicate)
+	}
+
+	createdAt := now()
+	user := database.User{
+		ID:             uuid.New(),
+		CreatedAt:      createdAt,
+		UpdatedAt:      createdAt,
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+		Status:         "active",
+	}
+	user.IsPremium.Valid = true
+	memory.users = append(memory.users, user)
+	return user, nil
+}
+
+func (memory *Memory) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	i := memory.userIndex(id)
+	if i < 0 {
+		return database.User{}, ErrNotFound
+	}
+	return memory.users[i], nil
+}
+
+func (memory *Memory) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	i := slices.IndexFunc(memory.users, func(user database.User) bool { return user.Email == email })
+	if i < 0 {
+		return database.User{}, ErrNotFound
+	}
+	return memory.users[i], nil
+}
+
+func (memory *Memory) GetUserAccess(ctx context.Context, id uuid.UUID) (database.GetUserAccessRow, error) {
+	user, err := memory.GetUserByID(ctx, id)
+	if err != nil {
+		return database.GetUserAccessRow{}, err
+	}
+	return database.GetUserAccessRow{
+		IsAdmin:             user.IsAdmin,
+		DeletionScheduledAt: user.DeletionScheduledAt,
+		Status:              user.Status,
+		SuspendedUntil:      user.SuspendedUntil,
+	}, nil
+}
+
+func (memory *Memory) CheckUserExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	return memory.userIndex(id) >= 0, nil
+}
+
+func (memory *Memory) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	i := memory.userIndex(arg.ID)
+	if i < 0 {
+		return database.User{}, ErrNotFound
+	}
+	if slices.ContainsFunc(memory.users, func(user database.User) bool { return user.Email == arg.Email && user.ID != arg.ID }) {
+		return database.User{}, fmt.Errorf("user with email %s already exists: %w", arg.Email, ErrDuplicate)
+	}
+
+	memory.users[i].Email = arg.Email
+	memory.users[i].HashedPassword = arg.HashedPassword
+	memory.users[i].UpdatedAt = now()
+	return memory.users[i], nil
+}
+
+func (memory *Memory) UpdateUserPasswordHash(ctx context.Context, arg database.UpdateUserPasswordHashParams) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	if i := memory.userIndex(arg.ID); i >= 0 {
+		memory.users[i].HashedPassword = arg.HashedPassword
+	}
+	return nil
+}
+
+func (memory *Memory) ScheduleUserDeletion(ctx context.Context, arg database.ScheduleUserDeletionParams) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	if i := memory.userIndex(arg.ID); i >= 0 {
+		memory.users[i].DeletionScheduledAt.Time = arg.DeletionScheduledAt
+		memory.users[i].DeletionScheduledAt.Valid = true
+		memory.users[i].UpdatedAt = now()
+	}
+	return nil
+}
+
+func (memory *Memory) CancelUserDeletion(ctx context.Context, id uuid.UUID) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	if i := memory.userIndex(id); i >= 0 && memory.users[i].DeletionScheduledAt.Valid {
+		memory.users[i].DeletionScheduledAt.Valid = false
+		memory.users[i].UpdatedAt = now()
+	}
+	return nil
+}
+
+func (memory *Memory) DeleteUsersScheduledForDeletion(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	var deletedIDs []uuid.UUID
+	for _, user := range memory.users {
+		if user.DeletionScheduledAt.Valid && !user.DeletionScheduledAt.Time.After(now) {
+			deletedIDs = append(deletedIDs, user.ID)
+		}
+	}
+	memory.deleteUsers(func(id uuid.UUID) bool { return slices.Contains(deletedIDs, id) })
+	return deletedIDs, nil
+}
+
+func (memory *Memory) DeleteUsers(ctx context.Context) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	memory.deleteUsers(func(uuid.UUID) bool { return true })
+	return nil
+}
+
+// DeleteDataExportsOfScheduledUsers returns nothing, data exports are not available in memory
+func (memory *Memory) DeleteDataExportsOfScheduledUsers(ctx context.Context, now time.Time) ([]sql.NullString, error) {
+	return nil, nil
+}
+
+func (memory *Memory) DeleteAllDataExports(ctx context.Context) ([]sql.NullString, error) {
+	return nil, nil
+}
+
+// deleteUsers removes users and their data like foreign keys of the database do
+func (memory *Memory) deleteUsers(deleted func(id uuid.UUID) bool) {
+	memory.users = slices.DeleteFunc(memory.users, func(user database.User) bool { return deleted(user.ID) })
+	for i, attempt := range memory.loginAttempts {
+		if attempt.UserID.Valid && deleted(attempt.UserID.UUID) {
+			memory.loginAttempts[i].UserID = uuid.NullUUID{}
+		}
+	}
+	memory.messages = slices.DeleteFunc(memory.messages, func(message database.Message) bool { return deleted(message.UserID) })
+	memory.refreshTokens = slices.DeleteFunc(memory.refreshTokens, func(token database.RefreshToken) bool { return deleted(token.UserID) })
+	memory.paymentEvents = slices.DeleteFunc(memory.paymentEvents, func(event database.PaymentEvent) bool { return deleted(event.UserID) })
+	memory.personalTokens = slices.DeleteFunc(memory.personalTokens, func(token database.PersonalAccessToken) bool { return deleted(token.UserID) })
+	memory.reports = slices.DeleteFunc(memory.reports, func(report database.Report) bool { return deleted(report.ReportedUserID) })
+	for i, report := range memory.reports {
+		if report.ReporterID.Valid && deleted(report.ReporterID.UUID) {
+			memory.reports[i].ReporterID = uuid.NullUUID{}
+		}
+	}
+	memory.blocks = slices.DeleteFunc(memory.blocks, func(block database.Block) bool { return deleted(block.BlockerID) || deleted(block.BlockedID) })
+	memory.mutes = slices.DeleteFunc(memory.mutes, func(mute database.Mute) bool { return deleted(mute.MuterID) || deleted(mute.MutedID) })
+}
+
+func (memory *Memory) DeleteLoginAttemptsOfScheduledUsers(ctx context.Context, now time.Time) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	var scheduledUsers []database.User
+	for _, user := range memory.users {
+		if user.DeletionScheduledAt.Valid && !user.DeletionScheduledAt.Time.After(now) {
+			scheduledUsers = append(scheduledUsers, user)
+		}
+	}
+	memory.loginAttempts = slices.DeleteFunc(memory.loginAttempts, func(attempt database.LoginAttempt) bool {
+		return slices.ContainsFunc(scheduledUsers, func(user database.User) bool {
+			return attempt.Email == user.Email || (attempt.UserID.Valid && attempt.UserID.UUID == user.ID)
+		})
+	})
+	return nil
+}
+
+func (memory *Memory) DeleteAllLoginAttempts(ctx context.Context) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	memory.loginAttempts = nil
+	return nil
+}
+
+func (memory *Memory) CreateLoginAttempt(ctx context.Context, arg database.CreateLoginAttemptParams) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	memory.loginAttempts = append(memory.loginAttempts, database.LoginAttempt{
+		ID:        uuid.New(),
+		CreatedAt: now(),
+		Email:     arg.Email,
+		IpAddress: arg.IpAddress,
+		UserID:    arg.UserID,
+		Succeeded: arg.Succeeded,
+	})
+	return nil
+}
+
+func (memory *Memory) GetLoginAttempts(ctx context.Context, limit int32) ([]database.LoginAttempt, error) {
+	return memory.getLoginAttempts(func(database.LoginAttempt) bool { return true }, limit), nil
+}
+
+func (memory *Memory) GetLoginAttemptsForEmail(ctx context.Context, arg database.GetLoginAttemptsForEmailParams) ([]database.LoginAttempt, error) {
+	return memory.getLoginAttempts(func(attempt database.LoginAttempt) bool { return attempt.Email == arg.Email }, arg.Limit), nil
+}
+
+// getLoginAttempts returns the newest matching attempts first, attempts are appended in the order they are made
+func (memory *Memory) getLoginAttempts(matches func(attempt database.LoginAttempt) bool, limit int32) []database.LoginAttempt {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	var attempts []database.LoginAttempt
+	for _, attempt := range slices.Backward(memory.loginAttempts) {
+		if len(attempts) == int(limit) {
+			break
+		}
+		if matches(attempt) {
+			attempts = append(attempts, attempt)
+		}
+	}
+	return attempts
+}
+
+// TakeLoginAttempt follows the database query, see its comment for the lockout
+func (memory *Memory) TakeLoginAttempt(ctx context.Context, arg database.TakeLoginAttemptParams) (int32, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	attemptAt := now()
+	throttle, ok := memory.loginThrottles[arg.Key]
+	if !ok || throttle.LastAttemptAt.Before(attemptAt.Add(-seconds(arg.WindowSeconds))) {
+		throttle = database.LoginThrottle{Key: arg.Key}
+	} else if attemptAt.Before(throttle.LastAttemptAt.Add(loginLockout(throttle.FailedCount, arg.FreeFailures, arg.MaxLockoutSeconds))) {
+		return 0, ErrNotFound
+	}
+
+	throttle.FailedCount++
+	throttle.LastAttemptAt = attemptAt
+	memory.loginThrottles[arg.Key] = throttle
+	return throttle.FailedCount, nil
+}
+
+func (memory *Memory) GetLoginLockout(ctx context.Context, arg database.GetLoginLockoutParams) (float64, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	throttle, ok := memory.loginThrottles[arg.Key]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	lockedUntil := throttle.LastAttemptAt.Add(loginLockout(throttle.FailedCount, arg.FreeFailures, arg.MaxLockoutSeconds))
+	return time.Until(lockedUntil).Seconds(), nil
+}
+
+func (memory *Memory) ReleaseLoginAttempt(ctx context.Context, key string) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	if throttle, ok := memory.loginThrottles[key]; ok && throttle.FailedCount > 0 {
+		throttle.FailedCount--
+		memory.loginThrottles[key] = throttle
+	}
+	return nil
+}
+
+func (memory *Memory) DeleteLoginThrottle(ctx context.Context, key string) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	delete(memory.loginThrottles, key)
+	return nil
+}
+
+func (memory *Memory) DeleteIdleLoginThrottles(ctx context.Context, idleSeconds float64) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	idleSince := now().Add(-seconds(idleSeconds))
+	maps.DeleteFunc(memory.loginThrottles, func(key string, throttle database.LoginThrottle) bool {
+		return throttle.LastAttemptAt.Before(idleSince)
+	})
+	return nil
+}
+
+// loginLockout is how long the key is locked out after its last attempt, like in TakeLoginAttempt query
+func loginLockout(failedCount, freeFailures int32, maxLockoutSeconds float64) time.Duration {
+	if failedCount < freeFailures {
+		return 0
+	}
+	return min(seconds(maxLockoutSeconds), time.Second<<min(failedCount-freeFailures, 20))
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
+
+func (memory *Memory) CreateMessage(ctx context.Context, arg database.CreateMessageParams) (database.Message, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	if memory.userIndex(arg.UserID) < 0 {
+		return database.Message{}, fmt.Errorf("user %s does not exist", arg.UserID)
+	}
+
+	createdAt := now()
+	message := database.Message{
+		ID:        uuid.New(),
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+		Body:      arg.Body,
+		UserID:    arg.UserID,
+	}
+	memory.messages = append(memory.messages, message)
+	return message, nil
+}
+
+func (memory *Memory) GetMessage(ctx context.Context, id uuid.UUID) (database.Message, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	i := slices.IndexFunc(memory.messages, func(message database.Message) bool { return message.ID == id })
+	if i < 0 {
+		return database.Message{}, ErrNotFound
+	}
+	return memory.messages[i], nil
+}
+
+func (memory *Memory) GetAllMessages(ctx context.Context) ([]database.Message, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	return slices.Clone(memory.messages), nil
+}
+
+func (memory *Memory) GetAllMessagesForAuthor(ctx context.Context, userID uuid.UUID) ([]database.Message, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	var messages []database.Message
+	for _, message := range memory.messages {
+		if message.UserID == userID {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
+
+func (memory *Memory) DeleteMessage(ctx context.Context, arg database.DeleteMessageParams) (uuid.UUID, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	i := slices.IndexFunc(memory.messages, func(message database.Message) bool {
+		return message.ID == arg.ID && message.UserID == arg.UserID
+	})
+	if i < 0 {
+		return uuid.Nil, ErrNotFound
+	}
+	memory.messages = slices.Delete(memory.messages, i, i+1)
+	for i, report := range memory.reports {
+		if report.MessageID.Valid && report.MessageID.UUID == arg.ID {
+			memory.reports[i].MessageID = uuid.NullUUID{}
+		}
+	}
+	return arg.ID, nil
+}
+
+func (memory *Memory) GetHiddenAuthorsForUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	hidden := make(map[uuid.UUID]bool)
+	for _, block := range memory.blocks {
+		if block.BlockerID == userID {
+			hidden[block.BlockedID] = true
+		}
+		if block.BlockedID == userID {
+			hidden[block.BlockerID] = true
+		}
+	}
+	for _, mute := range memory.mutes {
+		if mute.MuterID == userID {
+			hidden[mute.MutedID] = true
+		}
+	}
+	for _, user := range memory.users {
+		if user.Status == "shadow_banned" && user.ID != userID {
+			hidden[user.ID] = true
+		}
+	}
+	return slices.Collect(maps.Keys(hidden)), nil
+}
+
+func (memory *Memory) CheckBlockBetween(ctx context.Context, arg database.CheckBlockBetweenParams) (bool, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	return slices.ContainsFunc(memory.blocks, func(block database.Block) bool {
+		return (block.BlockerID == arg.FirstUserID && block.BlockedID == arg.SecondUserID) ||
+			(block.BlockerID == arg.SecondUserID && block.BlockedID == arg.FirstUserID)
+	}), nil
+}
+
+// CreateBlock does nothing if the user is already blocked, like ON CONFLICT DO NOTHING
+func (memory *Memory) CreateBlock(ctx context.Context, arg database.CreateBlockParams) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	if memory.userIndex(arg.BlockerID) < 0 || memory.userIndex(arg.BlockedID) < 0 {
+		return fmt.Errorf("users %s and %s must exist", arg.BlockerID, arg.BlockedID)
+	}
+	if !slices.ContainsFunc(memory.blocks, func(block database.Block) bool {
+		return block.BlockerID == arg.BlockerID && block.BlockedID == arg.BlockedID
+	}) {
+		memory.blocks = append(memory.blocks, database.Block{BlockerID: arg.BlockerID, BlockedID: arg.BlockedID, CreatedAt: now()})
+	}
+	return nil
+}
+
+func (memory *Memory) DeleteBlock(ctx context.Context, arg database.DeleteBlockParams) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	memory.blocks = slices.DeleteFunc(memory.blocks, func(block database.Block) bool {
+		return block.BlockerID == arg.BlockerID && block.BlockedID == arg.BlockedID
+	})
+	return nil
+}
+
+// GetBlocksForUser returns the newest blocks first
+func (memory *Memory) GetBlocksForUser(ctx context.Context, blockerID uuid.UUID) ([]database.Block, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	var blocks []database.Block
+	for _, block := range slices.Backward(memory.blocks) {
+		if block.BlockerID == blockerID {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks, nil
+}
+
+// CreateMute does nothing if the user is already muted, like ON CONFLICT DO NOTHING
+func (memory *Memory) CreateMute(ctx context.Context, arg database.CreateMuteParams) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	if memory.userIndex(arg.MuterID) < 0 || memory.userIndex(arg.MutedID) < 0 {
+		return fmt.Errorf("users %s and %s must exist", arg.MuterID, arg.MutedID)
+	}
+	if !slices.ContainsFunc(memory.mutes, func(mute database.Mute) bool {
+		return mute.MuterID == arg.MuterID && mute.MutedID == arg.MutedID
+	}) {
+		memory.mutes = append(memory.mutes, database.Mute{MuterID: arg.MuterID, MutedID: arg.MutedID, CreatedAt: now()})
+	}
+	return nil
+}
+
+func (memory *Memory) DeleteMute(ctx context.Context, arg database.DeleteMuteParams) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	memory.mutes = slices.DeleteFunc(memory.mutes, func(mute database.Mute) bool {
+		return mute.MuterID == arg.MuterID && mute.MutedID == arg.MutedID
+	})
+	return nil
+}
+
+// GetMutesForUser returns the newest mutes first
+func (memory *Memory) GetMutesForUser(ctx context.Context, muterID uuid.UUID) ([]database.Mute, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	var mutes []database.Mute
+	for _, mute := range slices.Backward(memory.mutes) {
+		if mute.MuterID == muterID {
+			mutes = append(mutes, mute)
+		}
+	}
+	return mutes, nil
+}
+
+func (memory *Memory) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	if memory.userIndex(arg.UserID) < 0 {
+		return fmt.Errorf("user %s does not exist", arg.UserID)
+	}
+
+	createdAt := now()
+	memory.refreshTokens = append(memory.refreshTokens, database.RefreshToken{
+		Token:     arg.Token,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+		UserID:    arg.UserID,
+		ExpiresAt: arg.ExpiresAt,
+	})
+	return nil
+}
+
+func (memory *Memory) GetUserFromRefreshToken(ctx context.Context, token string) (uuid.UUID, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	for _, refreshToken := range memory.refreshTokens {
+		if refreshToken.Token == token && !refreshToken.RevokedAt.Valid && refreshToken.ExpiresAt.After(time.Now()) {
+			return refreshToken.UserID, nil
+		}
+	}
+	return uuid.Nil, ErrNotFound
+}
+
+func (memory *Memory) GetRefreshTokensForUser(ctx context.Context, userID uuid.UUID) ([]database.GetRefreshTokensForUserRow, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	var tokens []database.GetRefreshTokensForUserRow
+	for _, refreshToken := range memory.refreshTokens {
+		if refreshToken.UserID == userID {
+			tokens = append(tokens, database.GetRefreshTokensForUserRow{
+				CreatedAt: refreshToken.CreatedAt,
+				ExpiresAt: refreshToken.ExpiresAt,
+				RevokedAt: refreshToken.RevokedAt,
+			})
+		}
+	}
+	return tokens, nil
+}
+
+func (memory *Memory) RevokeRefreshToken(ctx context.Context, token string) error {
+	memory.revokeRefreshTokens(func(refreshToken database.RefreshToken) bool { return refreshToken.Token == token })
+	return nil
+}
+
+func (memory *Memory) RevokeRefreshTokensForUser(ctx context.Context, userID uuid.UUID) error {
+	memory.revokeRefreshTokens(func(refreshToken database.RefreshToken) bool {
+		return refreshToken.UserID == userID && !refreshToken.RevokedAt.Valid
+	})
+	return nil
+}
+
+func (memory *Memory) revokeRefreshTokens(revoked func(refreshToken database.RefreshToken) bool) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	revokedAt := now()
+	for i, refreshToken := range memory.refreshTokens {
+		if revoked(refreshToken) {
+			memory.refreshTokens[i].RevokedAt.Time = revokedAt
+			memory.refreshTokens[i].RevokedAt.Valid = true
+			memory.refreshTokens[i].UpdatedAt = revokedAt
+		}
+	}
+}
+
+func (memory *Memory) UpgradeToPremium(ctx context.Context, id uuid.UUID) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	if i := memory.userIndex(id); i >= 0 {
+		memory.users[i].IsPremium.Bool = true
+		memory.users[i].IsPremium.Valid = true
+	}
+	return nil
+}
+
+func (memory *Memory) CreatePaymentEvent(ctx context.Context, arg database.CreatePaymentEventParams) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	if memory.userIndex(arg.UserID) < 0 {
+		return fmt.Errorf("user %s does not exist", arg.UserID)
+	}
+
+	memory.paymentEvents = append(memory.paymentEvents, database.PaymentEvent{
+		ID:        uuid.New(),
+		CreatedAt: now(),
+		UserID:    arg.UserID,
+		Event:     arg.Event,
+	})
+	return nil
+}
+
+func (memory *Memory) GetPaymentEventsForUser(ctx context.Context, userID uuid.UUID) ([]database.PaymentEvent, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	var events []database.PaymentEvent
+	for _, event := range memory.paymentEvents {
+		if event.UserID == userID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (memory *Memory) CreatePersonalAccessToken(ctx context.Context, arg database.CreatePersonalAccessTokenParams) (database.PersonalAccessToken, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	if memory.userIndex(arg.UserID) < 0 {
+		return database.PersonalAccessToken{}, fmt.Errorf("user %s does not exist", arg.UserID)
+	}
+	if slices.ContainsFunc(memory.personalTokens, func(token database.PersonalAccessToken) bool { return token.TokenHash == arg.TokenHash }) {
+		return database.PersonalAccessToken{}, fmt.Errorf("token with the same hash already exists: %w", ErrDuplicate)
+	}
+
+	createdAt := now()
+	token := database.PersonalAccessToken{
+		ID:        uuid.New(),
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+		UserID:    arg.UserID,
+		Name:      arg.Name,
+		TokenHash: arg.TokenHash,
+		Scopes:    slices.Clone(arg.Scopes),
+		ExpiresAt: arg.ExpiresAt,
+	}
+	memory.personalTokens = append(memory.personalTokens, token)
+	return token, nil
+}
+
+func (memory *Memory) GetPersonalAccessTokensForUser(ctx context.Context, userID uuid.UUID) ([]database.PersonalAccessToken, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	var tokens []database.PersonalAccessToken
+	for _, token := range memory.personalTokens {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (memory *Memory) GetActivePersonalAccessToken(ctx context.Context, tokenHash string) (database.PersonalAccessToken, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	for _, token := range memory.personalTokens {
+		if token.TokenHash == tokenHash && (!token.ExpiresAt.Valid || token.ExpiresAt.Time.After(time.Now())) {
+			return token, nil
+		}
+	}
+	return database.PersonalAccessToken{}, ErrNotFound
+}
+
+func (memory *Memory) TouchPersonalAccessToken(ctx context.Context, id uuid.UUID) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	for i, token := range memory.personalTokens {
+		if token.ID == id {
+			memory.personalTokens[i].LastUsedAt.Time = now()
+			memory.personalTokens[i].LastUsedAt.Valid = true
+		}
+	}
+	return nil
+}
+
+func (memory *Memory) DeletePersonalAccessToken(ctx context.Context, arg database.DeletePersonalAccessTokenParams) (uuid.UUID, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	i := slices.IndexFunc(memory.personalTokens, func(token database.PersonalAccessToken) bool {
+		return token.ID == arg.ID && token.UserID == arg.UserID
+	})
+	if i < 0 {
+		return uuid.Nil, ErrNotFound
+	}
+	memory.personalTokens = slices.Delete(memory.personalTokens, i, i+1)
+	return arg.ID, nil
+}
+
+func (memory *Memory) CreateReport(ctx context.Context, arg database.CreateReportParams) (database.Report, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	if memory.userIndex(arg.ReportedUserID) < 0 {
+		return database.Report{}, fmt.Errorf("user %s does not exist", arg.ReportedUserID)
+	}
+
+	createdAt := now()
+	report := database.Report{
+		ID:             uuid.New(),
+		CreatedAt:      createdAt,
+		UpdatedAt:      createdAt,
+		ReporterID:     arg.ReporterID,
+		TargetType:     arg.TargetType,
+		ReportedUserID: arg.ReportedUserID,
+		MessageID:      arg.MessageID,
+		MessageBody:    arg.MessageBody,
+		Reason:         arg.Reason,
+		Details:        arg.Details,
+		Status:         "open",
+	}
+	memory.reports = append(memory.reports, report)
+	return report, nil
+}
+
+func (memory *Memory) CheckOpenReportExists(ctx context.Context, arg database.CheckOpenReportExistsParams) (bool, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	return slices.ContainsFunc(memory.reports, func(report database.Report) bool {
+		return report.ReporterID.Valid && report.ReporterID.UUID == arg.ReporterID &&
+			report.ReportedUserID == arg.ReportedUserID &&
+			report.MessageID == arg.MessageID &&
+			report.Status != "resolved"
+	}), nil
+}
@@ -0,0 +1,342 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/google/uuid"
+)
+
+func createUser(t *testing.T, memory *Memory, email string) database.User {
+	t.Helper()
+	user, err := memory.CreateUser(context.Background(), database.CreateUserParams{Email: email, HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+	return user
+}
+
+func TestMemoryDuplicates(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemory()
+	user := createUser(t, memory, "user@example.com")
+	other := createUser(t, memory, "other@example.com")
+	_, err := memory.CreatePersonalAccessToken(ctx, database.CreatePersonalAccessTokenParams{UserID: user.ID, Name: "bot", TokenHash: "hash"})
+	if err != nil {
+		t.Fatalf("cannot create token: %s", err)
+	}
+
+	tests := []struct {
+		name   string
+		create func() error
+	}{
+		{"user with taken email", func() error {
+			_, err := memory.CreateUser(ctx, database.CreateUserParams{Email: user.Email})
+			return err
+		}},
+		{"update to taken email", func() error {
+			_, err := memory.UpdateUser(ctx, database.UpdateUserParams{ID: other.ID, Email: user.Email})
+			return err
+		}},
+		{"token with taken hash", func() error {
+			_, err := memory.CreatePersonalAccessToken(ctx, database.CreatePersonalAccessTokenParams{UserID: other.ID, Name: "bot", TokenHash: "hash"})
+			return err
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.create()
+			if !errors.Is(err, ErrDuplicate) {
+				t.Fatalf("expected duplicate error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMemoryNotFound(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemory()
+	user := createUser(t, memory, "user@example.com")
+	other := createUser(t, memory, "other@example.com")
+	message, err := memory.CreateMessage(ctx, database.CreateMessageParams{Body: "hello", UserID: user.ID})
+	if err != nil {
+		t.Fatalf("cannot create message: %s", err)
+	}
+	expired := sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+	token, err := memory.CreatePersonalAccessToken(ctx, database.CreatePersonalAccessTokenParams{UserID: user.ID, Name: "bot", TokenHash: "expired", ExpiresAt: expired})
+	if err != nil {
+		t.Fatalf("cannot create token: %s", err)
+	}
+	err = memory.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{Token: "expired", UserID: user.ID, ExpiresAt: expired.Time})
+	if err != nil {
+		t.Fatalf("cannot create refresh token: %s", err)
+	}
+	err = memory.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{Token: "revoked", UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("cannot create refresh token: %s", err)
+	}
+	err = memory.RevokeRefreshToken(ctx, "revoked")
+	if err != nil {
+		t.Fatalf("cannot revoke refresh token: %s", err)
+	}
+
+	tests := []struct {
+		name   string
+		lookup func() error
+	}{
+		{"unknown user", func() error {
+			_, err := memory.GetUserByID(ctx, uuid.New())
+			return err
+		}},
+		{"unknown email", func() error {
+			_, err := memory.GetUserByEmail(ctx, "nobody@example.com")
+			return err
+		}},
+		{"unknown message", func() error {
+			_, err := memory.GetMessage(ctx, uuid.New())
+			return err
+		}},
+		{"message of another user", func() error {
+			_, err := memory.DeleteMessage(ctx, database.DeleteMessageParams{ID: message.ID, UserID: other.ID})
+			return err
+		}},
+		{"expired refresh token", func() error {
+			_, err := memory.GetUserFromRefreshToken(ctx, "expired")
+			return err
+		}},
+		{"revoked refresh token", func() error {
+			_, err := memory.GetUserFromRefreshToken(ctx, "revoked")
+			return err
+		}},
+		{"expired personal token", func() error {
+			_, err := memory.GetActivePersonalAccessToken(ctx, "expired")
+			return err
+		}},
+		{"personal token of another user", func() error {
+			_, err := memory.DeletePersonalAccessToken(ctx, database.DeletePersonalAccessTokenParams{ID: token.ID, UserID: other.ID})
+			return err
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.lookup()
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("expected ErrNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMemoryLoginThrottle(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemory()
+
+	tests := []struct {
+		name string
+		// steps before the checked attempt: "take", "release" or "reset"
+		steps  []string
+		locked bool
+	}{
+		{"first attempt", nil, false},
+		{"free attempts", []string{"take", "take"}, false},
+		{"attempt after free ones", []string{"take", "take", "take"}, true},
+		{"released attempt", []string{"take", "take", "take", "release"}, false},
+		{"reset counter", []string{"take", "take", "take", "reset"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			takeParams := database.TakeLoginAttemptParams{Key: test.name, WindowSeconds: 60, FreeFailures: 3, MaxLockoutSeconds: 60}
+			for _, step := range test.steps {
+				var err error
+				switch step {
+				case "take":
+					_, err = memory.TakeLoginAttempt(ctx, takeParams)
+				case "release":
+					err = memory.ReleaseLoginAttempt(ctx, test.name)
+				case "reset":
+					err = memory.DeleteLoginThrottle(ctx, test.name)
+				}
+				if err != nil {
+					t.Fatalf("%s failed: %s", step, err)
+				}
+			}
+
+			_, err := memory.TakeLoginAttempt(ctx, takeParams)
+			if !test.locked {
+				if err != nil {
+					t.Fatalf("attempt was rejected: %s", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("expected lockout, got %v", err)
+			}
+			wait, err := memory.GetLoginLockout(ctx, database.GetLoginLockoutParams{Key: test.name, FreeFailures: 3, MaxLockoutSeconds: 60})
+			if err != nil || wait <= 0 || wait > 1 {
+				t.Fatalf("expected lockout of up to a second, got %f, %v", wait, err)
+			}
+		})
+	}
+}
+
+func TestMemoryHiddenAuthors(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemory()
+	viewer := createUser(t, memory, "viewer@example.com")
+	blocked := createUser(t, memory, "blocked@example.com")
+	blocker := createUser(t, memory, "blocker@example.com")
+	muted := createUser(t, memory, "muted@example.com")
+	stranger := createUser(t, memory, "stranger@example.com")
+	for _, arg := range []database.CreateBlockParams{{BlockerID: viewer.ID, BlockedID: blocked.ID}, {BlockerID: blocker.ID, BlockedID: viewer.ID}} {
+		if err := memory.CreateBlock(ctx, arg); err != nil {
+			t.Fatalf("cannot create block: %s", err)
+		}
+	}
+	if err := memory.CreateMute(ctx, database.CreateMuteParams{MuterID: viewer.ID, MutedID: muted.ID}); err != nil {
+		t.Fatalf("cannot create mute: %s", err)
+	}
+
+	hidden, err := memory.GetHiddenAuthorsForUser(ctx, viewer.ID)
+	if err != nil {
+		t.Fatalf("cannot get hidden authors: %s", err)
+	}
+	slices.SortFunc(hidden, func(a, b uuid.UUID) int { return strings.Compare(a.String(), b.String()) })
+	expected := []uuid.UUID{blocked.ID, blocker.ID, muted.ID}
+	slices.SortFunc(expected, func(a, b uuid.UUID) int { return strings.Compare(a.String(), b.String()) })
+	if !slices.Equal(hidden, expected) {
+		t.Fatalf("expected %v, got %v", expected, hidden)
+	}
+
+	tests := []struct {
+		name     string
+		other    uuid.UUID
+		expected bool
+	}{
+		{"blocked by the viewer", blocked.ID, true},
+		{"blocked the viewer", blocker.ID, true},
+		{"muted by the viewer", muted.ID, false},
+		{"stranger", stranger.ID, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			isBlocked, err := memory.CheckBlockBetween(ctx, database.CheckBlockBetweenParams{FirstUserID: viewer.ID, SecondUserID: test.other})
+			if err != nil {
+				t.Fatalf("cannot check block: %s", err)
+			}
+			if isBlocked != test.expected {
+				t.Fatalf("expected %t, got %t", test.expected, isBlocked)
+			}
+		})
+	}
+}
+
+func TestMemoryCheckOpenReportExists(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemory()
+	reporter := createUser(t, memory, "reporter@example.com")
+	author := createUser(t, memory, "author@example.com")
+	message, err := memory.CreateMessage(ctx, database.CreateMessageParams{Body: "hello", UserID: author.ID})
+	if err != nil {
+		t.Fatalf("cannot create message: %s", err)
+	}
+	messageID := uuid.NullUUID{UUID: message.ID, Valid: true}
+	_, err = memory.CreateReport(ctx, database.CreateReportParams{
+		ReporterID:     uuid.NullUUID{UUID: reporter.ID, Valid: true},
+		TargetType:     "message",
+		ReportedUserID: author.ID,
+		MessageID:      messageID,
+		Reason:         "spam",
+	})
+	if err != nil {
+		t.Fatalf("cannot create report: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		arg      database.CheckOpenReportExistsParams
+		expected bool
+	}{
+		{"same message", database.CheckOpenReportExistsParams{ReporterID: reporter.ID, ReportedUserID: author.ID, MessageID: messageID}, true},
+		{"the author", database.CheckOpenReportExistsParams{ReporterID: reporter.ID, ReportedUserID: author.ID}, false},
+		{"another reporter", database.CheckOpenReportExistsParams{ReporterID: author.ID, ReportedUserID: author.ID, MessageID: messageID}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exists, err := memory.CheckOpenReportExists(ctx, test.arg)
+			if err != nil {
+				t.Fatalf("cannot check reports: %s", err)
+			}
+			if exists != test.expected {
+				t.Fatalf("expected %t, got %t", test.expected, exists)
+			}
+		})
+	}
+}
+
+func TestMemoryDeleteUsersKeepsForeignKeys(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemory()
+	deleted := createUser(t, memory, "deleted@example.com")
+	kept := createUser(t, memory, "kept@example.com")
+	err := memory.ScheduleUserDeletion(ctx, database.ScheduleUserDeletionParams{ID: deleted.ID, DeletionScheduledAt: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("cannot schedule deletion: %s", err)
+	}
+
+	message, err := memory.CreateMessage(ctx, database.CreateMessageParams{Body: "hello", UserID: deleted.ID})
+	if err != nil {
+		t.Fatalf("cannot create message: %s", err)
+	}
+	_, err = memory.CreatePersonalAccessToken(ctx, database.CreatePersonalAccessTokenParams{UserID: deleted.ID, Name: "bot", TokenHash: "hash"})
+	if err != nil {
+		t.Fatalf("cannot create token: %s", err)
+	}
+	err = memory.CreateLoginAttempt(ctx, database.CreateLoginAttemptParams{Email: "attacker@example.com", UserID: uuid.NullUUID{UUID: deleted.ID, Valid: true}})
+	if err != nil {
+		t.Fatalf("cannot create attempt: %s", err)
+	}
+	// report sent by the deleted user stays for moderators, report about the deleted user is gone
+	sentReport, err := memory.CreateReport(ctx, database.CreateReportParams{ReporterID: uuid.NullUUID{UUID: deleted.ID, Valid: true}, TargetType: "user", ReportedUserID: kept.ID})
+	if err != nil {
+		t.Fatalf("cannot create report: %s", err)
+	}
+	_, err = memory.CreateReport(ctx, database.CreateReportParams{ReporterID: uuid.NullUUID{UUID: kept.ID, Valid: true}, TargetType: "message", ReportedUserID: deleted.ID, MessageID: uuid.NullUUID{UUID: message.ID, Valid: true}})
+	if err != nil {
+		t.Fatalf("cannot create report: %s", err)
+	}
+
+	err = memory.CreateBlock(ctx, database.CreateBlockParams{BlockerID: kept.ID, BlockedID: deleted.ID})
+	if err != nil {
+		t.Fatalf("cannot create block: %s", err)
+	}
+
+	deletedIDs, err := memory.DeleteUsersScheduledForDeletion(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("cannot delete users: %s", err)
+	}
+	if len(deletedIDs) != 1 || deletedIDs[0] != deleted.ID {
+		t.Fatalf("expected only %s to be deleted, got %v", deleted.ID, deletedIDs)
+	}
+
+	if _, err := memory.GetMessage(ctx, message.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("message of deleted user was kept: %v", err)
+	}
+	if _, err := memory.GetActivePersonalAccessToken(ctx, "hash"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("token of deleted user was kept: %v", err)
+	}
+	if len(memory.loginAttempts) != 1 || memory.loginAttempts[0].UserID.Valid {
+		t.Fatalf("login attempt should be kept without the user: %+v", memory.loginAttempts)
+	}
+	if len(memory.blocks) != 0 {
+		t.Fatalf("block of deleted user was kept: %+v", memory.blocks)
+	}
+	if len(memory.reports) != 1 || memory.reports[0].ID != sentReport.ID || memory.reports[0].ReporterID.Valid {
+		t.Fatalf("only sent report should be kept without the reporter: %+v", memory.reports)
+	}
+}
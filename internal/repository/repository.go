@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned by single row lookups when there is no such row. It is not a separate sentinel:
+// it is sql.ErrNoRows of the sqlc queries, which the memory implementation returns too, so errors of the database
+// implementation need no translation and services that query the database directly check the same error.
+var ErrNotFound = sql.ErrNoRows
+
+// ErrDuplicate is returned when a unique value (e.g. user's email) is already taken. Like ErrNotFound it is
+// the error of the database package, which database backends translate their driver errors into.
+var ErrDuplicate = database.ErrDuplicate
+
+// Users keeps accounts. Deleting users deletes their messages, refresh tokens and payment events too.
+// Export archives are kept in storage, so exports are deleted separately and their storage keys are returned.
+type Users interface {
+	CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error)
+	GetUserByEmail(ctx context.Context, email string) (database.User, error)
+	GetUserAccess(ctx context.Context, id uuid.UUID) (database.GetUserAccessRow, error)
+	CheckUserExists(ctx context.Context, id uuid.UUID) (bool, error)
+	UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error)
+	UpdateUserPasswordHash(ctx context.Context, arg database.UpdateUserPasswordHashParams) error
+	ScheduleUserDeletion(ctx context.Context, arg database.ScheduleUserDeletionParams) error
+	CancelUserDeletion(ctx context.Context, id uuid.UUID) error
+	DeleteUsersScheduledForDeletion(ctx context.Context, now time.Time) ([]uuid.UUID, error)
+	DeleteUsers(ctx context.Context) error
+	DeleteDataExportsOfScheduledUsers(ctx context.Context, now time.Time) ([]sql.NullString, error)
+	DeleteAllDataExports(ctx context.Context) ([]sql.NullString, error)
+}
+
+// LoginAttempts keeps results of password checks and counters of failed attempts (login throttles) for lockouts.
+// Deleting users keeps their attempts, so attempts are deleted separately, they contain emails and IP addresses of the users.
+type LoginAttempts interface {
+	CreateLoginAttempt(ctx context.Context, arg database.CreateLoginAttemptParams) error
+	// GetLoginAttempts and GetLoginAttemptsForEmail return the newest attempts first
+	GetLoginAttempts(ctx context.Context, limit int32) ([]database.LoginAttempt, error)
+	GetLoginAttemptsForEmail(ctx context.Context, arg database.GetLoginAttemptsForEmailParams) ([]database.LoginAttempt, error)
+	// TakeLoginAttempt atomically counts the attempt of the key as failed and returns the number of failed attempts,
+	// ErrNotFound is returned while the key is locked out
+	TakeLoginAttempt(ctx context.Context, arg database.TakeLoginAttemptParams) (int32, error)
+	GetLoginLockout(ctx context.Context, arg database.GetLoginLockoutParams) (float64, error)
+	ReleaseLoginAttempt(ctx context.Context, key string) error
+	DeleteLoginThrottle(ctx context.Context, key string) error
+	DeleteIdleLoginThrottles(ctx context.Context, idleSeconds float64) error
+	// DeleteLoginAttemptsOfScheduledUsers deletes attempts made by users whose deletion is due or made with their emails
+	DeleteLoginAttemptsOfScheduledUsers(ctx context.Context, now time.Time) error
+	DeleteAllLoginAttempts(ctx context.Context) error
+}
+
+// Messages keeps messages. It also tells which authors are hidden from a viewer (blocked, muted or shadow-banned),
+// so messages can be listed without going through other repositories.
+type Messages interface {
+	CreateMessage(ctx context.Context, arg database.CreateMessageParams) (database.Message, error)
+	GetMessage(ctx context.Context, id uuid.UUID) (database.Message, error)
+	GetAllMessages(ctx context.Context) ([]database.Message, error)
+	GetAllMessagesForAuthor(ctx context.Context, userID uuid.UUID) ([]database.Message, error)
+	// DeleteMessage deletes message only if it belongs to the user, ErrNotFound is returned otherwise
+	DeleteMessage(ctx context.Context, arg database.DeleteMessageParams) (uuid.UUID, error)
+	GetHiddenAuthorsForUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+	CheckBlockBetween(ctx context.Context, arg database.CheckBlockBetweenParams) (bool, error)
+}
+
+// RefreshTokens keeps refresh tokens issued on login
+type RefreshTokens interface {
+	CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) error
+	// GetUserFromRefreshToken returns owner of the token, ErrNotFound is returned for unknown, expired and revoked tokens
+	GetUserFromRefreshToken(ctx context.Context, token string) (uuid.UUID, error)
+	GetRefreshTokensForUser(ctx context.Context, userID uuid.UUID) ([]database.GetRefreshTokensForUserRow, error)
+	RevokeRefreshToken(ctx context.Context, token string) error
+	RevokeRefreshTokensForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// Payments keeps premium status of users and history of payment provider's events
+type Payments interface {
+	UpgradeToPremium(ctx context.Context, id uuid.UUID) error
+	CreatePaymentEvent(ctx context.Context, arg database.CreatePaymentEventParams) error
+	GetPaymentEventsForUser(ctx context.Context, userID uuid.UUID) ([]database.PaymentEvent, error)
+}
+
+// PersonalAccessTokens keeps hashes of long-lived scoped tokens created by users
+type PersonalAccessTokens interface {
+	CreatePersonalAccessToken(ctx context.Context, arg database.CreatePersonalAccessTokenParams) (database.PersonalAccessToken, error)
+	GetPersonalAccessTokensForUser(ctx context.Context, userID uuid.UUID) ([]database.PersonalAccessToken, error)
+	// GetActivePersonalAccessToken returns token by its hash, ErrNotFound is returned for unknown and expired tokens
+	GetActivePersonalAccessToken(ctx context.Context, tokenHash string) (database.PersonalAccessToken, error)
+	TouchPersonalAccessToken(ctx context.Context, id uuid.UUID) error
+	// DeletePersonalAccessToken deletes token only if it belongs to the user, ErrNotFound is returned otherwise
+	DeletePersonalAccessToken(ctx context.Context, arg database.DeletePersonalAccessTokenParams) (uuid.UUID, error)
+}
+
+// Reports keeps reports sent by users and content filter. Only creating reports is covered,
+// the moderation queue is reviewed through the database.
+type Reports interface {
+	CreateReport(ctx context.Context, arg database.CreateReportParams) (database.Report, error)
+	CheckOpenReportExists(ctx context.Context, arg database.CheckOpenReportExistsParams) (bool, error)
+}
+
+// Blocks keeps blocks and mutes between users. Messages.GetHiddenAuthorsForUser and Messages.CheckBlockBetween
+// read them, so a memory implementation of Messages must share its data with Blocks.
+type Blocks interface {
+	CreateBlock(ctx context.Context, arg database.CreateBlockParams) error
+	DeleteBlock(ctx context.Context, arg database.DeleteBlockParams) error
+	GetBlocksForUser(ctx context.Context, blockerID uuid.UUID) ([]database.Block, error)
+	CreateMute(ctx context.Context, arg database.CreateMuteParams) error
+	DeleteMute(ctx context.Context, arg database.DeleteMuteParams) error
+	GetMutesForUser(ctx context.Context, muterID uuid.UUID) ([]database.Mute, error)
+}
+
+// Repositories are storages of services that do not depend on a particular database driver or query code.
+// They are not a domain layer: parameters and results are the structs sqlc generates in package database,
+// shared by the Postgres queries and the SQLite backend, which converts its rows into them. Services are
+// decoupled from the driver and can run on Memory, but changing a query's columns changes these interfaces.
+//
+// Only users, login attempts, messages, refresh tokens, payments, personal access tokens, reports (creation only)
+// and blocks are covered. ModerationService, AccountStatusService (both through ApiConfig.InTx),
+// ContentFilterService, OAuthService, OIDCService and DataExportService take database.Querier,
+// so they need the database and answer 501 in demo mode.
+type Repositories struct {
+	Users                Users
+	LoginAttempts        LoginAttempts
+	Messages             Messages
+	RefreshTokens        RefreshTokens
+	Payments             Payments
+	PersonalAccessTokens PersonalAccessTokens
+	Reports              Reports
+	Blocks               Blocks
+}
+
+// NewDatabase keeps everything in the database, sqlc queries implement all the repositories as they are
+func NewDatabase(queries database.Querier) Repositories {
+	return Repositories{
+		Users:                queries,
+		LoginAttempts:        queries,
+		Messages:             queries,
+		RefreshTokens:        queries,
+		Payments:             queries,
+		PersonalAccessTokens: queries,
+		Reports:              queries,
+		Blocks:               queries,
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/models"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/tracing"
+	"github.com/google/uuid"
+)
+
+const (
+	AccountStatusActive = "active"
+	// suspended account cannot log in or use its tokens until suspended_until
+	AccountStatusSuspended = "suspended"
+	// shadow-banned account works as usual, but its messages are visible only to the account itself
+	AccountStatusShadowBanned = "shadow_banned"
+	// banned account cannot log in or use its tokens until an admin makes it active again
+	AccountStatusBanned = "banned"
+)
+
+var AccountStatuses = []string{AccountStatusActive, AccountStatusSuspended, AccountStatusShadowBanned, AccountStatusBanned}
+
+// AccountStatusService lets admins restrict accounts, every change is written to moderation_actions
+// in the same transaction (ApiConfig.InTx), so it needs the database and is unavailable in demo mode
+type AccountStatusService struct {
+	ApiConfig *config.ApiConfig
+	users     repository.Users
+}
+
+func NewAccountStatusService(apiConfig *config.ApiConfig, users repository.Users) *AccountStatusService {
+	return &AccountStatusService{ApiConfig: apiConfig, users: users}
+}
+
+func (statusServ *AccountStatusService) SetUserStatus(ctx context.Context, principal auth.Principal, userID string, statusRequest models.UserStatusRequest) (models.UserStatusResponse, error) {
+	ctx, span := tracing.Start(ctx, "AccountStatusService.SetUserStatus")
+	defer span.End()
+
+	err := requireAdmin(principal)
+	if err != nil {
+		return models.UserStatusResponse{}, err
+	}
+
+	if !slices.Contains(AccountStatuses, statusRequest.Status) {
+		return models.UserStatusResponse{}, validationError("status must be one of %v", AccountStatuses)
+	}
+	reason := strings.TrimSpace(statusRequest.Reason)
+	if reason == "" {
+		return models.UserStatusResponse{}, validationError("reason is required")
+	}
+	if len(reason) > maxModerationNoteLen {
+		return models.UserStatusResponse{}, validationError("reason must be at most %d characters", maxModerationNoteLen)
+	}
+
+	var suspendedUntil sql.NullTime
+	if statusRequest.Status == AccountStatusSuspended {
+		suspendedUntil, err = suspensionEnd(statusRequest.SuspendDays)
+		if err != nil {
+			return models.UserStatusResponse{}, wrapError(ErrorValidation, err)
+		}
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return models.UserStatusResponse{}, validationError("cannot convert user id to uuid: %s", err)
+	}
+
+	userAccess, err := statusServ.users.GetUserAccess(ctx, userUUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.UserStatusResponse{}, notFoundError("user not found")
+	}
+	if err != nil {
+		return models.UserStatusResponse{}, fmt.Errorf("cannot get user: %w", err)
+	}
+	if userAccess.IsAdmin && statusRequest.Status != AccountStatusActive {
+		return models.UserStatusResponse{}, conflictError("admins cannot be restricted")
+	}
+
+	// the change and its audit record are written together, so no change goes unrecorded
+	var dbStatus database.SetUserStatusRow
+	err = statusServ.ApiConfig.InTx(ctx, func(queries database.Querier) error {
+		dbStatus, err = setUserStatus(ctx, queries, userUUID, statusRequest.Status, reason, suspendedUntil)
+		if err != nil {
+			return err
+		}
+
+		err = queries.CreateModerationAction(ctx, database.CreateModerationActionParams{
+			ModeratorID:  uuid.NullUUID{UUID: principal.UserID, Valid: true},
+			Action:       ModerationActionChangeStatus,
+			TargetUserID: uuid.NullUUID{UUID: userUUID, Valid: true},
+			Note:         fmt.Sprintf("%s: %s", statusRequest.Status, reason),
+		})
+		if err != nil {
+			return fmt.Errorf("cannot record moderation action: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.UserStatusResponse{}, err
+	}
+
+	return convertDBToUserStatus(dbStatus), nil
+}
+
+// setUserStatus changes account status, restricted accounts lose their refresh tokens
+func setUserStatus(ctx context.Context, queries database.Querier, userID uuid.UUID, status, reason string, suspendedUntil sql.NullTime) (database.SetUserStatusRow, error) {
+	dbStatus, err := queries.SetUserStatus(ctx, database.SetUserStatusParams{
+		ID:             userID,
+		Status:         status,
+		StatusReason:   reason,
+		SuspendedUntil: suspendedUntil,
+	})
+	if err != nil {
+		return database.SetUserStatusRow{}, fmt.Errorf("cannot change user status: %w", err)
+	}
+
+	if status == AccountStatusSuspended || status == AccountStatusBanned {
+		err = queries.RevokeRefreshTokensForUser(ctx, userID)
+		if err != nil {
+			return database.SetUserStatusRow{}, fmt.Errorf("cannot revoke refresh tokens: %w", err)
+		}
+	}
+	return dbStatus, nil
+}
+
+// checkAccountStatus rejects suspended and banned accounts, shadow-banned accounts are let in
+// so that their owners do not notice the restriction
+func checkAccountStatus(status string, suspendedUntil sql.NullTime) error {
+	switch status {
+	case AccountStatusBanned:
+		return &Error{Kind: ErrorForbidden, Code: ErrorCodeAccountBanned, Message: "account is banned"}
+	case AccountStatusSuspended:
+		if suspendedUntil.Valid && suspendedUntil.Time.After(time.Now()) {
+			serviceErr := forbiddenError("account is suspended until %s", suspendedUntil.Time.Format(time.RFC3339))
+			serviceErr.Code = ErrorCodeAccountSuspended
+			return serviceErr
+		}
+	}
+	return nil
+}
+
+func suspensionEnd(suspendDays int) (sql.NullTime, error) {
+	if suspendDays == 0 {
+		suspendDays = defaultSuspendDays
+	}
+	if suspendDays < 0 || suspendDays > maxSuspendDays {
+		return sql.NullTime{}, fmt.Errorf("suspend_days must be between 1 and %d", maxSuspendDays)
+	}
+	return sql.NullTime{Time: time.Now().AddDate(0, 0, suspendDays), Valid: true}, nil
+}
+
+func convertDBToUserStatus(dbStatus database.SetUserStatusRow) models.UserStatusResponse {
+	responseStatus := models.UserStatusResponse{
+		UserID:    dbStatus.ID,
+		UpdatedAt: dbStatus.UpdatedAt,
+		Status:    dbStatus.Status,
+		Reason:    dbStatus.StatusReason,
+	}
+	if dbStatus.SuspendedUntil.Valid {
+		responseStatus.SuspendedUntil = &dbStatus.SuspendedUntil.Time
+	}
+	return responseStatus
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/models"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/tracing"
+)
+
+const (
+	defaultLoginAttemptsLimit = 100
+	maxLoginAttemptsLimit     = 1000
+)
+
+type AdminService struct {
+	ApiConfig     *config.ApiConfig
+	loginAttempts repository.LoginAttempts
+}
+
+func NewAdminService(apiConfig *config.ApiConfig, loginAttempts repository.LoginAttempts) *AdminService {
+	return &AdminService{ApiConfig: apiConfig, loginAttempts: loginAttempts}
+}
+
+func (adminServ *AdminService) GetLoginAttempts(ctx context.Context, principal auth.Principal, email string, limit string) ([]models.LoginAttemptResponse, error) {
+	ctx, span := tracing.Start(ctx, "AdminService.GetLoginAttempts")
+	defer span.End()
+
+	err := requireAdmin(principal)
+	if err != nil {
+		return nil, err
+	}
+
+	attemptsLimit, err := parseLimit(limit, defaultLoginAttemptsLimit, maxLoginAttemptsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var dbAttempts []database.LoginAttempt
+	if email != "" {
+		dbAttempts, err = adminServ.loginAttempts.GetLoginAttemptsForEmail(ctx, database.GetLoginAttemptsForEmailParams{Email: email, Limit: int32(attemptsLimit)})
+	} else {
+		dbAttempts, err = adminServ.loginAttempts.GetLoginAttempts(ctx, int32(attemptsLimit))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get login attempts: %w", err)
+	}
+
+	responseAttempts := make([]models.LoginAttemptResponse, len(dbAttempts))
+	for i, attempt := range dbAttempts {
+		responseAttempts[i] = models.LoginAttemptResponse{
+			ID:        attempt.ID,
+			CreatedAt: attempt.CreatedAt,
+			Email:     attempt.Email,
+			IPAddress: attempt.IpAddress,
+			UserID:    attempt.UserID,
+			Succeeded: attempt.Succeeded,
+		}
+	}
+	return responseAttempts, nil
+}
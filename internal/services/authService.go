@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/tracing"
+)
+
+type AuthService struct {
+	ApiConfig      *config.ApiConfig
+	users          repository.Users
+	personalTokens repository.PersonalAccessTokens
+	// queries look up OAuth clients, which are kept in the database only
+	queries database.Querier
+}
+
+func NewAuthService(apiConfig *config.ApiConfig, users repository.Users, personalTokens repository.PersonalAccessTokens, queries database.Querier) *AuthService {
+	return &AuthService{ApiConfig: apiConfig, users: users, personalTokens: personalTokens, queries: queries}
+}
+
+// Authenticate resolves bearer token (JWT or personal access token) from header into the caller
+func (authServ *AuthService) Authenticate(ctx context.Context, header http.Header) (auth.Principal, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Authenticate")
+	defer span.End()
+
+	token, err := auth.GetBearerToken(header)
+	if err != nil {
+		return auth.Principal{}, wrapError(ErrorUnauthenticated, err)
+	}
+
+	var principal auth.Principal
+	if auth.IsPersonalAccessToken(token) {
+		dbToken, err := authServ.personalTokens.GetActivePersonalAccessToken(ctx, auth.HashToken(token))
+		if errors.Is(err, repository.ErrNotFound) {
+			return auth.Principal{}, unauthenticatedError("token is not valid")
+		}
+		if err != nil {
+			return auth.Principal{}, fmt.Errorf("cannot get token: %w", err)
+		}
+
+		err = authServ.personalTokens.TouchPersonalAccessToken(ctx, dbToken.ID)
+		if err != nil {
+			return auth.Principal{}, fmt.Errorf("cannot update token usage: %w", err)
+		}
+		principal = auth.Principal{UserID: dbToken.UserID, Scopes: dbToken.Scopes, TokenType: auth.TokenTypePersonal}
+	} else {
+		claims, err := auth.ParseAccessToken(token, authServ.ApiConfig.JWTSecret)
+		if err != nil {
+			return auth.Principal{}, wrapError(ErrorUnauthenticated, err)
+		}
+		userID, err := auth.ParseSubject(claims)
+		if err != nil {
+			return auth.Principal{}, wrapError(ErrorUnauthenticated, err)
+		}
+
+		principal = auth.Principal{UserID: userID, TokenType: auth.TokenTypeSession}
+		if claims.ClientID != "" {
+			// access tokens cannot be revoked, so tokens of a deleted client stop working with the client
+			_, err = authServ.queries.GetOAuthClient(ctx, claims.ClientID)
+			if errors.Is(err, repository.ErrNotFound) {
+				return auth.Principal{}, unauthenticatedError("client of the token does not exist")
+			}
+			if err != nil {
+				return auth.Principal{}, fmt.Errorf("cannot get client: %w", err)
+			}
+
+			principal.TokenType = auth.TokenTypeOAuth
+			principal.ClientID = claims.ClientID
+			principal.Scopes = strings.Fields(claims.Scope)
+		}
+	}
+
+	userAccess, err := authServ.users.GetUserAccess(ctx, principal.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return auth.Principal{}, unauthenticatedError("user does not exist")
+	}
+	if err != nil {
+		return auth.Principal{}, fmt.Errorf("cannot get user: %w", err)
+	}
+	// tokens of account pending deletion stay unusable until the user logs in again and cancels the deletion
+	if userAccess.DeletionScheduledAt.Valid {
+		return auth.Principal{}, unauthenticatedError("account is scheduled for deletion, log in to cancel it")
+	}
+	err = checkAccountStatus(userAccess.Status, userAccess.SuspendedUntil)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	if userAccess.IsAdmin {
+		principal.Roles = append(principal.Roles, auth.RoleAdmin)
+	}
+
+	return principal, nil
+}
+
+func requireScope(principal auth.Principal, scope string) error {
+	if !principal.IsAuthenticated() {
+		return unauthenticatedError("authentication required")
+	}
+	if !principal.HasScope(scope) {
+		return forbiddenError("token does not have %s scope", scope)
+	}
+	return nil
+}
+
+// requireReadScope lets anonymous callers read public data, but a token has to have the scope,
+// because reads with a token see what only its user can see (e.g. own shadow-banned messages)
+func requireReadScope(principal auth.Principal, scope string) error {
+	if principal.IsAuthenticated() && !principal.HasScope(scope) {
+		return forbiddenError("token does not have %s scope", scope)
+	}
+	return nil
+}
+
+// requireFirstParty rejects third-party and personal tokens, e.g. for managing credentials
+func requireFirstParty(principal auth.Principal) error {
+	if !principal.IsAuthenticated() {
+		return unauthenticatedError("authentication required")
+	}
+	if !principal.IsFirstParty() {
+		return forbiddenError("this action requires user's own access token")
+	}
+	return nil
+}
+
+func requireAdmin(principal auth.Principal) error {
+	err := requireFirstParty(principal)
+	if err != nil {
+		return err
+	}
+	if !principal.HasRole(auth.RoleAdmin) {
+		return forbiddenError("user is not an admin")
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/models"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/tracing"
+	"github.com/google/uuid"
+)
+
+// BlockService manages blocks and mutes. Blocked users and users who blocked the viewer
+// are hidden from each other completely, muted users are only left out of the viewer's message lists.
+type BlockService struct {
+	ApiConfig *config.ApiConfig
+	blocks    repository.Blocks
+	users     repository.Users
+}
+
+func NewBlockService(apiConfig *config.ApiConfig, blocks repository.Blocks, users repository.Users) *BlockService {
+	return &BlockService{ApiConfig: apiConfig, blocks: blocks, users: users}
+}
+
+func (blockServ *BlockService) BlockUser(ctx context.Context, principal auth.Principal, userID string) error {
+	ctx, span := tracing.Start(ctx, "BlockService.BlockUser")
+	defer span.End()
+
+	targetID, err := blockServ.validateTarget(ctx, principal, userID)
+	if err != nil {
+		return err
+	}
+
+	err = blockServ.blocks.CreateBlock(ctx, database.CreateBlockParams{BlockerID: principal.UserID, BlockedID: targetID})
+	if err != nil {
+		return fmt.Errorf("cannot block user: %w", err)
+	}
+	return nil
+}
+
+func (blockServ *BlockService) UnblockUser(ctx context.Context, principal auth.Principal, userID string) error {
+	ctx, span := tracing.Start(ctx, "BlockService.UnblockUser")
+	defer span.End()
+
+	targetID, err := blockServ.parseTarget(principal, userID)
+	if err != nil {
+		return err
+	}
+
+	err = blockServ.blocks.DeleteBlock(ctx, database.DeleteBlockParams{BlockerID: principal.UserID, BlockedID: targetID})
+	if err != nil {
+		return fmt.Errorf("cannot unblock user: %w", err)
+	}
+	return nil
+}
+
+func (blockServ *BlockService) GetBlockedUsers(ctx context.Context, principal auth.Principal) ([]models.UserRelationResponse, error) {
+	ctx, span := tracing.Start(ctx, "BlockService.GetBlockedUsers")
+	defer span.End()
+
+	err := requireFirstParty(principal)
+	if err != nil {
+		return nil, err
+	}
+
+	dbBlocks, err := blockServ.blocks.GetBlocksForUser(ctx, principal.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get blocked users: %w", err)
+	}
+
+	responseBlocks := make([]models.UserRelationResponse, len(dbBlocks))
+	for i, block := range dbBlocks {
+		responseBlocks[i] = models.UserRelationResponse{UserID: block.BlockedID, CreatedAt: block.CreatedAt}
+	}
+	return responseBlocks, nil
+}
+
+func (blockServ *BlockService) MuteUser(ctx context.Context, principal auth.Principal, userID string) error {
+	ctx, span := tracing.Start(ctx, "BlockService.MuteUser")
+	defer span.End()
+
+	targetID, err := blockServ.validateTarget(ctx, principal, userID)
+	if err != nil {
+		return err
+	}
+
+	err = blockServ.blocks.CreateMute(ctx, database.CreateMuteParams{MuterID: principal.UserID, MutedID: targetID})
+	if err != nil {
+		return fmt.Errorf("cannot mute user: %w", err)
+	}
+	return nil
+}
+
+func (blockServ *BlockService) UnmuteUser(ctx context.Context, principal auth.Principal, userID string) error {
+	ctx, span := tracing.Start(ctx, "BlockService.UnmuteUser")
+	defer span.End()
+
+	targetID, err := blockServ.parseTarget(principal, userID)
+	if err != nil {
+		return err
+	}
+
+	err = blockServ.blocks.DeleteMute(ctx, database.DeleteMuteParams{MuterID: principal.UserID, MutedID: targetID})
+	if err != nil {
+		return fmt.Errorf("cannot unmute user: %w", err)
+	}
+	return nil
+}
+
+func (blockServ *BlockService) GetMutedUsers(ctx context.Context, principal auth.Principal) ([]models.UserRelationResponse, error) {
+	ctx, span := tracing.Start(ctx, "BlockService.GetMutedUsers")
+	defer span.End()
+
+	err := requireFirstParty(principal)
+	if err != nil {
+		return nil, err
+	}
+
+	dbMutes, err := blockServ.blocks.GetMutesForUser(ctx, principal.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get muted users: %w", err)
+	}
+
+	responseMutes := make([]models.UserRelationResponse, len(dbMutes))
+	for i, mute := range dbMutes {
+		responseMutes[i] = models.UserRelationResponse{UserID: mute.MutedID, CreatedAt: mute.CreatedAt}
+	}
+	return responseMutes, nil
+}
+
+func (blockServ *BlockService) parseTarget(principal auth.Principal, userID string) (uuid.UUID, error) {
+	err := requireScope(principal, auth.ScopeProfileWrite)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	targetID, err := uuid.Parse(userID)
+	if err != nil {
+		return uuid.UUID{}, validationError("cannot convert user id to uuid: %s", err)
+	}
+	if targetID == principal.UserID {
+		return uuid.UUID{}, validationError("user cannot block or mute themselves")
+	}
+	return targetID, nil
+}
+
+func (blockServ *BlockService) validateTarget(ctx context.Context, principal auth.Principal, userID string) (uuid.UUID, error) {
+	targetID, err := blockServ.parseTarget(principal, userID)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	userExists, err := blockServ.users.CheckUserExists(ctx, targetID)
+	if err != nil {
+		return uuid.UUID{}, fmt.Errorf("error in user validation: %w", err)
+	}
+	if !userExists {
+		return uuid.UUID{}, notFoundError("user not found")
+	}
+	return targetID, nil
+}
+
+// hiddenAuthors returns authors whose messages must not be shown to the viewer: blocked, muted and shadow-banned ones
+func hiddenAuthors(ctx context.Context, messages repository.Messages, viewer auth.Principal) (map[uuid.UUID]bool, error) {
+	hidden := make(map[uuid.UUID]bool)
+
+	// anonymous viewer has nil user id, so only shadow-banned authors are hidden from them
+	authorIDs, err := messages.GetHiddenAuthorsForUser(ctx, viewer.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get hidden authors: %w", err)
+	}
+	for _, authorID := range authorIDs {
+		hidden[authorID] = true
+	}
+	return hidden, nil
+}
+
+// isBlockedBetween reports whether either of the users blocked the other one
+func isBlockedBetween(ctx context.Context, messages repository.Messages, firstUserID, secondUserID uuid.UUID) (bool, error) {
+	blocked, err := messages.CheckBlockBetween(ctx, database.CheckBlockBetweenParams{FirstUserID: firstUserID, SecondUserID: secondUserID})
+	if err != nil {
+		return false, fmt.Errorf("cannot check blocks: %w", err)
+	}
+	return blocked, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestBlocksInDemoMode(t *testing.T) {
+	apiCfg := newDemoTestApiConfig(t)
+	repos := apiCfg.Repositories
+	blockServ := NewBlockService(apiCfg, repos.Blocks, repos.Users)
+	messageServ := NewMessageService(apiCfg, repos.Messages, repos.Users, repos.Reports)
+	userServ := newTestUserService(apiCfg)
+	ctx := context.Background()
+	viewer := createDemoUser(t, userServ, "viewer@example.com")
+	author := createDemoUser(t, userServ, "author@example.com")
+	_, err := messageServ.CreateMessage(ctx, author, models.MessageRequest{Body: "hello"})
+	if err != nil {
+		t.Fatalf("cannot create message: %s", err)
+	}
+
+	visibleMessages := func(viewer auth.Principal) int {
+		t.Helper()
+		messages, err := messageServ.GetAllMessages(ctx, viewer, "", "")
+		if err != nil {
+			t.Fatalf("cannot get messages: %s", err)
+		}
+		return len(messages)
+	}
+
+	err = blockServ.BlockUser(ctx, viewer, author.UserID.String())
+	if err != nil {
+		t.Fatalf("cannot block user: %s", err)
+	}
+	blocked, err := blockServ.GetBlockedUsers(ctx, viewer)
+	if err != nil || len(blocked) != 1 || blocked[0].UserID != author.UserID {
+		t.Fatalf("expected blocked author, got %+v, %v", blocked, err)
+	}
+	if count := visibleMessages(viewer); count != 0 {
+		t.Fatalf("messages of blocked author are visible: %d", count)
+	}
+
+	err = blockServ.UnblockUser(ctx, viewer, author.UserID.String())
+	if err != nil {
+		t.Fatalf("cannot unblock user: %s", err)
+	}
+	err = blockServ.MuteUser(ctx, viewer, author.UserID.String())
+	if err != nil {
+		t.Fatalf("cannot mute user: %s", err)
+	}
+	if count := visibleMessages(viewer); count != 0 {
+		t.Fatalf("messages of muted author are visible: %d", count)
+	}
+	if count := visibleMessages(author); count != 1 {
+		t.Fatalf("mute must hide messages only from the muter, author sees %d", count)
+	}
+
+	err = blockServ.BlockUser(ctx, viewer, uuid.NewString())
+	requireErrorKind(t, err, ErrorNotFound)
+}
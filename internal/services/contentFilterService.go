@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/contentfilter"
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/models"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/tracing"
+	"github.com/google/uuid"
+)
+
+const maxContentFilterTermLength = 100
+
+// ContentFilterService manages content filter rules, rules can be edited only when they are stored in the database
+// (contentfilter.DatabaseSource reads them), so they have no repository
+type ContentFilterService struct {
+	ApiConfig *config.ApiConfig
+	queries   database.Querier
+}
+
+func NewContentFilterService(apiConfig *config.ApiConfig, queries database.Querier) *ContentFilterService {
+	return &ContentFilterService{ApiConfig: apiConfig, queries: queries}
+}
+
+func (filterServ *ContentFilterService) GetRules(ctx context.Context, principal auth.Principal) ([]models.ContentFilterRuleResponse, error) {
+	ctx, span := tracing.Start(ctx, "ContentFilterService.GetRules")
+	defer span.End()
+
+	err := requireAdmin(principal)
+	if err != nil {
+		return nil, err
+	}
+	err = filterServ.requireDatabaseSource()
+	if err != nil {
+		return nil, err
+	}
+
+	dbRules, err := filterServ.queries.GetContentFilterRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get content filter rules: %w", err)
+	}
+
+	responseRules := make([]models.ContentFilterRuleResponse, len(dbRules))
+	for i, rule := range dbRules {
+		responseRules[i] = convertDBToContentFilterRule(rule)
+	}
+	return responseRules, nil
+}
+
+// CreateRule adds rule and reloads the filter, so the rule applies to the next message
+func (filterServ *ContentFilterService) CreateRule(ctx context.Context, principal auth.Principal, ruleRequest models.ContentFilterRuleRequest) (models.ContentFilterRuleResponse, error) {
+	ctx, span := tracing.Start(ctx, "ContentFilterService.CreateRule")
+	defer span.End()
+
+	err := requireAdmin(principal)
+	if err != nil {
+		return models.ContentFilterRuleResponse{}, err
+	}
+	err = filterServ.requireDatabaseSource()
+	if err != nil {
+		return models.ContentFilterRuleResponse{}, err
+	}
+
+	rule := contentfilter.Rule{Term: strings.TrimSpace(ruleRequest.Term), Action: ruleRequest.Action}
+	if len(rule.Term) > maxContentFilterTermLength {
+		return models.ContentFilterRuleResponse{}, validationError("term must be at most %d characters", maxContentFilterTermLength)
+	}
+	err = contentfilter.ValidateRule(rule)
+	if err != nil {
+		return models.ContentFilterRuleResponse{}, wrapError(ErrorValidation, err)
+	}
+
+	dbRule, err := filterServ.queries.CreateContentFilterRule(ctx, database.CreateContentFilterRuleParams{Term: rule.Term, Action: rule.Action})
+	if errors.Is(err, repository.ErrDuplicate) {
+		return models.ContentFilterRuleResponse{}, conflictError("rule for this term already exists")
+	}
+	if err != nil {
+		return models.ContentFilterRuleResponse{}, fmt.Errorf("cannot create content filter rule: %w", err)
+	}
+
+	err = filterServ.ApiConfig.ContentFilter.Reload(ctx)
+	if err != nil {
+		return models.ContentFilterRuleResponse{}, err
+	}
+	return convertDBToContentFilterRule(dbRule), nil
+}
+
+func (filterServ *ContentFilterService) DeleteRule(ctx context.Context, principal auth.Principal, ruleID string) error {
+	ctx, span := tracing.Start(ctx, "ContentFilterService.DeleteRule")
+	defer span.End()
+
+	err := requireAdmin(principal)
+	if err != nil {
+		return err
+	}
+	err = filterServ.requireDatabaseSource()
+	if err != nil {
+		return err
+	}
+
+	ruleUUID, err := uuid.Parse(ruleID)
+	if err != nil {
+		return validationError("cannot convert rule id to uuid: %s", err)
+	}
+
+	_, err = filterServ.queries.DeleteContentFilterRule(ctx, ruleUUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return notFoundError("content filter rule not found")
+	}
+	if err != nil {
+		return fmt.Errorf("cannot delete content filter rule: %w", err)
+	}
+
+	err = filterServ.ApiConfig.ContentFilter.Reload(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Reload re-reads rules from their source right away, e.g. after the rules file was edited
+func (filterServ *ContentFilterService) Reload(ctx context.Context, principal auth.Principal) error {
+	ctx, span := tracing.Start(ctx, "ContentFilterService.Reload")
+	defer span.End()
+
+	err := requireAdmin(principal)
+	if err != nil {
+		return err
+	}
+
+	err = filterServ.ApiConfig.ContentFilter.Reload(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (filterServ *ContentFilterService) requireDatabaseSource() error {
+	if _, ok := filterServ.ApiConfig.ContentFilter.Source().(contentfilter.DatabaseSource); !ok {
+		return conflictError("content filter rules are loaded from a file, edit the file instead")
+	}
+	return nil
+}
+
+func convertDBToContentFilterRule(dbRule database.ContentFilterRule) models.ContentFilterRuleResponse {
+	return models.ContentFilterRuleResponse{
+		ID:        dbRule.ID,
+		CreatedAt: dbRule.CreatedAt,
+		Term:      dbRule.Term,
+		Action:    dbRule.Action,
+	}
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/logging"
+	"github.com/ech00wv/SNserver/internal/models"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/storage"
+	"github.com/ech00wv/SNserver/internal/tracing"
+	"github.com/google/uuid"
+)
+
+const (
+	DataExportStatusPending   = "pending"
+	DataExportStatusRunning   = "running"
+	DataExportStatusCompleted = "completed"
+	DataExportStatusFailed    = "failed"
+
+	DataExportJobInterval = 5 * time.Second
+	// archives are kept for a week, download links are valid for a shorter time and are renewed on every status request
+	dataExportLifetime    = 7 * 24 * time.Hour
+	dataExportURLLifetime = time.Hour
+	// running export that was not finished in this time is considered abandoned (e.g. server restarted) and is built again
+	dataExportStaleAfter = 15 * time.Minute
+)
+
+// DataExportService builds archives of users' data, exports and identities are kept in the database only
+type DataExportService struct {
+	ApiConfig     *config.ApiConfig
+	queries       database.Querier
+	users         repository.Users
+	messages      repository.Messages
+	refreshTokens repository.RefreshTokens
+	payments      repository.Payments
+}
+
+func NewDataExportService(apiConfig *config.ApiConfig, queries database.Querier, repos repository.Repositories) *DataExportService {
+	return &DataExportService{
+		ApiConfig:     apiConfig,
+		queries:       queries,
+		users:         repos.Users,
+		messages:      repos.Messages,
+		refreshTokens: repos.RefreshTokens,
+		payments:      repos.Payments,
+	}
+}
+
+type exportProfile struct {
+	models.UserResponse
+	Identities []models.UserIdentityResponse `json:"identities"`
+}
+
+type exportSession struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type exportPayment struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Event     string    `json:"event"`
+}
+
+// RequestExport queues export of the caller's data, if an export is already in progress it is returned instead
+func (exportServ *DataExportService) RequestExport(ctx context.Context, principal auth.Principal) (models.DataExportResponse, error) {
+	ctx, span := tracing.Start(ctx, "DataExportService.RequestExport")
+	defer span.End()
+
+	err := requireFirstParty(principal)
+	if err != nil {
+		return models.DataExportResponse{}, err
+	}
+
+	dbExport, err := exportServ.queries.GetActiveDataExportForUser(ctx, principal.UserID)
+	if err == nil {
+		return exportServ.convertDBToDataExport(dbExport), nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return models.DataExportResponse{}, fmt.Errorf("cannot get data export: %w", err)
+	}
+
+	dbExport, err = exportServ.queries.CreateDataExport(ctx, database.CreateDataExportParams{UserID: principal.UserID, Status: DataExportStatusPending})
+	if err != nil {
+		return models.DataExportResponse{}, fmt.Errorf("cannot create data export: %w", err)
+	}
+	return exportServ.convertDBToDataExport(dbExport), nil
+}
+
+func (exportServ *DataExportService) GetExport(ctx context.Context, principal auth.Principal, exportID string) (models.DataExportResponse, error) {
+	ctx, span := tracing.Start(ctx, "DataExportService.GetExport")
+	defer span.End()
+
+	err := requireFirstParty(principal)
+	if err != nil {
+		return models.DataExportResponse{}, err
+	}
+
+	dbExport, err := exportServ.getExport(ctx, exportID)
+	if err != nil {
+		return models.DataExportResponse{}, err
+	}
+	if dbExport.UserID != principal.UserID {
+		return models.DataExportResponse{}, notFoundError("export not found")
+	}
+	return exportServ.convertDBToDataExport(dbExport), nil
+}
+
+// OpenExport returns archive of completed export, access is granted by the signed url instead of a token
+func (exportServ *DataExportService) OpenExport(ctx context.Context, exportID string, query url.Values) (io.ReadCloser, error) {
+	ctx, span := tracing.Start(ctx, "DataExportService.OpenExport")
+	defer span.End()
+
+	exportUUID, err := uuid.Parse(exportID)
+	if err != nil {
+		return nil, validationError("cannot convert export id to uuid: %s", err)
+	}
+	err = auth.VerifyURLSignature(DataExportDownloadPath(exportUUID), query, exportServ.ApiConfig.JWTSecret)
+	if err != nil {
+		return nil, wrapError(ErrorForbidden, err)
+	}
+
+	dbExport, err := exportServ.getExport(ctx, exportID)
+	if err != nil {
+		return nil, err
+	}
+	if dbExport.Status != DataExportStatusCompleted || !dbExport.StorageKey.Valid {
+		return nil, notFoundError("export is not ready")
+	}
+
+	archive, err := exportServ.ApiConfig.Storage.Open(ctx, dbExport.StorageKey.String)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, notFoundError("export archive not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot open export archive: %w", err)
+	}
+	return archive, nil
+}
+
+// ProcessExports removes expired archives and builds all queued exports
+func (exportServ *DataExportService) ProcessExports(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "DataExportService.ProcessExports")
+	defer span.End()
+
+	expiredKeys, err := exportServ.queries.DeleteExpiredDataExports(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("cannot delete expired data exports: %w", err)
+	}
+	for _, key := range expiredKeys {
+		if !key.Valid {
+			continue
+		}
+		err = exportServ.ApiConfig.Storage.Delete(ctx, key.String)
+		if err != nil {
+			logging.FromContext(ctx).Error("cannot delete export archive", "key", key.String, "error", err)
+		}
+	}
+
+	for {
+		dbExport, err := exportServ.queries.ClaimDataExport(ctx, time.Now().Add(-dataExportStaleAfter))
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot claim data export: %w", err)
+		}
+
+		err = exportServ.buildExport(ctx, dbExport)
+		if err != nil {
+			logging.FromContext(ctx).Error("data export failed", "export_id", dbExport.ID, "error", err)
+			err = exportServ.queries.FailDataExport(ctx, database.FailDataExportParams{ID: dbExport.ID, Error: "cannot build export"})
+			if err != nil {
+				return fmt.Errorf("cannot mark data export as failed: %w", err)
+			}
+		}
+	}
+}
+
+func (exportServ *DataExportService) buildExport(ctx context.Context, dbExport database.DataExport) error {
+	files, err := exportServ.collectUserData(ctx, dbExport.UserID)
+	if err != nil {
+		return err
+	}
+
+	// archive is streamed to storage while it is being written
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		pipeWriter.CloseWithError(writeExportArchive(pipeWriter, files))
+	}()
+
+	key := fmt.Sprintf("exports/%s/%s.zip", dbExport.UserID, dbExport.ID)
+	err = exportServ.ApiConfig.Storage.Save(ctx, key, pipeReader)
+	pipeReader.Close()
+	if err != nil {
+		return fmt.Errorf("cannot save archive: %w", err)
+	}
+
+	completed, err := exportServ.queries.CompleteDataExport(ctx, database.CompleteDataExportParams{
+		ID:         dbExport.ID,
+		StorageKey: key,
+		ExpiresAt:  time.Now().Add(dataExportLifetime),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot complete data export: %w", err)
+	}
+	// the export was deleted together with its user while the archive was being built
+	if completed == 0 {
+		err = exportServ.ApiConfig.Storage.Delete(ctx, key)
+		if err != nil {
+			return fmt.Errorf("cannot delete archive of deleted export: %w", err)
+		}
+	}
+	return nil
+}
+
+type exportFile struct {
+	name    string
+	content []byte
+}
+
+func (exportServ *DataExportService) collectUserData(ctx context.Context, userID uuid.UUID) ([]exportFile, error) {
+	dbUser, err := exportServ.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get user: %w", err)
+	}
+	dbIdentities, err := exportServ.queries.GetUserIdentitiesForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get identities: %w", err)
+	}
+	profile := exportProfile{UserResponse: convertDBToUser(dbUser), Identities: make([]models.UserIdentityResponse, len(dbIdentities))}
+	for i, identity := range dbIdentities {
+		profile.Identities[i] = convertDBToIdentity(identity)
+	}
+
+	dbMessages, err := exportServ.messages.GetAllMessagesForAuthor(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get messages: %w", err)
+	}
+	messages := make([]models.MessageResponse, len(dbMessages))
+	for i, message := range dbMessages {
+		messages[i] = converDbToMessage(message)
+	}
+
+	dbSessions, err := exportServ.refreshTokens.GetRefreshTokensForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get sessions: %w", err)
+	}
+	sessions := make([]exportSession, len(dbSessions))
+	for i, session := range dbSessions {
+		sessions[i] = exportSession{CreatedAt: session.CreatedAt, ExpiresAt: session.ExpiresAt}
+		if session.RevokedAt.Valid {
+			sessions[i].RevokedAt = &session.RevokedAt.Time
+		}
+	}
+
+	dbPayments, err := exportServ.payments.GetPaymentEventsForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get payments: %w", err)
+	}
+	payments := make([]exportPayment, len(dbPayments))
+	for i, payment := range dbPayments {
+		payments[i] = exportPayment{ID: payment.ID, CreatedAt: payment.CreatedAt, Event: payment.Event}
+	}
+
+	var files []exportFile
+	for _, file := range []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", profile},
+		{"messages.json", messages},
+		{"sessions.json", sessions},
+		{"payments.json", payments},
+	} {
+		content, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode %s: %w", file.name, err)
+		}
+		files = append(files, exportFile{name: file.name, content: content})
+	}
+
+	var ndjson []byte
+	for _, message := range messages {
+		line, err := json.Marshal(message)
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode messages.ndjson: %w", err)
+		}
+		ndjson = append(append(ndjson, line...), '\n')
+	}
+	files = append(files, exportFile{name: "messages.ndjson", content: ndjson})
+
+	return files, nil
+}
+
+func writeExportArchive(writer io.Writer, files []exportFile) error {
+	zipWriter := zip.NewWriter(writer)
+	for _, file := range files {
+		fileWriter, err := zipWriter.Create(file.name)
+		if err != nil {
+			return err
+		}
+		_, err = fileWriter.Write(file.content)
+		if err != nil {
+			return err
+		}
+	}
+	return zipWriter.Close()
+}
+
+func (exportServ *DataExportService) getExport(ctx context.Context, exportID string) (database.DataExport, error) {
+	exportUUID, err := uuid.Parse(exportID)
+	if err != nil {
+		return database.DataExport{}, validationError("cannot convert export id to uuid: %s", err)
+	}
+
+	dbExport, err := exportServ.queries.GetDataExport(ctx, exportUUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return database.DataExport{}, notFoundError("export not found")
+	}
+	if err != nil {
+		return database.DataExport{}, fmt.Errorf("cannot get export: %w", err)
+	}
+	return dbExport, nil
+}
+
+// DataExportDownloadPath is the path of archive download endpoint, it is also the signed part of download url
+func DataExportDownloadPath(exportID uuid.UUID) string {
+	return fmt.Sprintf("/api/exports/%s/download", exportID)
+}
+
+func (exportServ *DataExportService) convertDBToDataExport(dbExport database.DataExport) models.DataExportResponse {
+	responseExport := models.DataExportResponse{
+		ID:        dbExport.ID,
+		CreatedAt: dbExport.CreatedAt,
+		Status:    dbExport.Status,
+		Error:     dbExport.Error.String,
+	}
+	if dbExport.CompletedAt.Valid {
+		responseExport.CompletedAt = &dbExport.CompletedAt.Time
+	}
+	if dbExport.ExpiresAt.Valid {
+		responseExport.ExpiresAt = &dbExport.ExpiresAt.Time
+	}
+	if dbExport.Status == DataExportStatusCompleted {
+		urlExpiresAt := time.Now().Add(dataExportURLLifetime)
+		if dbExport.ExpiresAt.Valid && dbExport.ExpiresAt.Time.Before(urlExpiresAt) {
+			urlExpiresAt = dbExport.ExpiresAt.Time
+		}
+		responseExport.DownloadURL = auth.SignURL(DataExportDownloadPath(dbExport.ID), urlExpiresAt, exportServ.ApiConfig.JWTSecret)
+	}
+	return responseExport
+}
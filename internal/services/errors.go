@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ech00wv/SNserver/internal/config"
+)
+
+// ErrorKind is the class of a service error, transports map it to their own codes (e.g. HTTP statuses)
+type ErrorKind int
+
+const (
+	// ErrorInternal is a failure of the server or its database, its message is not meant for clients.
+	// Errors returned by services that are not *Error are internal too.
+	ErrorInternal ErrorKind = iota
+	// ErrorValidation means that the request is malformed or breaks a rule
+	ErrorValidation
+	// ErrorUnauthenticated means that the caller is unknown or its credentials are wrong
+	ErrorUnauthenticated
+	// ErrorForbidden means that the caller is known, but is not allowed to do the action
+	ErrorForbidden
+	ErrorNotFound
+	// ErrorConflict means that the action contradicts the current state, e.g. the report is already resolved
+	ErrorConflict
+	// ErrorTooManyAttempts means that the caller has to wait before trying again
+	ErrorTooManyAttempts
+	// ErrorUpstream is a failure of an external service, e.g. identity provider
+	ErrorUpstream
+	// ErrorNotImplemented means that the feature is not available in the server's configuration, e.g. in demo mode
+	ErrorNotImplemented
+)
+
+// codes of errors that clients may handle specially
+const (
+	ErrorCodeAccountBanned    = "account_banned"
+	ErrorCodeAccountSuspended = "account_suspended"
+	ErrorCodeDemoMode         = "demo_mode"
+)
+
+// Error is an error the caller of a service can act on
+type Error struct {
+	Kind ErrorKind
+	// Code is a machine-readable error code, transports use their default code of the kind if it is empty
+	Code    string
+	Message string
+	// Err is the cause with details for transports, e.g. *auth.PasswordPolicyError
+	Err error
+}
+
+func (serviceErr *Error) Error() string {
+	return serviceErr.Message
+}
+
+func (serviceErr *Error) Unwrap() error {
+	return serviceErr.Err
+}
+
+// ErrorKindOf returns kind of err, errors that are not *Error are internal
+func ErrorKindOf(err error) ErrorKind {
+	var serviceErr *Error
+	if errors.As(DescribeError(err), &serviceErr) {
+		return serviceErr.Kind
+	}
+	return ErrorInternal
+}
+
+// DescribeError turns failures the caller can act on, but which come from below the services,
+// into *Error: the database of demo mode fails every query of features that are kept in the database only.
+// Other errors are returned as they are.
+func DescribeError(err error) error {
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return err
+	}
+	if errors.Is(err, config.ErrDemoMode) {
+		return &Error{Kind: ErrorNotImplemented, Code: ErrorCodeDemoMode, Message: "this feature is not available in demo mode", Err: err}
+	}
+	return err
+}
+
+func newError(kind ErrorKind, format string, args ...any) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// wrapError uses message of err, which is kept as the cause
+func wrapError(kind ErrorKind, err error) *Error {
+	return &Error{Kind: kind, Message: err.Error(), Err: err}
+}
+
+func validationError(format string, args ...any) *Error {
+	return newError(ErrorValidation, format, args...)
+}
+
+func unauthenticatedError(format string, args ...any) *Error {
+	return newError(ErrorUnauthenticated, format, args...)
+}
+
+func forbiddenError(format string, args ...any) *Error {
+	return newError(ErrorForbidden, format, args...)
+}
+
+func notFoundError(format string, args ...any) *Error {
+	return newError(ErrorNotFound, format, args...)
+}
+
+func conflictError(format string, args ...any) *Error {
+	return newError(ErrorConflict, format, args...)
+}
+
+func tooManyAttemptsError(format string, args ...any) *Error {
+	return newError(ErrorTooManyAttempts, format, args...)
+}
+
+func upstreamError(format string, args ...any) *Error {
+	return newError(ErrorUpstream, format, args...)
+}
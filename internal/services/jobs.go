@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/logging"
+	"github.com/ech00wv/SNserver/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	AccountDeletionJobInterval      = 10 * time.Minute
+	RateLimitCleanupJobInterval     = time.Hour
+	LoginThrottleCleanupJobInterval = loginAttemptWindow
+)
+
+// RunPeriodically runs job right away and then every interval until ctx is done.
+// Errors are logged and do not stop next runs. Every run is traced and gets a logger with the job's name in its ctx.
+func RunPeriodically(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	logger := logging.FromContext(ctx).With("job", name)
+
+	for {
+		runCtx, span := tracing.Start(logging.NewContext(ctx, logger), "job "+name)
+		err := job(runCtx)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			logger.Error("job failed", "error", err)
+		}
+		span.End()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Jobs runs periodic jobs in background and keeps track of them for health checks
+type Jobs struct {
+	mu       sync.Mutex
+	statuses []*jobStatus
+	wg       sync.WaitGroup
+}
+
+type jobStatus struct {
+	name       string
+	interval   time.Duration
+	running    bool
+	finishedAt time.Time
+	exited     bool
+}
+
+// Start runs job with RunPeriodically until ctx is done
+func (jobs *Jobs) Start(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	status := &jobStatus{name: name, interval: interval, finishedAt: time.Now()}
+	jobs.mu.Lock()
+	jobs.statuses = append(jobs.statuses, status)
+	jobs.mu.Unlock()
+
+	jobs.wg.Add(1)
+	go func() {
+		defer jobs.wg.Done()
+		defer jobs.update(func() { status.exited = true })
+
+		RunPeriodically(ctx, name, interval, func(ctx context.Context) error {
+			jobs.update(func() { status.running = true })
+			defer jobs.update(func() {
+				status.running = false
+				status.finishedAt = time.Now()
+			})
+			return job(ctx)
+		})
+	}()
+}
+
+func (jobs *Jobs) update(change func()) {
+	jobs.mu.Lock()
+	defer jobs.mu.Unlock()
+	change()
+}
+
+// Wait blocks until all jobs stop after their ctx is done
+func (jobs *Jobs) Wait() {
+	jobs.wg.Wait()
+}
+
+// Check fails if a job stopped or missed its runs. A job that is running right now is considered alive,
+// because long runs (e.g. building big data exports) cannot be told apart from stuck ones.
+func (jobs *Jobs) Check(ctx context.Context) error {
+	jobs.mu.Lock()
+	defer jobs.mu.Unlock()
+
+	for _, status := range jobs.statuses {
+		if status.exited {
+			return fmt.Errorf("job %s is not running", status.name)
+		}
+		if !status.running && time.Since(status.finishedAt) > 2*status.interval {
+			return fmt.Errorf("job %s has not run since %s", status.name, status.finishedAt.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/logging"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/tracing"
+	"github.com/google/uuid"
+)
+
+const (
+	// loginAttemptWindow is how long failed attempts are counted after the last attempt, it is not shorter
+	// than maxLoginLockout, so counters idle for longer are not locked out and can be deleted
+	loginAttemptWindow       = 15 * time.Minute
+	freeFailedLoginsPerEmail = 3
+	freeFailedLoginsPerIP    = 10
+	maxLoginLockout          = 15 * time.Minute
+)
+
+// login attempts are counted separately for the email and for the ip address. Emails differing only in case
+// or surrounding spaces share the counter, otherwise changing the case would give more free attempts.
+func emailThrottleKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipThrottleKey(ipAddress string) string {
+	return "ip:" + ipAddress
+}
+
+// takeLoginAttempt counts the attempt as failed for email and ip address before the password is checked,
+// so parallel guesses cannot all pass the lockout. After free failed attempts the lockout starts at one second
+// and doubles with every next failed attempt. recordLoginAttempt takes the attempt back if the password is correct.
+func (userServ *UserService) takeLoginAttempt(ctx context.Context, email, ipAddress string) error {
+	err := userServ.takeThrottledAttempt(ctx, emailThrottleKey(email), freeFailedLoginsPerEmail)
+	if err != nil {
+		return err
+	}
+
+	err = userServ.takeThrottledAttempt(ctx, ipThrottleKey(ipAddress), freeFailedLoginsPerIP)
+	if err != nil {
+		// the password is not checked, so the attempt does not count against the email
+		releaseErr := userServ.loginAttempts.ReleaseLoginAttempt(ctx, emailThrottleKey(email))
+		if releaseErr != nil {
+			logging.FromContext(ctx).Error("cannot release login attempt", "error", releaseErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (userServ *UserService) takeThrottledAttempt(ctx context.Context, key string, freeFailures int32) error {
+	_, err := userServ.loginAttempts.TakeLoginAttempt(ctx, database.TakeLoginAttemptParams{
+		Key:               key,
+		WindowSeconds:     loginAttemptWindow.Seconds(),
+		FreeFailures:      freeFailures,
+		MaxLockoutSeconds: maxLoginLockout.Seconds(),
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		waitSeconds, err := userServ.loginAttempts.GetLoginLockout(ctx, database.GetLoginLockoutParams{
+			FreeFailures:      freeFailures,
+			MaxLockoutSeconds: maxLoginLockout.Seconds(),
+			Key:               key,
+		})
+		if err != nil {
+			return fmt.Errorf("cannot get login lockout: %w", err)
+		}
+		return tooManyAttemptsError("too many failed login attempts, try again in %d seconds", int(max(waitSeconds, 0))+1)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot count login attempt: %w", err)
+	}
+	return nil
+}
+
+// recordLoginAttempt keeps the attempt for admins. Successful attempt resets failed attempts of the email
+// and takes back the attempt counted for the ip address.
+func (userServ *UserService) recordLoginAttempt(ctx context.Context, email, ipAddress string, userID uuid.NullUUID, succeeded bool) error {
+	err := userServ.loginAttempts.CreateLoginAttempt(ctx, database.CreateLoginAttemptParams{
+		Email:     email,
+		IpAddress: ipAddress,
+		UserID:    userID,
+		Succeeded: succeeded,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot record login attempt: %w", err)
+	}
+	if !succeeded {
+		return nil
+	}
+
+	err = userServ.loginAttempts.DeleteLoginThrottle(ctx, emailThrottleKey(email))
+	if err != nil {
+		return fmt.Errorf("cannot reset failed logins for email: %w", err)
+	}
+	err = userServ.loginAttempts.ReleaseLoginAttempt(ctx, ipThrottleKey(ipAddress))
+	if err != nil {
+		return fmt.Errorf("cannot release login attempt for ip address: %w", err)
+	}
+	return nil
+}
+
+// DeleteIdleLoginThrottles removes counters without attempts in the last loginAttemptWindow,
+// they would start over anyway. Counters contain emails, so they are not kept longer than needed.
+func (userServ *UserService) DeleteIdleLoginThrottles(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteIdleLoginThrottles")
+	defer span.End()
+
+	err := userServ.loginAttempts.DeleteIdleLoginThrottles(ctx, loginAttemptWindow.Seconds())
+	if err != nil {
+		return fmt.Errorf("cannot delete idle login throttles: %w", err)
+	}
+	return nil
+}
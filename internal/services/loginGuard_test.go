@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/models"
+)
+
+func TestParallelLoginAttemptsAreThrottled(t *testing.T) {
+	tests := []struct {
+		name         string
+		newApiConfig func(t *testing.T) *config.ApiConfig
+	}{
+		{"sqlite", func(t *testing.T) *config.ApiConfig { return newTestApiConfig(t) }},
+		{"memory", newDemoTestApiConfig},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			userServ := newTestUserService(test.newApiConfig(t))
+			ctx := context.Background()
+			createDemoUser(t, userServ, "user@example.com")
+
+			const guesses = 20
+			errs := make([]error, guesses)
+			var wg sync.WaitGroup
+			for i := range guesses {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, errs[i] = userServ.LoginUser(ctx, models.UserRequest{Email: "user@example.com", Password: "wrong-password"}, "192.0.2.1")
+				}()
+			}
+			wg.Wait()
+
+			checkedCount := 0
+			for _, err := range errs {
+				switch ErrorKindOf(err) {
+				case ErrorUnauthenticated:
+					checkedCount++
+				case ErrorTooManyAttempts:
+				default:
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if checkedCount != freeFailedLoginsPerEmail {
+				t.Fatalf("expected %d passwords to be checked, got %d", freeFailedLoginsPerEmail, checkedCount)
+			}
+		})
+	}
+}
+
+func TestSuccessfulLoginResetsFailedAttempts(t *testing.T) {
+	userServ := newTestUserService(newTestApiConfig(t))
+	ctx := context.Background()
+	createDemoUser(t, userServ, "user@example.com")
+
+	for range freeFailedLoginsPerEmail - 1 {
+		_, err := userServ.LoginUser(ctx, models.UserRequest{Email: "user@example.com", Password: "wrong-password"}, "192.0.2.1")
+		requireErrorKind(t, err, ErrorUnauthenticated)
+	}
+	_, err := userServ.LoginUser(ctx, models.UserRequest{Email: "user@example.com", Password: "Correct-horse-98battery"}, "192.0.2.1")
+	if err != nil {
+		t.Fatalf("cannot log in: %s", err)
+	}
+
+	// failed attempts start over, so all free attempts are available again
+	for range freeFailedLoginsPerEmail {
+		_, err := userServ.LoginUser(ctx, models.UserRequest{Email: "user@example.com", Password: "wrong-password"}, "192.0.2.1")
+		requireErrorKind(t, err, ErrorUnauthenticated)
+	}
+	_, err = userServ.LoginUser(ctx, models.UserRequest{Email: "user@example.com", Password: "wrong-password"}, "192.0.2.1")
+	requireErrorKind(t, err, ErrorTooManyAttempts)
+}
+
+func TestLoginLockoutIgnoresEmailCase(t *testing.T) {
+	apiCfg := newDemoTestApiConfig(t)
+	userServ := newTestUserService(apiCfg)
+	ctx := context.Background()
+	createDemoUser(t, userServ, "user@example.com")
+
+	emails := []string{"user@example.com", "USER@example.com", "User@Example.com", "uSeR@eXaMpLe.CoM"}
+	for i, email := range emails[:freeFailedLoginsPerEmail] {
+		// every attempt comes from another address, so only the email counter can lock the account
+		_, err := userServ.LoginUser(ctx, models.UserRequest{Email: email, Password: "wrong-password"}, fmt.Sprintf("192.0.2.%d", i+1))
+		requireErrorKind(t, err, ErrorUnauthenticated)
+	}
+	_, err := userServ.LoginUser(ctx, models.UserRequest{Email: emails[freeFailedLoginsPerEmail], Password: "wrong-password"}, "198.51.100.1")
+	requireErrorKind(t, err, ErrorTooManyAttempts)
+}
@@ -2,64 +2,133 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"net/http"
 	"sort"
 	"strings"
 
 	"github.com/ech00wv/SNserver/internal/auth"
 	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/contentfilter"
 	"github.com/ech00wv/SNserver/internal/database"
 	"github.com/ech00wv/SNserver/internal/models"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/tracing"
 	"github.com/google/uuid"
 )
 
 type MessageService struct {
 	ApiConfig *config.ApiConfig
+	messages  repository.Messages
+	users     repository.Users
+	reports   repository.Reports
 }
 
-func (messageServ *MessageService) GetMessage(ctx context.Context, messageId string) (models.MessageResponse, int, error) {
+func NewMessageService(apiConfig *config.ApiConfig, messages repository.Messages, users repository.Users, reports repository.Reports) *MessageService {
+	return &MessageService{ApiConfig: apiConfig, messages: messages, users: users, reports: reports}
+}
+
+// GetMessage returns message by id, messages of users blocked by the viewer or blocking the viewer
+// and messages of shadow-banned users (except for the author) are not found
+func (messageServ *MessageService) GetMessage(ctx context.Context, viewer auth.Principal, messageId string) (models.MessageResponse, error) {
+	ctx, span := tracing.Start(ctx, "MessageService.GetMessage")
+	defer span.End()
+
+	err := requireReadScope(viewer, auth.ScopeMessagesRead)
+	if err != nil {
+		return models.MessageResponse{}, err
+	}
+
 	if messageId == "" {
-		return models.MessageResponse{}, http.StatusBadRequest, fmt.Errorf("message id not specified")
+		return models.MessageResponse{}, validationError("message id not specified")
 	}
 
 	messageUuid, err := uuid.Parse(messageId)
 	if err != nil {
-		return models.MessageResponse{}, http.StatusInternalServerError, fmt.Errorf("error in converting message id to uuid: %s", err)
+		return models.MessageResponse{}, validationError("message id is not a valid uuid")
+	}
+
+	dbMessage, err := messageServ.messages.GetMessage(ctx, messageUuid)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.MessageResponse{}, notFoundError("message not found")
+	}
+	if err != nil {
+		return models.MessageResponse{}, fmt.Errorf("cannot get message: %w", err)
 	}
 
-	dbMessage, err := messageServ.ApiConfig.Queries.GetMessage(ctx, messageUuid)
+	err = checkMessageVisible(ctx, messageServ.messages, messageServ.users, viewer, dbMessage)
 	if err != nil {
-		return models.MessageResponse{}, http.StatusNotFound, fmt.Errorf("error in getting message by id: %s", err)
+		return models.MessageResponse{}, err
 	}
 
 	responseMessage := converDbToMessage(dbMessage)
-	return responseMessage, http.StatusOK, nil
+	return responseMessage, nil
+}
+
+// checkMessageVisible reports messages hidden from the viewer as not found, so their existence is not revealed:
+// messages of users blocked by the viewer or blocking the viewer and messages of shadow-banned users (except for the author)
+func checkMessageVisible(ctx context.Context, messages repository.Messages, users repository.Users, viewer auth.Principal, dbMessage database.Message) error {
+	if viewer.UserID != dbMessage.UserID {
+		authorAccess, err := users.GetUserAccess(ctx, dbMessage.UserID)
+		if err != nil {
+			return fmt.Errorf("cannot get message author: %w", err)
+		}
+		if authorAccess.Status == AccountStatusShadowBanned {
+			return notFoundError("message not found")
+		}
+	}
+
+	if viewer.IsAuthenticated() {
+		blocked, err := isBlockedBetween(ctx, messages, viewer.UserID, dbMessage.UserID)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return notFoundError("message not found")
+		}
+	}
+	return nil
 }
 
-func (messageServ *MessageService) GetAllMessages(ctx context.Context, authorID string, order string) ([]models.MessageResponse, int, error) {
-	var (
-		messages []database.Message
-		err      error
-	)
+// GetAllMessages lists messages, leaving out authors the viewer blocked or muted, authors who blocked the viewer
+// and shadow-banned authors other than the viewer
+func (messageServ *MessageService) GetAllMessages(ctx context.Context, viewer auth.Principal, authorID string, order string) ([]models.MessageResponse, error) {
+	ctx, span := tracing.Start(ctx, "MessageService.GetAllMessages")
+	defer span.End()
+
+	err := requireReadScope(viewer, auth.ScopeMessagesRead)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []database.Message
 
 	if authorID != "" {
 		var authorUUID uuid.UUID
 		authorUUID, err = uuid.Parse(authorID)
 		if err != nil {
-			return nil, http.StatusBadRequest, fmt.Errorf("wrong author id: %s", err)
+			return nil, validationError("wrong author id: %s", err)
 		}
-		messages, err = messageServ.ApiConfig.Queries.GetAllMessagesForAuthor(ctx, authorUUID)
+		messages, err = messageServ.messages.GetAllMessagesForAuthor(ctx, authorUUID)
 	} else {
-		messages, err = messageServ.ApiConfig.Queries.GetAllMessages(ctx)
+		messages, err = messageServ.messages.GetAllMessages(ctx)
 	}
 	if err != nil {
-		return nil, http.StatusNotFound, fmt.Errorf("cannot get messages: %s", err)
+		return nil, fmt.Errorf("cannot get messages: %w", err)
 	}
 
-	responseMessages := make([]models.MessageResponse, len(messages))
-	for i, message := range messages {
-		responseMessages[i] = converDbToMessage(message)
+	hidden, err := hiddenAuthors(ctx, messageServ.messages, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	responseMessages := make([]models.MessageResponse, 0, len(messages))
+	for _, message := range messages {
+		if hidden[message.UserID] {
+			continue
+		}
+		responseMessages = append(responseMessages, converDbToMessage(message))
 	}
 
 	switch order {
@@ -72,100 +141,139 @@ func (messageServ *MessageService) GetAllMessages(ctx context.Context, authorID
 			return responseMessages[i].CreatedAt.After(responseMessages[j].CreatedAt)
 		})
 	default:
-		return nil, http.StatusBadRequest, fmt.Errorf("wrong sorting order")
+		return nil, validationError("wrong sorting order")
 	}
-	return responseMessages, http.StatusOK, nil
+	return responseMessages, nil
 }
 
-func (messageServ *MessageService) CreateMessage(ctx context.Context, header http.Header, messageStruct models.MessageRequest) (models.MessageResponse, int, error) {
+func (messageServ *MessageService) CreateMessage(ctx context.Context, principal auth.Principal, messageStruct models.MessageRequest) (models.MessageResponse, error) {
+	ctx, span := tracing.Start(ctx, "MessageService.CreateMessage")
+	defer span.End()
 
-	token, err := auth.GetBearerToken(header)
-	if err != nil {
-		return models.MessageResponse{}, http.StatusBadRequest, fmt.Errorf("error in getting token: %s", err)
-	}
-	userId, err := auth.ValidateJWT(token, messageServ.ApiConfig.JWTSecret)
+	err := requireScope(principal, auth.ScopeMessagesWrite)
 	if err != nil {
-		return models.MessageResponse{}, http.StatusUnauthorized, err
+		return models.MessageResponse{}, err
 	}
+	userId := principal.UserID
 
-	userExists, err := messageServ.ApiConfig.Queries.CheckUserExists(ctx, userId)
+	userAccess, err := messageServ.users.GetUserAccess(ctx, userId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.MessageResponse{}, validationError("user does not exists")
+	}
 	if err != nil {
-		return models.MessageResponse{}, http.StatusInternalServerError, fmt.Errorf("error in user validation: %s", err)
+		return models.MessageResponse{}, fmt.Errorf("error in user validation: %w", err)
 	}
 
-	if !userExists {
-		return models.MessageResponse{}, http.StatusBadRequest, fmt.Errorf("user does not exists")
+	// messages of shadow-banned users are created as usual and hidden from everybody else when listed
+	err = checkAccountStatus(userAccess.Status, userAccess.SuspendedUntil)
+	if err != nil {
+		return models.MessageResponse{}, err
 	}
 
 	messageText := messageStruct.Body
 
-	valid := validateMessageText(&messageText)
+	valid := validateMessageText(messageText)
 
 	if !valid {
-		return models.MessageResponse{}, http.StatusBadRequest, fmt.Errorf("message is not valid")
+		return models.MessageResponse{}, validationError("message is not valid")
+	}
+
+	filterResult := messageServ.ApiConfig.ContentFilter.Check(messageText)
+	if filterResult.Rejected {
+		return models.MessageResponse{}, validationError("message contains prohibited content")
+	}
+	// masks can be longer than the masked words, the stored text has to fit the limit too
+	if !validateMessageText(filterResult.Text) {
+		return models.MessageResponse{}, validationError("message is too long after masking prohibited words")
 	}
 
-	dbMessage, err := messageServ.ApiConfig.Queries.CreateMessage(ctx, database.CreateMessageParams{Body: messageText, UserID: userId})
+	messageParams := database.CreateMessageParams{Body: filterResult.Text, UserID: userId}
+	var dbMessage database.Message
+	if filterResult.Flagged {
+		// flagged message and its report are written together, so no flagged message misses the moderation queue
+		err = messageServ.ApiConfig.InRepositoriesTx(ctx, func(repos repository.Repositories) error {
+			dbMessage, err = repos.Messages.CreateMessage(ctx, messageParams)
+			if err != nil {
+				return fmt.Errorf("cannot create message: %w", err)
+			}
+			return flagMessage(ctx, repos.Reports, dbMessage, filterResult.Matched)
+		})
+	} else {
+		dbMessage, err = messageServ.messages.CreateMessage(ctx, messageParams)
+		if err != nil {
+			err = fmt.Errorf("cannot create message: %w", err)
+		}
+	}
 	if err != nil {
-		return models.MessageResponse{}, http.StatusInternalServerError, fmt.Errorf("cannot create message: %s", err)
+		return models.MessageResponse{}, err
 	}
+
+	messageServ.ApiConfig.Metrics.MessagesCreated.Inc()
 	responseMessage := converDbToMessage(dbMessage)
-	return responseMessage, http.StatusCreated, nil
+	return responseMessage, nil
 }
 
-func (messageServ *MessageService) DeleteMessage(ctx context.Context, header http.Header, messageID string) (int, error) {
-	token, err := auth.GetBearerToken(header)
+// flagMessage puts message matched by flagging content filter rules into moderation queue
+func flagMessage(ctx context.Context, reports repository.Reports, dbMessage database.Message, matchedRules []contentfilter.Rule) error {
+	var flaggedTerms []string
+	for _, rule := range matchedRules {
+		if rule.Action == contentfilter.ActionFlag {
+			flaggedTerms = append(flaggedTerms, rule.Term)
+		}
+	}
+
+	_, err := reports.CreateReport(ctx, database.CreateReportParams{
+		TargetType:     ReportTargetMessage,
+		ReportedUserID: dbMessage.UserID,
+		MessageID:      uuid.NullUUID{UUID: dbMessage.ID, Valid: true},
+		MessageBody:    sql.NullString{String: dbMessage.Body, Valid: true},
+		Reason:         ReportReasonContentFilter,
+		Details:        "matched content filter rules: " + strings.Join(flaggedTerms, ", "),
+	})
 	if err != nil {
-		return http.StatusUnauthorized, fmt.Errorf("cannot find authentication header: %s", err)
+		return fmt.Errorf("cannot flag message for review: %w", err)
 	}
+	return nil
+}
 
-	userID, err := auth.ValidateJWT(token, messageServ.ApiConfig.JWTSecret)
+func (messageServ *MessageService) DeleteMessage(ctx context.Context, principal auth.Principal, messageID string) error {
+	ctx, span := tracing.Start(ctx, "MessageService.DeleteMessage")
+	defer span.End()
+
+	err := requireScope(principal, auth.ScopeMessagesWrite)
 	if err != nil {
-		return http.StatusUnauthorized, fmt.Errorf("cannot validate JWT: %s", err)
+		return err
 	}
+	userID := principal.UserID
 
 	messageUUID, err := uuid.Parse(messageID)
 	if err != nil {
-		return http.StatusBadRequest, fmt.Errorf("cannot convert message id to uuid: %s", err)
+		return validationError("cannot convert message id to uuid: %s", err)
 	}
 
-	_, err = messageServ.ApiConfig.Queries.GetMessage(ctx, messageUUID)
+	_, err = messageServ.messages.GetMessage(ctx, messageUUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return notFoundError("message not found")
+	}
 	if err != nil {
-		return http.StatusNotFound, fmt.Errorf("this message does not exist: %s", err)
+		return fmt.Errorf("cannot get message: %w", err)
 	}
 
-	dbMessageID, err := messageServ.ApiConfig.Queries.DeleteMessage(ctx, database.DeleteMessageParams{ID: messageUUID, UserID: userID})
-	if err != nil || dbMessageID != messageUUID {
-		return http.StatusForbidden, fmt.Errorf("user cannot delete this message")
+	// only own messages are deleted, so no rows means that the message belongs to someone else
+	dbMessageID, err := messageServ.messages.DeleteMessage(ctx, database.DeleteMessageParams{ID: messageUUID, UserID: userID})
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && dbMessageID != messageUUID) {
+		return forbiddenError("user cannot delete this message")
+	}
+	if err != nil {
+		return fmt.Errorf("cannot delete message: %w", err)
 	}
 
-	return http.StatusNoContent, nil
+	return nil
 }
 
-func validateMessageText(message *string) bool {
+func validateMessageText(message string) bool {
 	const messageMaxLength = 140
-	if len(*message) > messageMaxLength {
-		return false
-	}
-	*message = profanityFix(*message)
-	return true
-}
-
-// checking profanity of sended message and censoring it
-func profanityFix(msg string) string {
-	profanedWords := map[string]struct{}{
-		"kerfuffle": {},
-		"sharbert":  {},
-		"fornax":    {},
-	}
-	splittedString := strings.Split(msg, " ")
-	splittedLoweredString := strings.Split(strings.ToLower(msg), " ")
-	for i, word := range splittedLoweredString {
-		if _, found := profanedWords[word]; found {
-			splittedString[i] = "****"
-		}
-	}
-	return strings.Join(splittedString, " ")
+	return len(message) <= messageMaxLength
 }
 
 func converDbToMessage(dbMessage database.Message) models.MessageResponse {
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/contentfilter"
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/models"
+)
+
+func TestReadingMessagesRequiresScope(t *testing.T) {
+	apiCfg := newTestApiConfig(t)
+	messageServ := NewMessageService(apiCfg, apiCfg.Repositories.Messages, apiCfg.Repositories.Users, apiCfg.Repositories.Reports)
+	ctx := context.Background()
+	author := createTestUser(t, apiCfg, "author@example.com", false)
+	message, err := apiCfg.Queries.CreateMessage(ctx, database.CreateMessageParams{Body: "hello", UserID: author.ID})
+	if err != nil {
+		t.Fatalf("cannot create message: %s", err)
+	}
+
+	tests := []struct {
+		name      string
+		principal auth.Principal
+		allowed   bool
+	}{
+		{"anonymous", auth.Principal{}, true},
+		{"session", auth.Principal{UserID: author.ID, TokenType: auth.TokenTypeSession}, true},
+		{"token with scope", auth.Principal{UserID: author.ID, TokenType: auth.TokenTypePersonal, Scopes: []string{auth.ScopeMessagesRead}}, true},
+		{"token without scope", auth.Principal{UserID: author.ID, TokenType: auth.TokenTypeOAuth, Scopes: []string{auth.ScopeMessagesWrite}}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, getErr := messageServ.GetMessage(ctx, test.principal, message.ID.String())
+			_, listErr := messageServ.GetAllMessages(ctx, test.principal, "", "")
+			if test.allowed {
+				if getErr != nil || listErr != nil {
+					t.Fatalf("reading was rejected: %v, %v", getErr, listErr)
+				}
+				return
+			}
+			requireErrorKind(t, getErr, ErrorForbidden)
+			requireErrorKind(t, listErr, ErrorForbidden)
+		})
+	}
+}
+
+func TestMaskedMessageFitsLengthLimit(t *testing.T) {
+	apiCfg := newTestApiConfig(t)
+	apiCfg.ContentFilter = contentfilter.NewFilter(contentfilter.StaticSource{{Term: "ab", Action: contentfilter.ActionMask}})
+	err := apiCfg.ContentFilter.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("cannot load rules: %s", err)
+	}
+	messageServ := NewMessageService(apiCfg, apiCfg.Repositories.Messages, apiCfg.Repositories.Users, apiCfg.Repositories.Reports)
+	author := createTestUser(t, apiCfg, "author@example.com", false)
+	principal := auth.Principal{UserID: author.ID, TokenType: auth.TokenTypeSession}
+
+	// "ab" is masked with 4 characters, so the message is 2 characters over the limit once masked
+	body := strings.Repeat("x", 137) + " ab"
+	_, err = messageServ.CreateMessage(context.Background(), principal, models.MessageRequest{Body: body})
+	requireErrorKind(t, err, ErrorValidation)
+
+	message, err := messageServ.CreateMessage(context.Background(), principal, models.MessageRequest{Body: body[2:]})
+	if err != nil {
+		t.Fatalf("cannot create message: %s", err)
+	}
+	if len(message.Body) != 140 || !strings.HasSuffix(message.Body, " ****") {
+		t.Fatalf("unexpected message body: %q", message.Body)
+	}
+}
+
+func TestFlaggedMessageIsNotKeptWithoutReport(t *testing.T) {
+	apiCfg := newTestApiConfig(t)
+	apiCfg.ContentFilter = contentfilter.NewFilter(contentfilter.StaticSource{{Term: "buy now", Action: contentfilter.ActionFlag}})
+	err := apiCfg.ContentFilter.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("cannot load rules: %s", err)
+	}
+	messageServ := NewMessageService(apiCfg, apiCfg.Repositories.Messages, apiCfg.Repositories.Users, apiCfg.Repositories.Reports)
+	author := createTestUser(t, apiCfg, "author@example.com", false)
+	principal := auth.Principal{UserID: author.ID, TokenType: auth.TokenTypeSession}
+
+	// reports cannot be written, so the message has to be rolled back
+	_, err = apiCfg.DB.Exec("ALTER TABLE reports RENAME TO unavailable_reports")
+	if err != nil {
+		t.Fatalf("cannot rename reports: %s", err)
+	}
+	_, err = messageServ.CreateMessage(context.Background(), principal, models.MessageRequest{Body: "buy now"})
+	if err == nil {
+		t.Fatalf("flagged message was created without report")
+	}
+
+	messages, err := apiCfg.Queries.GetAllMessagesForAuthor(context.Background(), author.ID)
+	if err != nil {
+		t.Fatalf("cannot get messages: %s", err)
+	}
+	if len(messages) != 0 {
+		t.Fatalf("flagged message was kept without report: %+v", messages)
+	}
+}
+
+func TestDefaultContentFilterRulesInDemoMode(t *testing.T) {
+	apiCfg := newDemoTestApiConfig(t)
+	err := apiCfg.ContentFilter.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("cannot load rules: %s", err)
+	}
+	messageServ := NewMessageService(apiCfg, apiCfg.Repositories.Messages, apiCfg.Repositories.Users, apiCfg.Repositories.Reports)
+	author := createDemoUser(t, newTestUserService(apiCfg), "author@example.com")
+
+	message, err := messageServ.CreateMessage(context.Background(), author, models.MessageRequest{Body: "what a kerfuffle"})
+	if err != nil {
+		t.Fatalf("cannot create message: %s", err)
+	}
+	if message.Body != "what a ****" {
+		t.Fatalf("default rules are not applied in demo mode: %q", message.Body)
+	}
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/models"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/tracing"
+	"github.com/google/uuid"
+)
+
+const (
+	ReportStatusOpen     = "open"
+	ReportStatusClaimed  = "claimed"
+	ReportStatusResolved = "resolved"
+
+	ModerationActionClaim         = "claim"
+	ModerationActionDismiss       = "dismiss"
+	ModerationActionDeleteMessage = "delete_message"
+	ModerationActionSuspendUser   = "suspend_user"
+	ModerationActionChangeStatus  = "change_status"
+
+	defaultSuspendDays   = 7
+	maxSuspendDays       = 365
+	defaultReportsLimit  = 50
+	maxReportsLimit      = 500
+	maxModerationNoteLen = 1000
+)
+
+var reportResolutions = []string{ModerationActionDismiss, ModerationActionDeleteMessage, ModerationActionSuspendUser}
+
+// ModerationService serves the moderation queue, every moderator decision is written to moderation_actions.
+// Decisions change reports, messages and users in one transaction, so the queue is kept in the database only.
+type ModerationService struct {
+	ApiConfig *config.ApiConfig
+	queries   database.Querier
+}
+
+func NewModerationService(apiConfig *config.ApiConfig, queries database.Querier) *ModerationService {
+	return &ModerationService{ApiConfig: apiConfig, queries: queries}
+}
+
+func (moderationServ *ModerationService) GetReports(ctx context.Context, principal auth.Principal, status, limit string) ([]models.ReportResponse, error) {
+	ctx, span := tracing.Start(ctx, "ModerationService.GetReports")
+	defer span.End()
+
+	err := requireAdmin(principal)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == "" {
+		status = ReportStatusOpen
+	}
+	if !slices.Contains([]string{ReportStatusOpen, ReportStatusClaimed, ReportStatusResolved}, status) {
+		return nil, validationError("status must be open, claimed or resolved")
+	}
+	reportsLimit, err := parseLimit(limit, defaultReportsLimit, maxReportsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	dbReports, err := moderationServ.queries.GetReportsByStatus(ctx, database.GetReportsByStatusParams{Status: status, Limit: int32(reportsLimit)})
+	if err != nil {
+		return nil, fmt.Errorf("cannot get reports: %w", err)
+	}
+
+	responseReports := make([]models.ReportResponse, len(dbReports))
+	for i, report := range dbReports {
+		responseReports[i] = convertDBToReport(report)
+	}
+	return responseReports, nil
+}
+
+// ClaimReport assigns open report to the moderator, so that two moderators do not review the same report
+func (moderationServ *ModerationService) ClaimReport(ctx context.Context, principal auth.Principal, reportID string) (models.ReportResponse, error) {
+	ctx, span := tracing.Start(ctx, "ModerationService.ClaimReport")
+	defer span.End()
+
+	err := requireAdmin(principal)
+	if err != nil {
+		return models.ReportResponse{}, err
+	}
+
+	dbReport, err := moderationServ.getReport(ctx, reportID)
+	if err != nil {
+		return models.ReportResponse{}, err
+	}
+	if dbReport.Status != ReportStatusOpen {
+		return models.ReportResponse{}, conflictError("report is already %s", dbReport.Status)
+	}
+
+	err = moderationServ.ApiConfig.InTx(ctx, func(queries database.Querier) error {
+		dbReport, err = queries.ClaimReport(ctx, database.ClaimReportParams{ID: dbReport.ID, ModeratorID: principal.UserID})
+		if errors.Is(err, repository.ErrNotFound) {
+			return conflictError("report was claimed by another moderator")
+		}
+		if err != nil {
+			return fmt.Errorf("cannot claim report: %w", err)
+		}
+		return recordAction(ctx, queries, principal, dbReport, ModerationActionClaim, "")
+	})
+	if err != nil {
+		return models.ReportResponse{}, err
+	}
+	return convertDBToReport(dbReport), nil
+}
+
+// ResolveReport applies moderator's decision to the report claimed by that moderator. The report is resolved,
+// the decision is applied and recorded in one transaction, so two requests cannot apply decisions to the same report.
+func (moderationServ *ModerationService) ResolveReport(ctx context.Context, principal auth.Principal, reportID string, resolveRequest models.ResolveReportRequest) (models.ReportResponse, error) {
+	ctx, span := tracing.Start(ctx, "ModerationService.ResolveReport")
+	defer span.End()
+
+	err := requireAdmin(principal)
+	if err != nil {
+		return models.ReportResponse{}, err
+	}
+
+	if !slices.Contains(reportResolutions, resolveRequest.Action) {
+		return models.ReportResponse{}, validationError("action must be one of %v", reportResolutions)
+	}
+	if len(resolveRequest.Note) > maxModerationNoteLen {
+		return models.ReportResponse{}, validationError("note must be at most %d characters", maxModerationNoteLen)
+	}
+
+	dbReport, err := moderationServ.getReport(ctx, reportID)
+	if err != nil {
+		return models.ReportResponse{}, err
+	}
+	if dbReport.Status != ReportStatusClaimed || dbReport.ClaimedBy.UUID != principal.UserID {
+		return models.ReportResponse{}, conflictError("report must be claimed by you before it is resolved")
+	}
+
+	err = moderationServ.ApiConfig.InTx(ctx, func(queries database.Querier) error {
+		// the report is resolved only while it is still claimed by the moderator, it also locks the report row
+		dbReport, err = queries.ResolveReport(ctx, database.ResolveReportParams{
+			ID:          dbReport.ID,
+			ModeratorID: principal.UserID,
+			Resolution:  resolveRequest.Action,
+		})
+		if errors.Is(err, repository.ErrNotFound) {
+			return conflictError("report was resolved by another request")
+		}
+		if err != nil {
+			return fmt.Errorf("cannot resolve report: %w", err)
+		}
+
+		switch resolveRequest.Action {
+		case ModerationActionDeleteMessage:
+			err = deleteReportedMessage(ctx, queries, dbReport)
+		case ModerationActionSuspendUser:
+			err = suspendReportedUser(ctx, queries, dbReport, resolveRequest.SuspendDays)
+		}
+		if err != nil {
+			return err
+		}
+
+		return recordAction(ctx, queries, principal, dbReport, resolveRequest.Action, resolveRequest.Note)
+	})
+	if err != nil {
+		return models.ReportResponse{}, err
+	}
+	return convertDBToReport(dbReport), nil
+}
+
+// GetModerationActions returns audit trail of moderator decisions, either latest ones or for specific report
+func (moderationServ *ModerationService) GetModerationActions(ctx context.Context, principal auth.Principal, reportID, limit string) ([]models.ModerationActionResponse, error) {
+	ctx, span := tracing.Start(ctx, "ModerationService.GetModerationActions")
+	defer span.End()
+
+	err := requireAdmin(principal)
+	if err != nil {
+		return nil, err
+	}
+
+	var dbActions []database.ModerationAction
+	if reportID != "" {
+		reportUUID, err := uuid.Parse(reportID)
+		if err != nil {
+			return nil, validationError("cannot convert report id to uuid: %s", err)
+		}
+		dbActions, err = moderationServ.queries.GetModerationActionsForReport(ctx, uuid.NullUUID{UUID: reportUUID, Valid: true})
+		if err != nil {
+			return nil, fmt.Errorf("cannot get moderation actions: %w", err)
+		}
+	} else {
+		actionsLimit, err := parseLimit(limit, defaultReportsLimit, maxReportsLimit)
+		if err != nil {
+			return nil, err
+		}
+		dbActions, err = moderationServ.queries.GetModerationActions(ctx, int32(actionsLimit))
+		if err != nil {
+			return nil, fmt.Errorf("cannot get moderation actions: %w", err)
+		}
+	}
+
+	responseActions := make([]models.ModerationActionResponse, len(dbActions))
+	for i, action := range dbActions {
+		responseActions[i] = models.ModerationActionResponse{
+			ID:           action.ID,
+			CreatedAt:    action.CreatedAt,
+			ModeratorID:  action.ModeratorID,
+			ReportID:     action.ReportID,
+			Action:       action.Action,
+			TargetUserID: action.TargetUserID,
+			MessageID:    action.MessageID,
+			Note:         action.Note,
+		}
+	}
+	return responseActions, nil
+}
+
+func deleteReportedMessage(ctx context.Context, queries database.Querier, dbReport database.Report) error {
+	if dbReport.TargetType != ReportTargetMessage {
+		return validationError("only message reports can be resolved by deleting the message")
+	}
+	if !dbReport.MessageID.Valid {
+		return conflictError("message was already deleted")
+	}
+
+	_, err := queries.DeleteMessage(ctx, database.DeleteMessageParams{ID: dbReport.MessageID.UUID, UserID: dbReport.ReportedUserID})
+	if errors.Is(err, repository.ErrNotFound) {
+		return conflictError("message was already deleted")
+	}
+	if err != nil {
+		return fmt.Errorf("cannot delete message: %w", err)
+	}
+	return nil
+}
+
+func suspendReportedUser(ctx context.Context, queries database.Querier, dbReport database.Report, suspendDays int) error {
+	suspendedUntil, err := suspensionEnd(suspendDays)
+	if err != nil {
+		return wrapError(ErrorValidation, err)
+	}
+
+	userAccess, err := queries.GetUserAccess(ctx, dbReport.ReportedUserID)
+	if err != nil {
+		return fmt.Errorf("cannot get user: %w", err)
+	}
+	if userAccess.IsAdmin {
+		return conflictError("admins cannot be suspended")
+	}
+
+	reason := fmt.Sprintf("report %s: %s", dbReport.ID, dbReport.Reason)
+	_, err = setUserStatus(ctx, queries, dbReport.ReportedUserID, AccountStatusSuspended, reason, suspendedUntil)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func recordAction(ctx context.Context, queries database.Querier, principal auth.Principal, dbReport database.Report, action, note string) error {
+	err := queries.CreateModerationAction(ctx, database.CreateModerationActionParams{
+		ModeratorID:  uuid.NullUUID{UUID: principal.UserID, Valid: true},
+		ReportID:     uuid.NullUUID{UUID: dbReport.ID, Valid: true},
+		Action:       action,
+		TargetUserID: uuid.NullUUID{UUID: dbReport.ReportedUserID, Valid: true},
+		MessageID:    dbReport.MessageID,
+		Note:         note,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot record moderation action: %w", err)
+	}
+	return nil
+}
+
+func (moderationServ *ModerationService) getReport(ctx context.Context, reportID string) (database.Report, error) {
+	reportUUID, err := uuid.Parse(reportID)
+	if err != nil {
+		return database.Report{}, validationError("cannot convert report id to uuid: %s", err)
+	}
+
+	dbReport, err := moderationServ.queries.GetReport(ctx, reportUUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return database.Report{}, notFoundError("report not found")
+	}
+	if err != nil {
+		return database.Report{}, fmt.Errorf("cannot get report: %w", err)
+	}
+	return dbReport, nil
+}
+
+func parseLimit(limit string, defaultLimit, maxLimit int) (int, error) {
+	if limit == "" {
+		return defaultLimit, nil
+	}
+	parsedLimit, err := strconv.Atoi(limit)
+	if err != nil || parsedLimit <= 0 || parsedLimit > maxLimit {
+		return 0, validationError("limit must be a number between 1 and %d", maxLimit)
+	}
+	return parsedLimit, nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/models"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/google/uuid"
+)
+
+// createTestUser creates user that cannot log in, admins get the admin role in the database
+func createTestUser(t *testing.T, apiCfg *config.ApiConfig, email string, isAdmin bool) database.User {
+	t.Helper()
+	dbUser, err := apiCfg.Queries.CreateUser(context.Background(), database.CreateUserParams{Email: email, HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+	if isAdmin {
+		_, err = apiCfg.DB.Exec("UPDATE users SET is_admin = true WHERE id = ?", dbUser.ID)
+		if err != nil {
+			t.Fatalf("cannot make user admin: %s", err)
+		}
+	}
+	return dbUser
+}
+
+// claimedMessageReport returns report of author's message claimed by moderator
+func claimedMessageReport(t *testing.T, moderationServ *ModerationService, moderator auth.Principal, author database.User) database.Report {
+	t.Helper()
+	ctx := context.Background()
+	message, err := moderationServ.ApiConfig.Queries.CreateMessage(ctx, database.CreateMessageParams{Body: "reported", UserID: author.ID})
+	if err != nil {
+		t.Fatalf("cannot create message: %s", err)
+	}
+	report, err := moderationServ.ApiConfig.Queries.CreateReport(ctx, database.CreateReportParams{
+		TargetType:     ReportTargetMessage,
+		ReportedUserID: author.ID,
+		MessageID:      uuid.NullUUID{UUID: message.ID, Valid: true},
+		Reason:         "spam",
+	})
+	if err != nil {
+		t.Fatalf("cannot create report: %s", err)
+	}
+	_, err = moderationServ.ClaimReport(ctx, moderator, report.ID.String())
+	if err != nil {
+		t.Fatalf("cannot claim report: %s", err)
+	}
+	return report
+}
+
+func TestResolveReportAppliesDecisionOnce(t *testing.T) {
+	apiCfg := newTestApiConfig(t)
+	moderationServ := NewModerationService(apiCfg, apiCfg.Queries)
+	ctx := context.Background()
+	admin := createTestUser(t, apiCfg, "admin@example.com", true)
+	moderator := auth.Principal{UserID: admin.ID, Roles: []string{auth.RoleAdmin}, TokenType: auth.TokenTypeSession}
+	author := createTestUser(t, apiCfg, "author@example.com", false)
+	report := claimedMessageReport(t, moderationServ, moderator, author)
+
+	resolved, err := moderationServ.ResolveReport(ctx, moderator, report.ID.String(), models.ResolveReportRequest{Action: ModerationActionDeleteMessage})
+	if err != nil {
+		t.Fatalf("cannot resolve report: %s", err)
+	}
+	if resolved.Status != ReportStatusResolved {
+		t.Fatalf("report is %s, want %s", resolved.Status, ReportStatusResolved)
+	}
+	_, err = apiCfg.Queries.GetMessage(ctx, report.MessageID.UUID)
+	if !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("reported message was not deleted: %v", err)
+	}
+
+	_, err = moderationServ.ResolveReport(ctx, moderator, report.ID.String(), models.ResolveReportRequest{Action: ModerationActionSuspendUser})
+	requireErrorKind(t, err, ErrorConflict)
+	access, err := apiCfg.Queries.GetUserAccess(ctx, author.ID)
+	if err != nil {
+		t.Fatalf("cannot get user: %s", err)
+	}
+	if access.Status != AccountStatusActive {
+		t.Fatalf("second resolution was applied, author is %s", access.Status)
+	}
+
+	actions, err := apiCfg.Queries.GetModerationActionsForReport(ctx, uuid.NullUUID{UUID: report.ID, Valid: true})
+	if err != nil {
+		t.Fatalf("cannot get moderation actions: %s", err)
+	}
+	if len(actions) != 2 {
+		t.Fatalf("got %d moderation actions, want claim and resolution", len(actions))
+	}
+}
+
+func TestResolveReportRollsBackFailedDecision(t *testing.T) {
+	apiCfg := newTestApiConfig(t)
+	moderationServ := NewModerationService(apiCfg, apiCfg.Queries)
+	ctx := context.Background()
+	admin := createTestUser(t, apiCfg, "admin@example.com", true)
+	moderator := auth.Principal{UserID: admin.ID, Roles: []string{auth.RoleAdmin}, TokenType: auth.TokenTypeSession}
+	otherAdmin := createTestUser(t, apiCfg, "other-admin@example.com", true)
+	report := claimedMessageReport(t, moderationServ, moderator, otherAdmin)
+
+	_, err := moderationServ.ResolveReport(ctx, moderator, report.ID.String(), models.ResolveReportRequest{Action: ModerationActionSuspendUser})
+	requireErrorKind(t, err, ErrorConflict)
+
+	dbReport, err := apiCfg.Queries.GetReport(ctx, report.ID)
+	if err != nil {
+		t.Fatalf("cannot get report: %s", err)
+	}
+	if dbReport.Status != ReportStatusClaimed {
+		t.Fatalf("report is %s after failed decision, want %s", dbReport.Status, ReportStatusClaimed)
+	}
+	actions, err := apiCfg.Queries.GetModerationActionsForReport(ctx, uuid.NullUUID{UUID: report.ID, Valid: true})
+	if err != nil {
+		t.Fatalf("cannot get moderation actions: %s", err)
+	}
+	if len(actions) != 1 || actions[0].Action != ModerationActionClaim {
+		t.Fatalf("failed decision was recorded: %+v", actions)
+	}
+
+	_, err = moderationServ.ResolveReport(ctx, moderator, report.ID.String(), models.ResolveReportRequest{Action: ModerationActionDismiss})
+	if err != nil {
+		t.Fatalf("report cannot be resolved after failed decision: %s", err)
+	}
+}
+
+func TestSetUserStatusRecordsAction(t *testing.T) {
+	apiCfg := newTestApiConfig(t)
+	statusServ := NewAccountStatusService(apiCfg, apiCfg.Repositories.Users)
+	ctx := context.Background()
+	admin := createTestUser(t, apiCfg, "admin@example.com", true)
+	moderator := auth.Principal{UserID: admin.ID, Roles: []string{auth.RoleAdmin}, TokenType: auth.TokenTypeSession}
+	user := createTestUser(t, apiCfg, "user@example.com", false)
+
+	status, err := statusServ.SetUserStatus(ctx, moderator, user.ID.String(), models.UserStatusRequest{Status: AccountStatusBanned, Reason: "spam"})
+	if err != nil {
+		t.Fatalf("cannot set status: %s", err)
+	}
+	if status.Status != AccountStatusBanned {
+		t.Fatalf("status is %s, want %s", status.Status, AccountStatusBanned)
+	}
+
+	actions, err := apiCfg.Queries.GetModerationActions(ctx, 10)
+	if err != nil {
+		t.Fatalf("cannot get moderation actions: %s", err)
+	}
+	if len(actions) != 1 || actions[0].Action != ModerationActionChangeStatus || actions[0].TargetUserID.UUID != user.ID {
+		t.Fatalf("unexpected moderation actions: %+v", actions)
+	}
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/models"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/tracing"
+	"github.com/google/uuid"
+)
+
+const (
+	oauthCodeTTL       = 10 * time.Minute
+	maxOAuthClientName = 100
+)
+
+// OAuthError is an error of the token endpoint in the format defined by RFC 6749
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (oauthErr *OAuthError) Error() string {
+	return fmt.Sprintf("%s: %s", oauthErr.Code, oauthErr.Description)
+}
+
+// OAuthService is the authorization server for third-party clients, clients and authorization codes
+// are kept in the database only
+type OAuthService struct {
+	ApiConfig *config.ApiConfig
+	queries   database.Querier
+}
+
+func NewOAuthService(apiConfig *config.ApiConfig, queries database.Querier) *OAuthService {
+	return &OAuthService{ApiConfig: apiConfig, queries: queries}
+}
+
+func (oauthServ *OAuthService) RegisterClient(ctx context.Context, principal auth.Principal, clientRequest models.OAuthClientRequest) (models.OAuthClientResponse, error) {
+	ctx, span := tracing.Start(ctx, "OAuthService.RegisterClient")
+	defer span.End()
+
+	err := requireFirstParty(principal)
+	if err != nil {
+		return models.OAuthClientResponse{}, err
+	}
+
+	if clientRequest.Name == "" || len(clientRequest.Name) > maxOAuthClientName {
+		return models.OAuthClientResponse{}, validationError("client name must be between 1 and %d characters", maxOAuthClientName)
+	}
+	if len(clientRequest.RedirectURIs) == 0 {
+		return models.OAuthClientResponse{}, validationError("at least one redirect uri is required")
+	}
+	for _, redirectURI := range clientRequest.RedirectURIs {
+		err = validateRedirectURI(redirectURI)
+		if err != nil {
+			return models.OAuthClientResponse{}, wrapError(ErrorValidation, err)
+		}
+	}
+	if len(clientRequest.Scopes) == 0 {
+		return models.OAuthClientResponse{}, validationError("at least one scope is required")
+	}
+	for _, scope := range clientRequest.Scopes {
+		if !slices.Contains(auth.Scopes, scope) {
+			return models.OAuthClientResponse{}, validationError("unknown scope: %s", scope)
+		}
+	}
+
+	secret := ""
+	secretHash := sql.NullString{}
+	if clientRequest.Confidential {
+		secret, err = auth.MakeRefreshToken()
+		if err != nil {
+			return models.OAuthClientResponse{}, fmt.Errorf("cannot generate client secret: %w", err)
+		}
+		secretHash = sql.NullString{String: auth.HashToken(secret), Valid: true}
+	}
+
+	dbClient, err := oauthServ.queries.CreateOAuthClient(ctx, database.CreateOAuthClientParams{
+		ID:           uuid.NewString(),
+		Name:         clientRequest.Name,
+		SecretHash:   secretHash,
+		RedirectUris: clientRequest.RedirectURIs,
+		Scopes:       clientRequest.Scopes,
+		UserID:       principal.UserID,
+	})
+	if err != nil {
+		return models.OAuthClientResponse{}, fmt.Errorf("cannot create client: %w", err)
+	}
+
+	responseClient := convertDBToOAuthClient(dbClient)
+	responseClient.Secret = secret
+	return responseClient, nil
+}
+
+func (oauthServ *OAuthService) GetClientsForUser(ctx context.Context, principal auth.Principal) ([]models.OAuthClientResponse, error) {
+	ctx, span := tracing.Start(ctx, "OAuthService.GetClientsForUser")
+	defer span.End()
+
+	err := requireFirstParty(principal)
+	if err != nil {
+		return nil, err
+	}
+
+	dbClients, err := oauthServ.queries.GetOAuthClientsForUser(ctx, principal.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get clients: %w", err)
+	}
+
+	responseClients := make([]models.OAuthClientResponse, len(dbClients))
+	for i, client := range dbClients {
+		responseClients[i] = convertDBToOAuthClient(client)
+	}
+	return responseClients, nil
+}
+
+// GetClient returns public information about the client shown on the consent screen
+func (oauthServ *OAuthService) GetClient(ctx context.Context, clientID string) (models.OAuthClientResponse, error) {
+	ctx, span := tracing.Start(ctx, "OAuthService.GetClient")
+	defer span.End()
+
+	dbClient, err := oauthServ.queries.GetOAuthClient(ctx, clientID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.OAuthClientResponse{}, notFoundError("client not found")
+	}
+	if err != nil {
+		return models.OAuthClientResponse{}, fmt.Errorf("cannot get client: %w", err)
+	}
+	return convertDBToOAuthClient(dbClient), nil
+}
+
+func (oauthServ *OAuthService) DeleteClient(ctx context.Context, principal auth.Principal, clientID string) error {
+	ctx, span := tracing.Start(ctx, "OAuthService.DeleteClient")
+	defer span.End()
+
+	err := requireFirstParty(principal)
+	if err != nil {
+		return err
+	}
+
+	_, err = oauthServ.queries.DeleteOAuthClient(ctx, database.DeleteOAuthClientParams{ID: clientID, UserID: principal.UserID})
+	if errors.Is(err, repository.ErrNotFound) {
+		return notFoundError("client not found")
+	}
+	if err != nil {
+		return fmt.Errorf("cannot delete client: %w", err)
+	}
+	return nil
+}
+
+// ValidateAuthorizeRequest checks the authorization request before the consent screen is shown.
+// Errors here must not be redirected to the client since its redirect uri cannot be trusted yet.
+func (oauthServ *OAuthService) ValidateAuthorizeRequest(ctx context.Context, authRequest models.OAuthAuthorizeRequest) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "OAuthService.ValidateAuthorizeRequest")
+	defer span.End()
+
+	dbClient, err := oauthServ.queries.GetOAuthClient(ctx, authRequest.ClientID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, validationError("unknown client")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get client: %w", err)
+	}
+
+	if !slices.Contains(dbClient.RedirectUris, authRequest.RedirectURI) {
+		return nil, validationError("redirect uri is not registered for the client")
+	}
+	if authRequest.ResponseType != "code" {
+		return nil, validationError("only code response type is supported")
+	}
+	if authRequest.CodeChallenge == "" || authRequest.CodeChallengeMethod != "S256" {
+		return nil, validationError("PKCE with S256 code challenge is required")
+	}
+
+	scopes := strings.Fields(authRequest.Scope)
+	if len(scopes) == 0 {
+		scopes = dbClient.Scopes
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(dbClient.Scopes, scope) {
+			return nil, validationError("scope %s is not allowed for the client", scope)
+		}
+	}
+	return scopes, nil
+}
+
+// Authorize records the user's decision on the consent screen and returns where to redirect the user
+func (oauthServ *OAuthService) Authorize(ctx context.Context, principal auth.Principal, authRequest models.OAuthAuthorizeRequest) (models.OAuthAuthorizeResponse, error) {
+	ctx, span := tracing.Start(ctx, "OAuthService.Authorize")
+	defer span.End()
+
+	err := requireFirstParty(principal)
+	if err != nil {
+		return models.OAuthAuthorizeResponse{}, err
+	}
+
+	scopes, err := oauthServ.ValidateAuthorizeRequest(ctx, authRequest)
+	if err != nil {
+		return models.OAuthAuthorizeResponse{}, err
+	}
+
+	redirectParams := url.Values{}
+	if authRequest.State != "" {
+		redirectParams.Set("state", authRequest.State)
+	}
+
+	if !authRequest.Approve {
+		redirectParams.Set("error", "access_denied")
+		return models.OAuthAuthorizeResponse{RedirectURI: appendQuery(authRequest.RedirectURI, redirectParams)}, nil
+	}
+
+	err = oauthServ.queries.DeleteExpiredOAuthAuthorizationCodes(ctx)
+	if err != nil {
+		return models.OAuthAuthorizeResponse{}, fmt.Errorf("cannot clean up authorization codes: %w", err)
+	}
+
+	code, err := auth.MakeRefreshToken()
+	if err != nil {
+		return models.OAuthAuthorizeResponse{}, fmt.Errorf("cannot generate authorization code: %w", err)
+	}
+
+	err = oauthServ.queries.CreateOAuthAuthorizationCode(ctx, database.CreateOAuthAuthorizationCodeParams{
+		CodeHash:      auth.HashToken(code),
+		ClientID:      authRequest.ClientID,
+		UserID:        principal.UserID,
+		RedirectUri:   authRequest.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: authRequest.CodeChallenge,
+		ExpiresAt:     time.Now().Add(oauthCodeTTL),
+	})
+	if err != nil {
+		return models.OAuthAuthorizeResponse{}, fmt.Errorf("cannot save authorization code: %w", err)
+	}
+
+	redirectParams.Set("code", code)
+	return models.OAuthAuthorizeResponse{RedirectURI: appendQuery(authRequest.RedirectURI, redirectParams)}, nil
+}
+
+// ExchangeCode is the token endpoint of the authorization code grant
+func (oauthServ *OAuthService) ExchangeCode(ctx context.Context, tokenRequest models.OAuthTokenRequest) (models.OAuthTokenResponse, error) {
+	ctx, span := tracing.Start(ctx, "OAuthService.ExchangeCode")
+	defer span.End()
+
+	if tokenRequest.GrantType != "authorization_code" {
+		return models.OAuthTokenResponse{}, wrapError(ErrorValidation, &OAuthError{Code: "unsupported_grant_type", Description: "only authorization_code grant is supported"})
+	}
+
+	dbClient, err := oauthServ.queries.GetOAuthClient(ctx, tokenRequest.ClientID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.OAuthTokenResponse{}, wrapError(ErrorUnauthenticated, &OAuthError{Code: "invalid_client", Description: "unknown client"})
+	}
+	if err != nil {
+		return models.OAuthTokenResponse{}, fmt.Errorf("cannot get client: %w", err)
+	}
+	if dbClient.SecretHash.Valid {
+		secretHash := auth.HashToken(tokenRequest.ClientSecret)
+		if subtle.ConstantTimeCompare([]byte(secretHash), []byte(dbClient.SecretHash.String)) != 1 {
+			return models.OAuthTokenResponse{}, wrapError(ErrorUnauthenticated, &OAuthError{Code: "invalid_client", Description: "wrong client secret"})
+		}
+	}
+
+	dbCode, err := oauthServ.queries.ConsumeOAuthAuthorizationCode(ctx, auth.HashToken(tokenRequest.Code))
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.OAuthTokenResponse{}, wrapError(ErrorValidation, &OAuthError{Code: "invalid_grant", Description: "unknown or expired authorization code"})
+	}
+	if err != nil {
+		return models.OAuthTokenResponse{}, fmt.Errorf("cannot get authorization code: %w", err)
+	}
+	if dbCode.ClientID != dbClient.ID || dbCode.RedirectUri != tokenRequest.RedirectURI {
+		return models.OAuthTokenResponse{}, wrapError(ErrorValidation, &OAuthError{Code: "invalid_grant", Description: "authorization code was issued for another client or redirect uri"})
+	}
+	if auth.MakePKCEChallenge(tokenRequest.CodeVerifier) != dbCode.CodeChallenge {
+		return models.OAuthTokenResponse{}, wrapError(ErrorValidation, &OAuthError{Code: "invalid_grant", Description: "code verifier does not match code challenge"})
+	}
+
+	accessToken, err := auth.MakeClientJWT(dbCode.UserID, dbClient.ID, dbCode.Scopes, oauthServ.ApiConfig.JWTSecret, oauthServ.ApiConfig.AccessTokenTTL)
+	if err != nil {
+		return models.OAuthTokenResponse{}, fmt.Errorf("error in token creation: %w", err)
+	}
+
+	return models.OAuthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(oauthServ.ApiConfig.AccessTokenTTL.Seconds()),
+		Scope:       strings.Join(dbCode.Scopes, " "),
+	}, nil
+}
+
+// validateRedirectURI allows only absolute https uris without fragment, plain http is allowed for localhost
+func validateRedirectURI(redirectURI string) error {
+	parsedURI, err := url.Parse(redirectURI)
+	if err != nil || !parsedURI.IsAbs() || parsedURI.Host == "" {
+		return fmt.Errorf("redirect uri must be absolute: %s", redirectURI)
+	}
+	if parsedURI.Fragment != "" {
+		return fmt.Errorf("redirect uri must not contain fragment: %s", redirectURI)
+	}
+	isLocal := parsedURI.Hostname() == "localhost" || parsedURI.Hostname() == "127.0.0.1"
+	if parsedURI.Scheme != "https" && !(parsedURI.Scheme == "http" && isLocal) {
+		return fmt.Errorf("redirect uri must use https: %s", redirectURI)
+	}
+	return nil
+}
+
+func appendQuery(rawURL string, params url.Values) string {
+	parsedURL, _ := url.Parse(rawURL)
+	query := parsedURL.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	parsedURL.RawQuery = query.Encode()
+	return parsedURL.String()
+}
+
+func convertDBToOAuthClient(dbClient database.OauthClient) models.OAuthClientResponse {
+	return models.OAuthClientResponse{
+		ID:           dbClient.ID,
+		CreatedAt:    dbClient.CreatedAt,
+		Name:         dbClient.Name,
+		Confidential: dbClient.SecretHash.Valid,
+		RedirectURIs: dbClient.RedirectUris,
+		Scopes:       dbClient.Scopes,
+	}
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/models"
+)
+
+func TestDeletedClientTokensAreRejected(t *testing.T) {
+	apiCfg := newTestApiConfig(t)
+	repos := apiCfg.Repositories
+	oauthServ := NewOAuthService(apiCfg, apiCfg.Queries)
+	authServ := NewAuthService(apiCfg, repos.Users, repos.PersonalAccessTokens, apiCfg.Queries)
+	ctx := context.Background()
+	user := createDemoUser(t, newTestUserService(apiCfg), "user@example.com")
+
+	client, err := oauthServ.RegisterClient(ctx, user, models.OAuthClientRequest{Name: "app", RedirectURIs: []string{"https://app.example.com/callback"}, Scopes: []string{auth.ScopeMessagesRead}})
+	if err != nil {
+		t.Fatalf("cannot register client: %s", err)
+	}
+	token, err := auth.MakeClientJWT(user.UserID, client.ID, []string{auth.ScopeMessagesRead}, apiCfg.JWTSecret, time.Hour)
+	if err != nil {
+		t.Fatalf("cannot create token: %s", err)
+	}
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+
+	principal, err := authServ.Authenticate(ctx, header)
+	if err != nil {
+		t.Fatalf("cannot authenticate with token: %s", err)
+	}
+	if principal.TokenType != auth.TokenTypeOAuth || principal.ClientID != client.ID {
+		t.Fatalf("unexpected principal: %+v", principal)
+	}
+
+	err = oauthServ.DeleteClient(ctx, user, client.ID)
+	if err != nil {
+		t.Fatalf("cannot delete client: %s", err)
+	}
+	_, err = authServ.Authenticate(ctx, header)
+	requireErrorKind(t, err, ErrorUnauthenticated)
+}
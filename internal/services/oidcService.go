@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/models"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/tracing"
+	"github.com/google/uuid"
+)
+
+const (
+	oidcAuthRequestTTL = 10 * time.Minute
+	// password hash of accounts created through social login, it never matches any password
+	unsetPasswordHash = "unset"
+)
+
+// OIDCService logs users in with external providers. Auth requests and linked identities are kept in the database
+// only, accounts are read through the users repository and sessions are created by UserService.
+type OIDCService struct {
+	ApiConfig *config.ApiConfig
+	queries   database.Querier
+	users     repository.Users
+	userServ  *UserService
+}
+
+func NewOIDCService(apiConfig *config.ApiConfig, queries database.Querier, users repository.Users, userServ *UserService) *OIDCService {
+	return &OIDCService{ApiConfig: apiConfig, queries: queries, users: users, userServ: userServ}
+}
+
+// StartAuth prepares authorization request and returns provider's url the client should be redirected to
+// together with the state, the caller has to bind the state to the browser (see auth.HashOIDCState).
+// If the caller is authenticated, the provider identity will be linked to that user instead of logging in.
+func (oidcServ *OIDCService) StartAuth(ctx context.Context, principal auth.Principal, providerName string) (string, string, error) {
+	ctx, span := tracing.Start(ctx, "OIDCService.StartAuth")
+	defer span.End()
+
+	provider, ok := oidcServ.ApiConfig.OIDCProviders[providerName]
+	if !ok {
+		return "", "", notFoundError("unknown provider: %s", providerName)
+	}
+
+	linkUserID := uuid.NullUUID{}
+	if principal.IsAuthenticated() {
+		err := requireFirstParty(principal)
+		if err != nil {
+			return "", "", err
+		}
+		linkUserID = uuid.NullUUID{UUID: principal.UserID, Valid: true}
+	}
+
+	err := oidcServ.queries.DeleteExpiredOIDCAuthRequests(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot clean up auth requests: %w", err)
+	}
+
+	state, err := auth.MakeOIDCSecret()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := auth.MakeOIDCSecret()
+	if err != nil {
+		return "", "", err
+	}
+	codeVerifier, err := auth.MakeOIDCSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		return "", "", wrapError(ErrorUpstream, err)
+	}
+
+	err = oidcServ.queries.CreateOIDCAuthRequest(ctx, database.CreateOIDCAuthRequestParams{
+		State:        state,
+		Provider:     providerName,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(oidcAuthRequestTTL),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("cannot save auth request: %w", err)
+	}
+
+	return authURL, state, nil
+}
+
+// HandleCallback finishes authorization started by StartAuth. It either logs the user in
+// (creating an account on first login) or links the identity to the user who started the flow.
+// stateHash is the hash of the state stored in the browser that started the flow, it prevents
+// an attacker from making the victim finish the attacker's flow (login and account linking CSRF).
+func (oidcServ *OIDCService) HandleCallback(ctx context.Context, providerName, code, state, stateHash, providerError string) (models.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "OIDCService.HandleCallback")
+	defer span.End()
+
+	provider, ok := oidcServ.ApiConfig.OIDCProviders[providerName]
+	if !ok {
+		return models.UserResponse{}, notFoundError("unknown provider: %s", providerName)
+	}
+	if providerError != "" {
+		return models.UserResponse{}, validationError("provider returned error: %s", providerError)
+	}
+	if code == "" || state == "" {
+		return models.UserResponse{}, validationError("code or state not specified")
+	}
+	if !auth.CheckOIDCState(state, stateHash) {
+		return models.UserResponse{}, validationError("state was not issued to this browser")
+	}
+
+	authRequest, err := oidcServ.queries.ConsumeOIDCAuthRequest(ctx, state)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.UserResponse{}, validationError("unknown or expired state")
+	}
+	if err != nil {
+		return models.UserResponse{}, fmt.Errorf("cannot get auth request: %w", err)
+	}
+	if authRequest.Provider != providerName {
+		return models.UserResponse{}, validationError("state was issued for another provider")
+	}
+
+	rawIDToken, err := provider.Exchange(ctx, code, authRequest.CodeVerifier)
+	if err != nil {
+		return models.UserResponse{}, upstreamError("cannot exchange code: %s", err)
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, rawIDToken, authRequest.Nonce)
+	if err != nil {
+		return models.UserResponse{}, wrapError(ErrorUnauthenticated, err)
+	}
+
+	if authRequest.LinkUserID.Valid {
+		return oidcServ.linkIdentity(ctx, authRequest.LinkUserID.UUID, providerName, claims)
+	}
+	return oidcServ.loginWithIdentity(ctx, providerName, claims)
+}
+
+func (oidcServ *OIDCService) GetUserIdentities(ctx context.Context, principal auth.Principal) ([]models.UserIdentityResponse, error) {
+	ctx, span := tracing.Start(ctx, "OIDCService.GetUserIdentities")
+	defer span.End()
+
+	err := requireFirstParty(principal)
+	if err != nil {
+		return nil, err
+	}
+
+	dbIdentities, err := oidcServ.queries.GetUserIdentitiesForUser(ctx, principal.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get identities: %w", err)
+	}
+
+	responseIdentities := make([]models.UserIdentityResponse, len(dbIdentities))
+	for i, identity := range dbIdentities {
+		responseIdentities[i] = convertDBToIdentity(identity)
+	}
+	return responseIdentities, nil
+}
+
+func (oidcServ *OIDCService) loginWithIdentity(ctx context.Context, providerName string, claims auth.OIDCClaims) (models.UserResponse, error) {
+	identity, err := oidcServ.queries.GetUserIdentity(ctx, database.GetUserIdentityParams{Provider: providerName, Subject: claims.Subject})
+	if err == nil {
+		dbUser, err := oidcServ.users.GetUserByID(ctx, identity.UserID)
+		if err != nil {
+			return models.UserResponse{}, fmt.Errorf("cannot get user: %w", err)
+		}
+		return oidcServ.userServ.createSession(ctx, dbUser, true)
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return models.UserResponse{}, fmt.Errorf("cannot get identity: %w", err)
+	}
+
+	if !claims.EmailVerified || !validateEmail(claims.Email) {
+		return models.UserResponse{}, validationError("provider did not return verified email")
+	}
+
+	_, err = oidcServ.users.GetUserByEmail(ctx, claims.Email)
+	if err == nil {
+		return models.UserResponse{}, conflictError("user with this email already exists, log in and link the provider to the account")
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return models.UserResponse{}, fmt.Errorf("cannot get user: %w", err)
+	}
+
+	// the user and the identity are created together, so a concurrent first login does not leave a user without identity
+	var dbUser database.User
+	err = oidcServ.ApiConfig.InTx(ctx, func(queries database.Querier) error {
+		dbUser, err = queries.CreateUser(ctx, database.CreateUserParams{Email: claims.Email, HashedPassword: unsetPasswordHash})
+		if errors.Is(err, repository.ErrDuplicate) {
+			return conflictError("user with this email already exists, log in and link the provider to the account")
+		}
+		if err != nil {
+			return fmt.Errorf("error creating user: %w", err)
+		}
+
+		_, err = queries.CreateUserIdentity(ctx, database.CreateUserIdentityParams{
+			UserID:   dbUser.ID,
+			Provider: providerName,
+			Subject:  claims.Subject,
+			Email:    claims.Email,
+		})
+		if errors.Is(err, repository.ErrDuplicate) {
+			return conflictError("this identity was linked by another request")
+		}
+		if err != nil {
+			return fmt.Errorf("cannot create identity: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.UserResponse{}, err
+	}
+
+	return oidcServ.userServ.createSession(ctx, dbUser, true)
+}
+
+func (oidcServ *OIDCService) linkIdentity(ctx context.Context, userID uuid.UUID, providerName string, claims auth.OIDCClaims) (models.UserResponse, error) {
+	identity, err := oidcServ.queries.GetUserIdentity(ctx, database.GetUserIdentityParams{Provider: providerName, Subject: claims.Subject})
+	if err == nil && identity.UserID != userID {
+		return models.UserResponse{}, conflictError("this identity is linked to another user")
+	}
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return models.UserResponse{}, fmt.Errorf("cannot get identity: %w", err)
+	}
+
+	if errors.Is(err, repository.ErrNotFound) {
+		userIdentities, err := oidcServ.queries.GetUserIdentitiesForUser(ctx, userID)
+		if err != nil {
+			return models.UserResponse{}, fmt.Errorf("cannot get identities: %w", err)
+		}
+		for _, userIdentity := range userIdentities {
+			if userIdentity.Provider == providerName {
+				return models.UserResponse{}, conflictError("user already has an identity from this provider")
+			}
+		}
+
+		_, err = oidcServ.queries.CreateUserIdentity(ctx, database.CreateUserIdentityParams{
+			UserID:   userID,
+			Provider: providerName,
+			Subject:  claims.Subject,
+			Email:    claims.Email,
+		})
+		if errors.Is(err, repository.ErrDuplicate) {
+			return models.UserResponse{}, conflictError("this identity was linked by another request")
+		}
+		if err != nil {
+			return models.UserResponse{}, fmt.Errorf("cannot create identity: %w", err)
+		}
+	}
+
+	dbUser, err := oidcServ.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.UserResponse{}, fmt.Errorf("cannot get user: %w", err)
+	}
+	return convertDBToUser(dbUser), nil
+}
+
+func convertDBToIdentity(dbIdentity database.UserIdentity) models.UserIdentityResponse {
+	return models.UserIdentityResponse{
+		ID:        dbIdentity.ID,
+		CreatedAt: dbIdentity.CreatedAt,
+		Provider:  dbIdentity.Provider,
+		Subject:   dbIdentity.Subject,
+		Email:     dbIdentity.Email,
+	}
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	testProvider = "mock"
+	testClientID = "snserver"
+)
+
+// mockIssuer is an OpenID Connect provider that authorizes everyone as the identity set by signIn
+type mockIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockAuthorization
+}
+
+type mockAuthorization struct {
+	nonce         string
+	codeChallenge string
+	subject       string
+	email         string
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate key: %s", err)
+	}
+	issuer := &mockIssuer{key: key, codes: make(map[string]mockAuthorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(rw http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(rw).Encode(map[string]string{
+			"issuer":                 issuer.server.URL,
+			"authorization_endpoint": issuer.server.URL + "/authorize",
+			"token_endpoint":         issuer.server.URL + "/token",
+			"jwks_uri":               issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(rw http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(rw).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", issuer.token)
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (issuer *mockIssuer) providerConfig() config.OIDCProviderConfig {
+	return config.OIDCProviderConfig{
+		Name:        testProvider,
+		Issuer:      issuer.server.URL,
+		ClientID:    testClientID,
+		RedirectURL: "http://localhost/api/auth/mock/callback",
+	}
+}
+
+// signIn plays the user's part at the authorization endpoint and returns the code and the state
+// the provider redirects back with
+func (issuer *mockIssuer) signIn(t *testing.T, authURL, subject, email string) (string, string) {
+	t.Helper()
+	parsedURL, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("cannot parse auth url: %s", err)
+	}
+	query := parsedURL.Query()
+	if query.Get("client_id") != testClientID || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected auth url: %s", authURL)
+	}
+
+	code := uuid.NewString()
+	issuer.mu.Lock()
+	defer issuer.mu.Unlock()
+	issuer.codes[code] = mockAuthorization{
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		subject:       subject,
+		email:         email,
+	}
+	return code, query.Get("state")
+}
+
+func (issuer *mockIssuer) token(rw http.ResponseWriter, req *http.Request) {
+	issuer.mu.Lock()
+	authorization, ok := issuer.codes[req.PostFormValue("code")]
+	delete(issuer.codes, req.PostFormValue("code"))
+	issuer.mu.Unlock()
+
+	if !ok || auth.MakePKCEChallenge(req.PostFormValue("code_verifier")) != authorization.codeChallenge {
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, auth.OIDCClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer.server.URL,
+			Subject:   authorization.subject,
+			Audience:  jwt.ClaimStrings{testClientID},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+		Nonce:         authorization.nonce,
+		Email:         authorization.email,
+		EmailVerified: true,
+	})
+	token.Header["kid"] = "test"
+	idToken, err := token.SignedString(issuer.key)
+	if err != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(rw).Encode(map[string]string{"id_token": idToken})
+}
+
+// runOIDCFlow goes through the whole flow in one browser and returns the callback result
+func runOIDCFlow(t *testing.T, oidcServ *OIDCService, issuer *mockIssuer, principal auth.Principal, subject, email string) (uuid.UUID, string, error) {
+	t.Helper()
+	authURL, state, err := oidcServ.StartAuth(context.Background(), principal, testProvider)
+	if err != nil {
+		t.Fatalf("cannot start auth: %s", err)
+	}
+	code, returnedState := issuer.signIn(t, authURL, subject, email)
+	if returnedState != state {
+		t.Fatalf("provider got state %q, want %q", returnedState, state)
+	}
+
+	user, err := oidcServ.HandleCallback(context.Background(), testProvider, code, returnedState, auth.HashOIDCState(state), "")
+	return user.ID, user.Token, err
+}
+
+func newTestOIDCService(apiCfg *config.ApiConfig) *OIDCService {
+	return NewOIDCService(apiCfg, apiCfg.Queries, apiCfg.Repositories.Users, newTestUserService(apiCfg))
+}
+
+func TestOIDCSignupAndLogin(t *testing.T) {
+	issuer := newMockIssuer(t)
+	oidcServ := newTestOIDCService(newTestApiConfig(t, issuer.providerConfig()))
+
+	signupUserID, token, err := runOIDCFlow(t, oidcServ, issuer, auth.Principal{}, "subject-1", "social@example.com")
+	if err != nil {
+		t.Fatalf("first login failed: %s", err)
+	}
+	if token == "" {
+		t.Fatalf("first login did not create a session")
+	}
+	dbUser, err := oidcServ.ApiConfig.Queries.GetUserByEmail(context.Background(), "social@example.com")
+	if err != nil {
+		t.Fatalf("user was not created: %s", err)
+	}
+	if dbUser.ID != signupUserID || dbUser.HashedPassword != unsetPasswordHash {
+		t.Fatalf("unexpected created user: %+v", dbUser)
+	}
+
+	loginUserID, token, err := runOIDCFlow(t, oidcServ, issuer, auth.Principal{}, "subject-1", "social@example.com")
+	if err != nil {
+		t.Fatalf("second login failed: %s", err)
+	}
+	if loginUserID != signupUserID || token == "" {
+		t.Fatalf("second login got user %s, want %s", loginUserID, signupUserID)
+	}
+}
+
+func TestOIDCSignupWithTakenEmail(t *testing.T) {
+	issuer := newMockIssuer(t)
+	oidcServ := newTestOIDCService(newTestApiConfig(t, issuer.providerConfig()))
+	_, err := oidcServ.ApiConfig.Queries.CreateUser(context.Background(), database.CreateUserParams{Email: "taken@example.com", HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+
+	_, _, err = runOIDCFlow(t, oidcServ, issuer, auth.Principal{}, "subject-1", "taken@example.com")
+	requireErrorKind(t, err, ErrorConflict)
+}
+
+func TestOIDCLinkIdentity(t *testing.T) {
+	issuer := newMockIssuer(t)
+	oidcServ := newTestOIDCService(newTestApiConfig(t, issuer.providerConfig()))
+	dbUser, err := oidcServ.ApiConfig.Queries.CreateUser(context.Background(), database.CreateUserParams{Email: "user@example.com", HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+	principal := auth.Principal{UserID: dbUser.ID, TokenType: auth.TokenTypeSession}
+
+	linkedUserID, token, err := runOIDCFlow(t, oidcServ, issuer, principal, "subject-1", "other@example.com")
+	if err != nil {
+		t.Fatalf("linking failed: %s", err)
+	}
+	if linkedUserID != dbUser.ID || token != "" {
+		t.Fatalf("linking got user %s with token %q, want %s without token", linkedUserID, token, dbUser.ID)
+	}
+
+	identities, err := oidcServ.GetUserIdentities(context.Background(), principal)
+	if err != nil {
+		t.Fatalf("cannot get identities: %s", err)
+	}
+	if len(identities) != 1 || identities[0].Provider != testProvider || identities[0].Subject != "subject-1" {
+		t.Fatalf("unexpected identities: %+v", identities)
+	}
+
+	loginUserID, _, err := runOIDCFlow(t, oidcServ, issuer, auth.Principal{}, "subject-1", "other@example.com")
+	if err != nil {
+		t.Fatalf("login with linked identity failed: %s", err)
+	}
+	if loginUserID != dbUser.ID {
+		t.Fatalf("login with linked identity got user %s, want %s", loginUserID, dbUser.ID)
+	}
+
+	otherUser, err := oidcServ.ApiConfig.Queries.CreateUser(context.Background(), database.CreateUserParams{Email: "another@example.com", HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+	_, _, err = runOIDCFlow(t, oidcServ, issuer, auth.Principal{UserID: otherUser.ID, TokenType: auth.TokenTypeSession}, "subject-1", "other@example.com")
+	requireErrorKind(t, err, ErrorConflict)
+}
+
+func TestOIDCCallbackRequiresStateFromSameBrowser(t *testing.T) {
+	issuer := newMockIssuer(t)
+	oidcServ := newTestOIDCService(newTestApiConfig(t, issuer.providerConfig()))
+
+	// the attacker starts the flow and makes the victim's browser open the callback
+	authURL, state, err := oidcServ.StartAuth(context.Background(), auth.Principal{}, testProvider)
+	if err != nil {
+		t.Fatalf("cannot start auth: %s", err)
+	}
+	code, _ := issuer.signIn(t, authURL, "attacker", "attacker@example.com")
+
+	_, err = oidcServ.HandleCallback(context.Background(), testProvider, code, state, "", "")
+	requireErrorKind(t, err, ErrorValidation)
+
+	_, victimState, err := oidcServ.StartAuth(context.Background(), auth.Principal{}, testProvider)
+	if err != nil {
+		t.Fatalf("cannot start auth: %s", err)
+	}
+	_, err = oidcServ.HandleCallback(context.Background(), testProvider, code, state, auth.HashOIDCState(victimState), "")
+	requireErrorKind(t, err, ErrorValidation)
+}
@@ -2,43 +2,82 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/ech00wv/SNserver/internal/auth"
 	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/database"
 	"github.com/ech00wv/SNserver/internal/models"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/tracing"
 	"github.com/google/uuid"
 )
 
 type PaymentService struct {
 	ApiConfig *config.ApiConfig
+	users     repository.Users
+	payments  repository.Payments
 }
 
-func (paymentServ *PaymentService) UpgradeToPremium(ctx context.Context, paymentData models.PaymentProviderWebhook, reqHeader http.Header) int {
+func NewPaymentService(apiConfig *config.ApiConfig, users repository.Users, payments repository.Payments) *PaymentService {
+	return &PaymentService{ApiConfig: apiConfig, users: users, payments: payments}
+}
+
+// UpgradeToPremium handles payment provider's webhook, events other than "user.upgraded" are ignored
+func (paymentServ *PaymentService) UpgradeToPremium(ctx context.Context, paymentData models.PaymentProviderWebhook, reqHeader http.Header) error {
+	ctx, span := tracing.Start(ctx, "PaymentService.UpgradeToPremium")
+	defer span.End()
 
 	token, err := auth.GetApiKey(reqHeader)
 	if err != nil {
-		return http.StatusUnauthorized
+		return wrapError(ErrorUnauthenticated, err)
 	}
 
 	if token != paymentServ.ApiConfig.PaymentKey {
-		return http.StatusUnauthorized
+		return unauthenticatedError("wrong api key")
+	}
+
+	// only webhooks from the provider are counted, unauthenticated requests are seen in request metrics
+	err = paymentServ.upgradeToPremium(ctx, paymentData)
+	result := "processed"
+	if ErrorKindOf(err) == ErrorNotFound {
+		result = "user_not_found"
+	} else if err != nil {
+		result = "failed"
 	}
+	paymentServ.ApiConfig.Metrics.WebhooksProcessed.WithLabelValues(result).Inc()
+	return err
+}
 
+func (paymentServ *PaymentService) upgradeToPremium(ctx context.Context, paymentData models.PaymentProviderWebhook) error {
 	if paymentData.Event != "user.upgraded" {
-		return http.StatusNoContent
+		return nil
+	}
+
+	userUUID, err := uuid.Parse(paymentData.Data.UserID)
+	if err != nil {
+		return notFoundError("user not found")
+	}
+
+	userExists, err := paymentServ.users.CheckUserExists(ctx, userUUID)
+	if err != nil {
+		return fmt.Errorf("cannot check user: %w", err)
+	}
+	if !userExists {
+		return notFoundError("user not found")
 	}
 
-	userID := paymentData.Data.UserID
-	userUUID, err := uuid.Parse(userID)
+	err = paymentServ.payments.UpgradeToPremium(ctx, userUUID)
 	if err != nil {
-		return http.StatusNotFound
+		return fmt.Errorf("cannot upgrade user: %w", err)
 	}
 
-	err = paymentServ.ApiConfig.Queries.UpgradeToPremium(ctx, userUUID)
+	// payment history is kept for users' data exports
+	err = paymentServ.payments.CreatePaymentEvent(ctx, database.CreatePaymentEventParams{UserID: userUUID, Event: paymentData.Event})
 	if err != nil {
-		return http.StatusInternalServerError
+		return fmt.Errorf("cannot record payment event: %w", err)
 	}
 
-	return http.StatusNoContent
+	return nil
 }
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/models"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/tracing"
+	"github.com/google/uuid"
+)
+
+const (
+	maxPersonalTokenName          = 100
+	maxPersonalTokenLifetimeDays  = 365
+	personalTokensPerUserMaxCount = 50
+)
+
+type PersonalTokenService struct {
+	ApiConfig      *config.ApiConfig
+	personalTokens repository.PersonalAccessTokens
+}
+
+func NewPersonalTokenService(apiConfig *config.ApiConfig, personalTokens repository.PersonalAccessTokens) *PersonalTokenService {
+	return &PersonalTokenService{ApiConfig: apiConfig, personalTokens: personalTokens}
+}
+
+func (tokenServ *PersonalTokenService) CreateToken(ctx context.Context, principal auth.Principal, tokenRequest models.PersonalTokenRequest) (models.PersonalTokenResponse, error) {
+	ctx, span := tracing.Start(ctx, "PersonalTokenService.CreateToken")
+	defer span.End()
+
+	// tokens can be managed only with user's own access token, so a leaked personal token cannot mint new ones
+	err := requireFirstParty(principal)
+	if err != nil {
+		return models.PersonalTokenResponse{}, err
+	}
+
+	if tokenRequest.Name == "" || len(tokenRequest.Name) > maxPersonalTokenName {
+		return models.PersonalTokenResponse{}, validationError("token name must be between 1 and %d characters", maxPersonalTokenName)
+	}
+	if len(tokenRequest.Scopes) == 0 {
+		return models.PersonalTokenResponse{}, validationError("at least one scope is required")
+	}
+	for _, scope := range tokenRequest.Scopes {
+		if !slices.Contains(auth.Scopes, scope) {
+			return models.PersonalTokenResponse{}, validationError("unknown scope: %s", scope)
+		}
+	}
+	if tokenRequest.ExpiresInDays < 0 || tokenRequest.ExpiresInDays > maxPersonalTokenLifetimeDays {
+		return models.PersonalTokenResponse{}, validationError("expires_in_days must be between 0 (never expires) and %d", maxPersonalTokenLifetimeDays)
+	}
+
+	existingTokens, err := tokenServ.personalTokens.GetPersonalAccessTokensForUser(ctx, principal.UserID)
+	if err != nil {
+		return models.PersonalTokenResponse{}, fmt.Errorf("cannot get tokens: %w", err)
+	}
+	if len(existingTokens) >= personalTokensPerUserMaxCount {
+		return models.PersonalTokenResponse{}, conflictError("user cannot have more than %d tokens", personalTokensPerUserMaxCount)
+	}
+
+	expiresAt := sql.NullTime{}
+	if tokenRequest.ExpiresInDays > 0 {
+		expiresAt = sql.NullTime{Time: time.Now().AddDate(0, 0, tokenRequest.ExpiresInDays), Valid: true}
+	}
+
+	token, err := auth.MakePersonalAccessToken()
+	if err != nil {
+		return models.PersonalTokenResponse{}, fmt.Errorf("cannot generate token: %w", err)
+	}
+
+	dbToken, err := tokenServ.personalTokens.CreatePersonalAccessToken(ctx, database.CreatePersonalAccessTokenParams{
+		UserID:    principal.UserID,
+		Name:      tokenRequest.Name,
+		TokenHash: auth.HashToken(token),
+		Scopes:    tokenRequest.Scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return models.PersonalTokenResponse{}, fmt.Errorf("cannot create token: %w", err)
+	}
+
+	responseToken := convertDBToPersonalToken(dbToken)
+	responseToken.Token = token
+	return responseToken, nil
+}
+
+func (tokenServ *PersonalTokenService) GetTokens(ctx context.Context, principal auth.Principal) ([]models.PersonalTokenResponse, error) {
+	ctx, span := tracing.Start(ctx, "PersonalTokenService.GetTokens")
+	defer span.End()
+
+	err := requireFirstParty(principal)
+	if err != nil {
+		return nil, err
+	}
+
+	dbTokens, err := tokenServ.personalTokens.GetPersonalAccessTokensForUser(ctx, principal.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get tokens: %w", err)
+	}
+
+	responseTokens := make([]models.PersonalTokenResponse, len(dbTokens))
+	for i, token := range dbTokens {
+		responseTokens[i] = convertDBToPersonalToken(token)
+	}
+	return responseTokens, nil
+}
+
+func (tokenServ *PersonalTokenService) DeleteToken(ctx context.Context, principal auth.Principal, tokenID string) error {
+	ctx, span := tracing.Start(ctx, "PersonalTokenService.DeleteToken")
+	defer span.End()
+
+	err := requireFirstParty(principal)
+	if err != nil {
+		return err
+	}
+
+	tokenUUID, err := uuid.Parse(tokenID)
+	if err != nil {
+		return validationError("cannot convert token id to uuid: %s", err)
+	}
+
+	_, err = tokenServ.personalTokens.DeletePersonalAccessToken(ctx, database.DeletePersonalAccessTokenParams{ID: tokenUUID, UserID: principal.UserID})
+	if errors.Is(err, repository.ErrNotFound) {
+		return notFoundError("token not found")
+	}
+	if err != nil {
+		return fmt.Errorf("cannot delete token: %w", err)
+	}
+	return nil
+}
+
+func convertDBToPersonalToken(dbToken database.PersonalAccessToken) models.PersonalTokenResponse {
+	responseToken := models.PersonalTokenResponse{
+		ID:        dbToken.ID,
+		CreatedAt: dbToken.CreatedAt,
+		Name:      dbToken.Name,
+		Scopes:    dbToken.Scopes,
+	}
+	if dbToken.ExpiresAt.Valid {
+		responseToken.ExpiresAt = &dbToken.ExpiresAt.Time
+	}
+	if dbToken.LastUsedAt.Valid {
+		responseToken.LastUsedAt = &dbToken.LastUsedAt.Time
+	}
+	return responseToken
+}
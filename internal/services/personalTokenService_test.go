@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/models"
+)
+
+// createDemoUser signs up through UserService, so it works without the database
+func createDemoUser(t *testing.T, userServ *UserService, email string) auth.Principal {
+	t.Helper()
+	user, err := userServ.CreateUser(context.Background(), models.UserRequest{Email: email, Password: "Correct-horse-98battery"})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+	return auth.Principal{UserID: user.ID, TokenType: auth.TokenTypeSession}
+}
+
+func TestPersonalTokensInDemoMode(t *testing.T) {
+	apiCfg := newDemoTestApiConfig(t)
+	repos := apiCfg.Repositories
+	tokenServ := NewPersonalTokenService(apiCfg, repos.PersonalAccessTokens)
+	authServ := NewAuthService(apiCfg, repos.Users, repos.PersonalAccessTokens, apiCfg.Queries)
+	ctx := context.Background()
+	owner := createDemoUser(t, newTestUserService(apiCfg), "owner@example.com")
+
+	token, err := tokenServ.CreateToken(ctx, owner, models.PersonalTokenRequest{Name: "bot", Scopes: []string{auth.ScopeMessagesRead}})
+	if err != nil {
+		t.Fatalf("cannot create token: %s", err)
+	}
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token.Token)
+
+	principal, err := authServ.Authenticate(ctx, header)
+	if err != nil {
+		t.Fatalf("cannot authenticate with token: %s", err)
+	}
+	if principal.UserID != owner.UserID || principal.TokenType != auth.TokenTypePersonal {
+		t.Fatalf("unexpected principal: %+v", principal)
+	}
+
+	tokens, err := tokenServ.GetTokens(ctx, owner)
+	if err != nil {
+		t.Fatalf("cannot get tokens: %s", err)
+	}
+	if len(tokens) != 1 || tokens[0].LastUsedAt == nil {
+		t.Fatalf("expected one used token, got %+v", tokens)
+	}
+
+	_, err = tokenServ.CreateToken(ctx, principal, models.PersonalTokenRequest{Name: "minted", Scopes: []string{auth.ScopeMessagesRead}})
+	requireErrorKind(t, err, ErrorForbidden)
+
+	err = tokenServ.DeleteToken(ctx, owner, token.ID.String())
+	if err != nil {
+		t.Fatalf("cannot delete token: %s", err)
+	}
+	_, err = authServ.Authenticate(ctx, header)
+	requireErrorKind(t, err, ErrorUnauthenticated)
+	err = tokenServ.DeleteToken(ctx, owner, token.ID.String())
+	requireErrorKind(t, err, ErrorNotFound)
+}
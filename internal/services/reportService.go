@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/models"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/tracing"
+	"github.com/google/uuid"
+)
+
+const (
+	ReportTargetMessage = "message"
+	ReportTargetUser    = "user"
+
+	// reason of reports created by content filter, users cannot choose it
+	ReportReasonContentFilter = "content_filter"
+
+	maxReportDetailsLength = 1000
+)
+
+var ReportReasons = []string{"spam", "harassment", "hate_speech", "violence", "sexual_content", "other"}
+
+type ReportService struct {
+	ApiConfig *config.ApiConfig
+	messages  repository.Messages
+	users     repository.Users
+	reports   repository.Reports
+}
+
+func NewReportService(apiConfig *config.ApiConfig, messages repository.Messages, users repository.Users, reports repository.Reports) *ReportService {
+	return &ReportService{ApiConfig: apiConfig, messages: messages, users: users, reports: reports}
+}
+
+// ReportMessage puts message into moderation queue, the body is saved in the report so it can be reviewed after deletion
+func (reportServ *ReportService) ReportMessage(ctx context.Context, principal auth.Principal, messageID string, reportRequest models.ReportRequest) (models.ReportResponse, error) {
+	ctx, span := tracing.Start(ctx, "ReportService.ReportMessage")
+	defer span.End()
+
+	err := reportServ.validateReport(principal, reportRequest)
+	if err != nil {
+		return models.ReportResponse{}, err
+	}
+
+	messageUUID, err := uuid.Parse(messageID)
+	if err != nil {
+		return models.ReportResponse{}, validationError("cannot convert message id to uuid: %s", err)
+	}
+	dbMessage, err := reportServ.messages.GetMessage(ctx, messageUUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.ReportResponse{}, notFoundError("message not found")
+	}
+	if err != nil {
+		return models.ReportResponse{}, fmt.Errorf("cannot get message: %w", err)
+	}
+	// messages hidden from the reporter cannot be reported, the answer would reveal that they exist
+	err = checkMessageVisible(ctx, reportServ.messages, reportServ.users, principal, dbMessage)
+	if err != nil {
+		return models.ReportResponse{}, err
+	}
+	if dbMessage.UserID == principal.UserID {
+		return models.ReportResponse{}, validationError("user cannot report their own message")
+	}
+
+	return reportServ.createReport(ctx, database.CreateReportParams{
+		ReporterID:     uuid.NullUUID{UUID: principal.UserID, Valid: true},
+		TargetType:     ReportTargetMessage,
+		ReportedUserID: dbMessage.UserID,
+		MessageID:      uuid.NullUUID{UUID: dbMessage.ID, Valid: true},
+		MessageBody:    sql.NullString{String: dbMessage.Body, Valid: true},
+		Reason:         reportRequest.Reason,
+		Details:        reportRequest.Details,
+	})
+}
+
+func (reportServ *ReportService) ReportUser(ctx context.Context, principal auth.Principal, userID string, reportRequest models.ReportRequest) (models.ReportResponse, error) {
+	ctx, span := tracing.Start(ctx, "ReportService.ReportUser")
+	defer span.End()
+
+	err := reportServ.validateReport(principal, reportRequest)
+	if err != nil {
+		return models.ReportResponse{}, err
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return models.ReportResponse{}, validationError("cannot convert user id to uuid: %s", err)
+	}
+	if userUUID == principal.UserID {
+		return models.ReportResponse{}, validationError("user cannot report themselves")
+	}
+	userExists, err := reportServ.users.CheckUserExists(ctx, userUUID)
+	if err != nil {
+		return models.ReportResponse{}, fmt.Errorf("error in user validation: %w", err)
+	}
+	if !userExists {
+		return models.ReportResponse{}, notFoundError("user not found")
+	}
+
+	return reportServ.createReport(ctx, database.CreateReportParams{
+		ReporterID:     uuid.NullUUID{UUID: principal.UserID, Valid: true},
+		TargetType:     ReportTargetUser,
+		ReportedUserID: userUUID,
+		Reason:         reportRequest.Reason,
+		Details:        reportRequest.Details,
+	})
+}
+
+func (reportServ *ReportService) validateReport(principal auth.Principal, reportRequest models.ReportRequest) error {
+	err := requireScope(principal, auth.ScopeMessagesWrite)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(ReportReasons, reportRequest.Reason) {
+		return validationError("reason must be one of %v", ReportReasons)
+	}
+	if utf8.RuneCountInString(reportRequest.Details) > maxReportDetailsLength {
+		return validationError("details must be at most %d characters", maxReportDetailsLength)
+	}
+	return nil
+}
+
+// createReport saves the report unless the reporter already has unresolved report of the same target
+func (reportServ *ReportService) createReport(ctx context.Context, reportParams database.CreateReportParams) (models.ReportResponse, error) {
+	reportExists, err := reportServ.reports.CheckOpenReportExists(ctx, database.CheckOpenReportExistsParams{
+		ReporterID:     reportParams.ReporterID.UUID,
+		ReportedUserID: reportParams.ReportedUserID,
+		MessageID:      reportParams.MessageID,
+	})
+	if err != nil {
+		return models.ReportResponse{}, fmt.Errorf("cannot check existing reports: %w", err)
+	}
+	if reportExists {
+		return models.ReportResponse{}, conflictError("this has already been reported and is waiting for review")
+	}
+
+	dbReport, err := reportServ.reports.CreateReport(ctx, reportParams)
+	if err != nil {
+		return models.ReportResponse{}, fmt.Errorf("cannot create report: %w", err)
+	}
+	return convertDBToReport(dbReport), nil
+}
+
+func convertDBToReport(dbReport database.Report) models.ReportResponse {
+	responseReport := models.ReportResponse{
+		ID:             dbReport.ID,
+		CreatedAt:      dbReport.CreatedAt,
+		ReporterID:     dbReport.ReporterID,
+		TargetType:     dbReport.TargetType,
+		ReportedUserID: dbReport.ReportedUserID,
+		MessageID:      dbReport.MessageID,
+		MessageBody:    dbReport.MessageBody.String,
+		Reason:         dbReport.Reason,
+		Details:        dbReport.Details,
+		Status:         dbReport.Status,
+		ClaimedBy:      dbReport.ClaimedBy,
+		Resolution:     dbReport.Resolution.String,
+	}
+	if dbReport.ClaimedAt.Valid {
+		responseReport.ClaimedAt = &dbReport.ClaimedAt.Time
+	}
+	if dbReport.ResolvedAt.Valid {
+		responseReport.ResolvedAt = &dbReport.ResolvedAt.Time
+	}
+	return responseReport
+}
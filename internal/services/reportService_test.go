@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/contentfilter"
+	"github.com/ech00wv/SNserver/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestReportsInDemoMode(t *testing.T) {
+	apiCfg := newDemoTestApiConfig(t)
+	repos := apiCfg.Repositories
+	reportServ := NewReportService(apiCfg, repos.Messages, repos.Users, repos.Reports)
+	messageServ := NewMessageService(apiCfg, repos.Messages, repos.Users, repos.Reports)
+	userServ := newTestUserService(apiCfg)
+	ctx := context.Background()
+	author := createDemoUser(t, userServ, "author@example.com")
+	reporter := createDemoUser(t, userServ, "reporter@example.com")
+
+	apiCfg.ContentFilter = contentfilter.NewFilter(contentfilter.StaticSource{{Term: "casino", Action: contentfilter.ActionFlag}})
+	err := apiCfg.ContentFilter.Reload(ctx)
+	if err != nil {
+		t.Fatalf("cannot load rules: %s", err)
+	}
+
+	message, err := messageServ.CreateMessage(ctx, author, models.MessageRequest{Body: "visit my casino"})
+	if err != nil {
+		t.Fatalf("cannot create flagged message: %s", err)
+	}
+	// the content filter report has no reporter, so users can still report the message
+	report, err := reportServ.ReportMessage(ctx, reporter, message.ID.String(), models.ReportRequest{Reason: "spam"})
+	if err != nil {
+		t.Fatalf("cannot report message: %s", err)
+	}
+	if report.Status != "open" || report.MessageBody != message.Body {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	_, err = reportServ.ReportMessage(ctx, reporter, message.ID.String(), models.ReportRequest{Reason: "spam"})
+	requireErrorKind(t, err, ErrorConflict)
+	_, err = reportServ.ReportUser(ctx, reporter, author.UserID.String(), models.ReportRequest{Reason: "harassment"})
+	if err != nil {
+		t.Fatalf("cannot report user: %s", err)
+	}
+	_, err = reportServ.ReportUser(ctx, author, author.UserID.String(), models.ReportRequest{Reason: "spam"})
+	requireErrorKind(t, err, ErrorValidation)
+}
+
+func TestReportingHiddenMessage(t *testing.T) {
+	apiCfg := newDemoTestApiConfig(t)
+	repos := apiCfg.Repositories
+	reportServ := NewReportService(apiCfg, repos.Messages, repos.Users, repos.Reports)
+	messageServ := NewMessageService(apiCfg, repos.Messages, repos.Users, repos.Reports)
+	blockServ := NewBlockService(apiCfg, repos.Blocks, repos.Users)
+	userServ := newTestUserService(apiCfg)
+	ctx := context.Background()
+	author := createDemoUser(t, userServ, "author@example.com")
+	reporter := createDemoUser(t, userServ, "reporter@example.com")
+
+	message, err := messageServ.CreateMessage(ctx, author, models.MessageRequest{Body: "hello"})
+	if err != nil {
+		t.Fatalf("cannot create message: %s", err)
+	}
+	_, err = reportServ.ReportMessage(ctx, reporter, uuid.NewString(), models.ReportRequest{Reason: "spam"})
+	requireErrorKind(t, err, ErrorNotFound)
+
+	// the message is hidden from the reporter, so it is reported as missing
+	err = blockServ.BlockUser(ctx, author, reporter.UserID.String())
+	if err != nil {
+		t.Fatalf("cannot block user: %s", err)
+	}
+	_, err = reportServ.ReportMessage(ctx, reporter, message.ID.String(), models.ReportRequest{Reason: "spam"})
+	requireErrorKind(t, err, ErrorNotFound)
+}
+
+func TestDatabaseFeaturesInDemoMode(t *testing.T) {
+	apiCfg := newDemoTestApiConfig(t)
+	oauthServ := NewOAuthService(apiCfg, apiCfg.Queries)
+	user := createDemoUser(t, newTestUserService(apiCfg), "user@example.com")
+
+	_, err := oauthServ.GetClientsForUser(context.Background(), auth.Principal{UserID: user.UserID, TokenType: auth.TokenTypeSession})
+	requireErrorKind(t, err, ErrorNotImplemented)
+	var serviceErr *Error
+	if !errors.As(DescribeError(err), &serviceErr) || serviceErr.Code != ErrorCodeDemoMode {
+		t.Fatalf("expected error with code %s, got %v", ErrorCodeDemoMode, err)
+	}
+}
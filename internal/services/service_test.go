@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/config"
+	_ "modernc.org/sqlite"
+)
+
+// newTestApiConfig returns services' dependencies backed by in-memory SQLite database
+func newTestApiConfig(t *testing.T, providers ...config.OIDCProviderConfig) *config.ApiConfig {
+	t.Helper()
+
+	cfg := newTestConfig(t)
+	cfg.OIDCProviders = providers
+	return initializeTestApiConfig(t, cfg)
+}
+
+// newDemoTestApiConfig returns services' dependencies of demo mode, repositories are kept in repository.Memory
+func newDemoTestApiConfig(t *testing.T) *config.ApiConfig {
+	t.Helper()
+
+	cfg := newTestConfig(t)
+	cfg.DemoMode = true
+	return initializeTestApiConfig(t, cfg)
+}
+
+func newTestConfig(t *testing.T) config.Config {
+	return config.Config{
+		DBDriver:                    config.DBDriverSQLite,
+		DBURL:                       ":memory:",
+		JWTSecret:                   "test-secret",
+		AccessTokenTTL:              time.Hour,
+		RefreshTokenTTL:             time.Hour,
+		AccountDeletionGracePeriod:  time.Hour,
+		StorageDir:                  t.TempDir(),
+		ContentFilterReloadInterval: time.Minute,
+		RateLimitStore:              config.RateLimitStoreMemory,
+		PasswordPolicy:              auth.DefaultPasswordPolicy(),
+		// cheap parameters keep tests fast, they are not checked by the services
+		PasswordHash: auth.Argon2Params{MemoryKiB: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+	}
+}
+
+func initializeTestApiConfig(t *testing.T, cfg config.Config) *config.ApiConfig {
+	t.Helper()
+
+	apiCfg, err := config.InitializeApiConfig(context.Background(), cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("cannot initialize api config: %s", err)
+	}
+	t.Cleanup(func() { apiCfg.DB.Close() })
+	return apiCfg
+}
+
+func requireErrorKind(t *testing.T, err error, kind ErrorKind) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected error of kind %d, got nil", kind)
+	}
+	if ErrorKindOf(err) != kind {
+		t.Fatalf("expected error of kind %d, got %d: %s", kind, ErrorKindOf(err), err)
+	}
+}
@@ -2,48 +2,72 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/ech00wv/SNserver/internal/auth"
 	"github.com/ech00wv/SNserver/internal/config"
-	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/tracing"
 )
 
 type TokenService struct {
-	Queries *database.Queries
+	ApiConfig     *config.ApiConfig
+	refreshTokens repository.RefreshTokens
+	users         repository.Users
 }
 
-func (tokenServ *TokenService) RefreshAccessToken(ctx context.Context, header http.Header, apiCfg *config.ApiConfig) (string, int, error) {
+func NewTokenService(apiConfig *config.ApiConfig, refreshTokens repository.RefreshTokens, users repository.Users) *TokenService {
+	return &TokenService{ApiConfig: apiConfig, refreshTokens: refreshTokens, users: users}
+}
+
+func (tokenServ *TokenService) RefreshAccessToken(ctx context.Context, header http.Header) (string, error) {
+	ctx, span := tracing.Start(ctx, "TokenService.RefreshAccessToken")
+	defer span.End()
 
 	refreshToken, err := auth.GetBearerToken(header)
 	if err != nil {
-		return "", http.StatusBadRequest, fmt.Errorf("authorization header has wrong structure: %s", err)
+		return "", validationError("authorization header has wrong structure: %s", err)
 	}
 
-	dbUserId, err := tokenServ.Queries.GetUserFromRefreshToken(ctx, refreshToken)
+	dbUserId, err := tokenServ.refreshTokens.GetUserFromRefreshToken(ctx, refreshToken)
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", unauthenticatedError("could not find refresh token or it is expired")
+	}
 	if err != nil {
-		return "", http.StatusUnauthorized, fmt.Errorf("could not find refresh token or it is expired: %s", err)
+		return "", fmt.Errorf("cannot get refresh token: %w", err)
 	}
 
-	newAccessToken, err := auth.MakeJWT(dbUserId, apiCfg.JWTSecret, time.Hour)
+	userAccess, err := tokenServ.users.GetUserAccess(ctx, dbUserId)
+	if err != nil {
+		return "", fmt.Errorf("cannot get user: %w", err)
+	}
+	err = checkAccountStatus(userAccess.Status, userAccess.SuspendedUntil)
 	if err != nil {
-		return "", http.StatusInternalServerError, fmt.Errorf("error in creating jwt: %s", err)
+		return "", err
 	}
 
-	return newAccessToken, http.StatusOK, nil
+	newAccessToken, err := auth.MakeJWT(dbUserId, tokenServ.ApiConfig.JWTSecret, tokenServ.ApiConfig.AccessTokenTTL)
+	if err != nil {
+		return "", fmt.Errorf("error in creating jwt: %w", err)
+	}
+
+	return newAccessToken, nil
 
 }
 
-func (tokenServ *TokenService) RevokeRefreshToken(ctx context.Context, header http.Header) (int, error) {
+func (tokenServ *TokenService) RevokeRefreshToken(ctx context.Context, header http.Header) error {
+	ctx, span := tracing.Start(ctx, "TokenService.RevokeRefreshToken")
+	defer span.End()
+
 	refreshToken, err := auth.GetBearerToken(header)
 	if err != nil {
-		return http.StatusBadRequest, fmt.Errorf("authorization header has wrong structure: %s", err)
+		return validationError("authorization header has wrong structure: %s", err)
 	}
-	err = tokenServ.Queries.RevokeRefreshToken(ctx, refreshToken)
+	err = tokenServ.refreshTokens.RevokeRefreshToken(ctx, refreshToken)
 	if err != nil {
-		return http.StatusBadRequest, fmt.Errorf("cannot revoke token: %s", err)
+		return fmt.Errorf("cannot revoke token: %w", err)
 	}
-	return http.StatusNoContent, nil
+	return nil
 }
@@ -2,109 +2,367 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"net/http"
 	"regexp"
 	"time"
 
 	"github.com/ech00wv/SNserver/internal/auth"
 	"github.com/ech00wv/SNserver/internal/config"
 	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/logging"
 	"github.com/ech00wv/SNserver/internal/models"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/tracing"
+	"github.com/google/uuid"
 )
 
 type UserService struct {
-	ApiConfig *config.ApiConfig
+	ApiConfig     *config.ApiConfig
+	users         repository.Users
+	loginAttempts repository.LoginAttempts
+	refreshTokens repository.RefreshTokens
 }
 
-func (userServ *UserService) CreateUser(ctx context.Context, requestedUser models.UserRequest) (models.UserResponse, int, error) {
+// NewUserService creates the service on top of given repositories, ApiConfig provides its settings
+// (password policy, token lifetimes, etc.)
+func NewUserService(apiConfig *config.ApiConfig, users repository.Users, loginAttempts repository.LoginAttempts, refreshTokens repository.RefreshTokens) *UserService {
+	return &UserService{ApiConfig: apiConfig, users: users, loginAttempts: loginAttempts, refreshTokens: refreshTokens}
+}
+
+func (userServ *UserService) CreateUser(ctx context.Context, requestedUser models.UserRequest) (models.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer span.End()
 
-	hashedPassword, err := auth.HashPassword(requestedUser.Password)
+	if !validateEmail(requestedUser.Email) {
+		return models.UserResponse{}, validationError("email is not valid")
+	}
+
+	err := userServ.validatePassword(ctx, requestedUser.Password, requestedUser.Email)
 	if err != nil {
-		return models.UserResponse{}, http.StatusInternalServerError, fmt.Errorf("cannot hash password: %s", err)
+		return models.UserResponse{}, err
 	}
 
-	if !validateEmail(requestedUser.Email) {
-		return models.UserResponse{}, http.StatusBadRequest, fmt.Errorf("email is not valid")
+	hashedPassword, err := auth.HashPassword(requestedUser.Password, userServ.ApiConfig.PasswordHash)
+	if err != nil {
+		return models.UserResponse{}, fmt.Errorf("cannot hash password: %w", err)
 	}
 
-	dbUser, err := userServ.ApiConfig.Queries.CreateUser(ctx, database.CreateUserParams{Email: requestedUser.Email, HashedPassword: hashedPassword})
+	dbUser, err := userServ.users.CreateUser(ctx, database.CreateUserParams{Email: requestedUser.Email, HashedPassword: hashedPassword})
+	if errors.Is(err, repository.ErrDuplicate) {
+		return models.UserResponse{}, conflictError("user with this email already exists")
+	}
 	if err != nil {
-		return models.UserResponse{}, http.StatusInternalServerError, fmt.Errorf("error creating user: %s", err)
+		return models.UserResponse{}, fmt.Errorf("error creating user: %w", err)
 	}
 	responseUser := convertDBToUser(dbUser)
-	return responseUser, http.StatusCreated, nil
+	return responseUser, nil
 }
 
 func (userServ *UserService) DeleteUsers(ctx context.Context) error {
-	err := userServ.ApiConfig.Queries.DeleteUsers(ctx)
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUsers")
+	defer span.End()
+
+	exportKeys, err := userServ.users.DeleteAllDataExports(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot delete data exports: %w", err)
+	}
+	userServ.deleteExportArchives(ctx, exportKeys)
+
+	err = userServ.loginAttempts.DeleteAllLoginAttempts(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot delete login attempts: %w", err)
+	}
+
+	err = userServ.users.DeleteUsers(ctx)
 	return err
 }
 
-func (userServ *UserService) LoginUser(ctx context.Context, requestedUser models.UserRequest) (models.UserResponse, int, error) {
+func (userServ *UserService) LoginUser(ctx context.Context, requestedUser models.UserRequest, ipAddress string) (models.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.LoginUser")
+	defer span.End()
+
+	return userServ.login(ctx, requestedUser, ipAddress, true)
+}
+
+// LoginForConsent logs the user in on the OAuth consent page. Only an access token is issued,
+// the page needs it just to approve the request, so no long-lived session is left behind.
+func (userServ *UserService) LoginForConsent(ctx context.Context, requestedUser models.UserRequest, ipAddress string) (models.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.LoginForConsent")
+	defer span.End()
+
+	return userServ.login(ctx, requestedUser, ipAddress, false)
+}
+
+func (userServ *UserService) login(ctx context.Context, requestedUser models.UserRequest, ipAddress string, withRefreshToken bool) (models.UserResponse, error) {
+
 	if !validateEmail(requestedUser.Email) {
-		return models.UserResponse{}, http.StatusBadRequest, fmt.Errorf("email is not valid")
+		return models.UserResponse{}, validationError("email is not valid")
+	}
+
+	err := userServ.takeLoginAttempt(ctx, requestedUser.Email, ipAddress)
+	if err != nil {
+		return models.UserResponse{}, err
 	}
 
-	dbUser, err := userServ.ApiConfig.Queries.GetUserByEmail(ctx, requestedUser.Email)
+	dbUser, err := userServ.users.GetUserByEmail(ctx, requestedUser.Email)
+	if errors.Is(err, repository.ErrNotFound) {
+		auth.SimulatePasswordCheck(requestedUser.Password, userServ.ApiConfig.PasswordHash)
+		err = userServ.recordLoginAttempt(ctx, requestedUser.Email, ipAddress, uuid.NullUUID{}, false)
+		if err != nil {
+			return models.UserResponse{}, err
+		}
+		userServ.ApiConfig.Metrics.FailedLogins.Inc()
+		return models.UserResponse{}, unauthenticatedError("incorrect email or password")
+	}
 	if err != nil {
-		return models.UserResponse{}, http.StatusInternalServerError, fmt.Errorf("cannot get user: %s", err)
+		return models.UserResponse{}, fmt.Errorf("cannot get user: %w", err)
 	}
 
-	err = auth.CheckPasswordHash(requestedUser.Password, dbUser.HashedPassword)
+	passwordMatches := false
+	if dbUser.HashedPassword == unsetPasswordHash {
+		// accounts created through social login have no password to check, simulating the check
+		// keeps them indistinguishable by response time from accounts with a password
+		auth.SimulatePasswordCheck(requestedUser.Password, userServ.ApiConfig.PasswordHash)
+	} else {
+		passwordMatches = auth.CheckPasswordHash(requestedUser.Password, dbUser.HashedPassword) == nil
+	}
+	err = userServ.recordLoginAttempt(ctx, requestedUser.Email, ipAddress, uuid.NullUUID{UUID: dbUser.ID, Valid: true}, passwordMatches)
 	if err != nil {
-		return models.UserResponse{}, http.StatusUnauthorized, fmt.Errorf("incorrect email or password: %s", err)
+		return models.UserResponse{}, err
 	}
-	expiresIn := time.Hour
+	if !passwordMatches {
+		userServ.ApiConfig.Metrics.FailedLogins.Inc()
+		return models.UserResponse{}, unauthenticatedError("incorrect email or password")
+	}
+
+	userServ.upgradePasswordHash(ctx, dbUser, requestedUser.Password)
 
-	token, err := auth.MakeJWT(dbUser.ID, userServ.ApiConfig.JWTSecret, expiresIn)
+	return userServ.createSession(ctx, dbUser, withRefreshToken)
+}
+
+// upgradePasswordHash re-hashes password of legacy (bcrypt or outdated Argon2id) hash after successful login,
+// so stored hashes migrate gradually. Failure here must not prevent the login.
+func (userServ *UserService) upgradePasswordHash(ctx context.Context, dbUser database.User, password string) {
+	if !auth.NeedsRehash(dbUser.HashedPassword, userServ.ApiConfig.PasswordHash) {
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(password, userServ.ApiConfig.PasswordHash)
+	if err != nil {
+		logging.FromContext(ctx).Error("cannot re-hash password", "user_id", dbUser.ID, "error", err)
+		return
+	}
+
+	err = userServ.users.UpdateUserPasswordHash(ctx, database.UpdateUserPasswordHashParams{ID: dbUser.ID, HashedPassword: hashedPassword})
 	if err != nil {
-		return models.UserResponse{}, http.StatusInternalServerError, fmt.Errorf("error in token creation: %s", err)
+		logging.FromContext(ctx).Error("cannot save re-hashed password", "user_id", dbUser.ID, "error", err)
+	}
+}
+
+// createSession issues access token (and refresh token if withRefreshToken) for authenticated user, suspended and banned
+// users are rejected and logging into account that is pending deletion cancels the deletion
+func (userServ *UserService) createSession(ctx context.Context, dbUser database.User, withRefreshToken bool) (models.UserResponse, error) {
+	err := checkAccountStatus(dbUser.Status, dbUser.SuspendedUntil)
+	if err != nil {
+		return models.UserResponse{}, err
+	}
+
+	if dbUser.DeletionScheduledAt.Valid {
+		err := userServ.users.CancelUserDeletion(ctx, dbUser.ID)
+		if err != nil {
+			return models.UserResponse{}, fmt.Errorf("cannot cancel account deletion: %w", err)
+		}
+	}
+
+	token, err := auth.MakeJWT(dbUser.ID, userServ.ApiConfig.JWTSecret, userServ.ApiConfig.AccessTokenTTL)
+	if err != nil {
+		return models.UserResponse{}, fmt.Errorf("error in token creation: %w", err)
 	}
 
 	responseUser := convertDBToUser(dbUser)
 	responseUser.Token = token
+	if !withRefreshToken {
+		userServ.ApiConfig.Metrics.Logins.Inc()
+		return responseUser, nil
+	}
+
 	refreshToken, err := auth.MakeRefreshToken()
 	if err != nil {
-		return models.UserResponse{}, http.StatusInternalServerError, fmt.Errorf("cannot generate refresh token: %s", err)
+		return models.UserResponse{}, fmt.Errorf("cannot generate refresh token: %w", err)
 	}
 
 	responseUser.RefreshToken = refreshToken
-	err = userServ.ApiConfig.Queries.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{Token: refreshToken, UserID: dbUser.ID, ExpiresAt: time.Now().Add(1440 * time.Hour)})
+	err = userServ.refreshTokens.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{Token: refreshToken, UserID: dbUser.ID, ExpiresAt: time.Now().Add(userServ.ApiConfig.RefreshTokenTTL)})
 	if err != nil {
-		return models.UserResponse{}, http.StatusInternalServerError, fmt.Errorf("cannot create a refresh token entry: %s", err)
+		return models.UserResponse{}, fmt.Errorf("cannot create a refresh token entry: %w", err)
 	}
 
-	return responseUser, http.StatusOK, nil
+	userServ.ApiConfig.Metrics.Logins.Inc()
+	return responseUser, nil
 }
 
-func (userServ *UserService) UpdateUser(ctx context.Context, header http.Header, email, password string) (database.User, int, error) {
-	token, err := auth.GetBearerToken(header)
+// UpdateUser changes email and password of the caller's account after current password confirmation.
+// Credentials are managed with the user's own session only, third-party and personal tokens are rejected,
+// whatever scopes they have. Accounts created through social login have no password and skip the confirmation.
+func (userServ *UserService) UpdateUser(ctx context.Context, principal auth.Principal, requestedUser models.UpdateUserRequest, ipAddress string) (models.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+
+	err := requireFirstParty(principal)
 	if err != nil {
-		return database.User{}, http.StatusUnauthorized, fmt.Errorf("wrong authorization header: %s", err)
+		return models.UserResponse{}, err
 	}
 
-	userID, err := auth.ValidateJWT(token, userServ.ApiConfig.JWTSecret)
+	currentUser, err := userServ.users.GetUserByID(ctx, principal.UserID)
 	if err != nil {
-		return database.User{}, http.StatusUnauthorized, fmt.Errorf("unknown JWT: %s", err)
+		return models.UserResponse{}, fmt.Errorf("cannot get user: %w", err)
 	}
 
-	if !validateEmail(email) {
-		return database.User{}, http.StatusBadRequest, fmt.Errorf("wrong email structure: %s", err)
+	if currentUser.HashedPassword != unsetPasswordHash {
+		err = userServ.confirmPassword(ctx, currentUser, requestedUser.CurrentPassword, ipAddress)
+		if err != nil {
+			return models.UserResponse{}, err
+		}
 	}
 
-	hashedPassword, err := auth.HashPassword(password)
+	if !validateEmail(requestedUser.Email) {
+		return models.UserResponse{}, validationError("wrong email structure")
+	}
+
+	err = userServ.validatePassword(ctx, requestedUser.Password, requestedUser.Email)
 	if err != nil {
-		return database.User{}, http.StatusInternalServerError, fmt.Errorf("cannot hash password: %s", err)
+		return models.UserResponse{}, err
 	}
 
-	dbUser, err := userServ.ApiConfig.Queries.UpdateUser(ctx, database.UpdateUserParams{ID: userID, Email: email, HashedPassword: hashedPassword})
+	hashedPassword, err := auth.HashPassword(requestedUser.Password, userServ.ApiConfig.PasswordHash)
 	if err != nil {
-		return database.User{}, http.StatusInternalServerError, fmt.Errorf("cannot update user: %s", err)
+		return models.UserResponse{}, fmt.Errorf("cannot hash password: %w", err)
+	}
+
+	dbUser, err := userServ.users.UpdateUser(ctx, database.UpdateUserParams{ID: currentUser.ID, Email: requestedUser.Email, HashedPassword: hashedPassword})
+	if errors.Is(err, repository.ErrDuplicate) {
+		return models.UserResponse{}, conflictError("user with this email already exists")
 	}
+	if err != nil {
+		return models.UserResponse{}, fmt.Errorf("cannot update user: %w", err)
+	}
+
+	return convertDBToUser(dbUser), nil
+}
 
-	return dbUser, http.StatusOK, nil
+// DeleteAccount schedules deletion of the caller's account after password confirmation.
+// The account is removed by DeleteScheduledUsers once the grace period is over, unless the user logs in before that.
+func (userServ *UserService) DeleteAccount(ctx context.Context, principal auth.Principal, password, ipAddress string) (models.AccountDeletionResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteAccount")
+	defer span.End()
+
+	err := requireFirstParty(principal)
+	if err != nil {
+		return models.AccountDeletionResponse{}, err
+	}
+
+	dbUser, err := userServ.users.GetUserByID(ctx, principal.UserID)
+	if err != nil {
+		return models.AccountDeletionResponse{}, fmt.Errorf("cannot get user: %w", err)
+	}
 
+	// accounts created through social login have no password, the first-party session is the only proof they have
+	if dbUser.HashedPassword != unsetPasswordHash {
+		err = userServ.confirmPassword(ctx, dbUser, password, ipAddress)
+		if err != nil {
+			return models.AccountDeletionResponse{}, err
+		}
+	}
+
+	deletionScheduledAt := time.Now().Add(userServ.ApiConfig.AccountDeletionGracePeriod)
+	err = userServ.users.ScheduleUserDeletion(ctx, database.ScheduleUserDeletionParams{ID: dbUser.ID, DeletionScheduledAt: deletionScheduledAt})
+	if err != nil {
+		return models.AccountDeletionResponse{}, fmt.Errorf("cannot schedule account deletion: %w", err)
+	}
+
+	err = userServ.refreshTokens.RevokeRefreshTokensForUser(ctx, dbUser.ID)
+	if err != nil {
+		return models.AccountDeletionResponse{}, fmt.Errorf("cannot revoke refresh tokens: %w", err)
+	}
+
+	return models.AccountDeletionResponse{DeletionScheduledAt: deletionScheduledAt}, nil
+}
+
+// confirmPassword is guarded like login, so a stolen access token cannot be used to guess the password
+func (userServ *UserService) confirmPassword(ctx context.Context, dbUser database.User, password, ipAddress string) error {
+	err := userServ.takeLoginAttempt(ctx, dbUser.Email, ipAddress)
+	if err != nil {
+		return err
+	}
+
+	err = auth.CheckPasswordHash(password, dbUser.HashedPassword)
+	passwordMatches := err == nil
+	err = userServ.recordLoginAttempt(ctx, dbUser.Email, ipAddress, uuid.NullUUID{UUID: dbUser.ID, Valid: true}, passwordMatches)
+	if err != nil {
+		return err
+	}
+	if !passwordMatches {
+		return unauthenticatedError("incorrect password")
+	}
+	return nil
+}
+
+// DeleteScheduledUsers removes accounts whose grace period is over, messages, tokens
+// and other user's data are removed by cascading foreign keys. Data exports are deleted first,
+// so their archives can be removed from storage, and login attempts, which outlive their users.
+func (userServ *UserService) DeleteScheduledUsers(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteScheduledUsers")
+	defer span.End()
+
+	now := time.Now()
+	exportKeys, err := userServ.users.DeleteDataExportsOfScheduledUsers(ctx, now)
+	if err != nil {
+		return fmt.Errorf("cannot delete data exports of scheduled users: %w", err)
+	}
+	userServ.deleteExportArchives(ctx, exportKeys)
+
+	err = userServ.loginAttempts.DeleteLoginAttemptsOfScheduledUsers(ctx, now)
+	if err != nil {
+		return fmt.Errorf("cannot delete login attempts of scheduled users: %w", err)
+	}
+
+	deletedIDs, err := userServ.users.DeleteUsersScheduledForDeletion(ctx, now)
+	if err != nil {
+		return fmt.Errorf("cannot delete scheduled users: %w", err)
+	}
+	for _, userID := range deletedIDs {
+		logging.FromContext(ctx).Info("user was deleted after grace period", "user_id", userID)
+	}
+	return nil
+}
+
+// deleteExportArchives removes archives of deleted data exports, archives that cannot be deleted are only logged,
+// their exports are already gone, so retrying would not find them again
+func (userServ *UserService) deleteExportArchives(ctx context.Context, keys []sql.NullString) {
+	for _, key := range keys {
+		if !key.Valid {
+			continue
+		}
+		err := userServ.ApiConfig.Storage.Delete(ctx, key.String)
+		if err != nil {
+			logging.FromContext(ctx).Error("cannot delete export archive", "key", key.String, "error", err)
+		}
+	}
+}
+
+// validatePassword checks password against configured policy, policy violations are reported as bad request
+func (userServ *UserService) validatePassword(ctx context.Context, password, email string) error {
+	err := userServ.ApiConfig.PasswordPolicy.Validate(ctx, password, email)
+	var policyErr *auth.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		return wrapError(ErrorValidation, err)
+	}
+	if err != nil {
+		return err
+	}
+	return nil
 }
 
 func validateEmail(email string) bool {
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ech00wv/SNserver/internal/auth"
+	"github.com/ech00wv/SNserver/internal/config"
+	"github.com/ech00wv/SNserver/internal/database"
+	"github.com/ech00wv/SNserver/internal/models"
+	"github.com/ech00wv/SNserver/internal/repository"
+	"github.com/ech00wv/SNserver/internal/storage"
+	"github.com/google/uuid"
+)
+
+func newTestUserService(apiCfg *config.ApiConfig) *UserService {
+	repos := apiCfg.Repositories
+	return NewUserService(apiCfg, repos.Users, repos.LoginAttempts, repos.RefreshTokens)
+}
+
+// createExportArchive builds data export of the user and returns storage key of its archive
+func createExportArchive(t *testing.T, apiCfg *config.ApiConfig, user database.User) string {
+	t.Helper()
+	exportServ := NewDataExportService(apiCfg, apiCfg.Queries, apiCfg.Repositories)
+	export, err := exportServ.RequestExport(context.Background(), auth.Principal{UserID: user.ID, TokenType: auth.TokenTypeSession})
+	if err != nil {
+		t.Fatalf("cannot request export: %s", err)
+	}
+	err = exportServ.ProcessExports(context.Background())
+	if err != nil {
+		t.Fatalf("cannot process exports: %s", err)
+	}
+
+	dbExport, err := apiCfg.Queries.GetDataExport(context.Background(), export.ID)
+	if err != nil {
+		t.Fatalf("cannot get export: %s", err)
+	}
+	if dbExport.Status != DataExportStatusCompleted {
+		t.Fatalf("export is %s, want %s", dbExport.Status, DataExportStatusCompleted)
+	}
+	archive, err := apiCfg.Storage.Open(context.Background(), dbExport.StorageKey.String)
+	if err != nil {
+		t.Fatalf("cannot open archive: %s", err)
+	}
+	archive.Close()
+	return dbExport.StorageKey.String
+}
+
+func requireArchiveDeleted(t *testing.T, apiCfg *config.ApiConfig, key string) {
+	t.Helper()
+	_, err := apiCfg.Storage.Open(context.Background(), key)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("archive %s was not deleted: %v", key, err)
+	}
+}
+
+func TestDeleteScheduledUsersRemovesExportArchives(t *testing.T) {
+	apiCfg := newTestApiConfig(t)
+	userServ := newTestUserService(apiCfg)
+	ctx := context.Background()
+
+	deletedUser, err := apiCfg.Queries.CreateUser(ctx, database.CreateUserParams{Email: "deleted@example.com", HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+	keptUser, err := apiCfg.Queries.CreateUser(ctx, database.CreateUserParams{Email: "kept@example.com", HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+	deletedKey := createExportArchive(t, apiCfg, deletedUser)
+	keptKey := createExportArchive(t, apiCfg, keptUser)
+
+	err = apiCfg.Queries.ScheduleUserDeletion(ctx, database.ScheduleUserDeletionParams{ID: deletedUser.ID, DeletionScheduledAt: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("cannot schedule deletion: %s", err)
+	}
+	err = apiCfg.Queries.ScheduleUserDeletion(ctx, database.ScheduleUserDeletionParams{ID: keptUser.ID, DeletionScheduledAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("cannot schedule deletion: %s", err)
+	}
+
+	err = userServ.DeleteScheduledUsers(ctx)
+	if err != nil {
+		t.Fatalf("cannot delete scheduled users: %s", err)
+	}
+
+	_, err = apiCfg.Queries.GetUserByID(ctx, deletedUser.ID)
+	if !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("scheduled user was not deleted: %v", err)
+	}
+	requireArchiveDeleted(t, apiCfg, deletedKey)
+
+	_, err = apiCfg.Queries.GetUserByID(ctx, keptUser.ID)
+	if err != nil {
+		t.Fatalf("user in grace period was deleted: %s", err)
+	}
+	archive, err := apiCfg.Storage.Open(ctx, keptKey)
+	if err != nil {
+		t.Fatalf("archive of user in grace period was deleted: %s", err)
+	}
+	archive.Close()
+}
+
+func TestDeleteUsersRemovesExportArchives(t *testing.T) {
+	apiCfg := newTestApiConfig(t)
+	userServ := newTestUserService(apiCfg)
+
+	user, err := apiCfg.Queries.CreateUser(context.Background(), database.CreateUserParams{Email: "user@example.com", HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+	key := createExportArchive(t, apiCfg, user)
+
+	err = userServ.DeleteUsers(context.Background())
+	if err != nil {
+		t.Fatalf("cannot delete users: %s", err)
+	}
+	requireArchiveDeleted(t, apiCfg, key)
+}
+
+func TestDeleteAccountWithoutPassword(t *testing.T) {
+	apiCfg := newTestApiConfig(t)
+	userServ := newTestUserService(apiCfg)
+	ctx := context.Background()
+
+	socialUser, err := apiCfg.Queries.CreateUser(ctx, database.CreateUserParams{Email: "social@example.com", HashedPassword: unsetPasswordHash})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+	_, err = userServ.DeleteAccount(ctx, auth.Principal{UserID: socialUser.ID, TokenType: auth.TokenTypeSession}, "", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("account without password cannot be deleted: %s", err)
+	}
+	dbUser, err := apiCfg.Queries.GetUserByID(ctx, socialUser.ID)
+	if err != nil {
+		t.Fatalf("cannot get user: %s", err)
+	}
+	if !dbUser.DeletionScheduledAt.Valid {
+		t.Fatalf("deletion of account without password was not scheduled")
+	}
+
+	hash, err := auth.HashPassword("Correct-horse-98battery", apiCfg.PasswordHash)
+	if err != nil {
+		t.Fatalf("cannot hash password: %s", err)
+	}
+	passwordUser, err := apiCfg.Queries.CreateUser(ctx, database.CreateUserParams{Email: "password@example.com", HashedPassword: hash})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+	_, err = userServ.DeleteAccount(ctx, auth.Principal{UserID: passwordUser.ID, TokenType: auth.TokenTypeSession}, "", "127.0.0.1")
+	requireErrorKind(t, err, ErrorUnauthenticated)
+}
+
+func TestDeleteScheduledUsersRemovesLoginAttempts(t *testing.T) {
+	apiCfg := newTestApiConfig(t)
+	userServ := newTestUserService(apiCfg)
+	ctx := context.Background()
+
+	user, err := apiCfg.Queries.CreateUser(ctx, database.CreateUserParams{Email: "deleted@example.com", HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+	for _, attempt := range []database.CreateLoginAttemptParams{
+		{Email: user.Email, IpAddress: "10.0.0.1", UserID: uuid.NullUUID{UUID: user.ID, Valid: true}, Succeeded: true},
+		{Email: user.Email, IpAddress: "10.0.0.2", Succeeded: false},
+		{Email: "other@example.com", IpAddress: "10.0.0.3", Succeeded: false},
+	} {
+		err = apiCfg.Queries.CreateLoginAttempt(ctx, attempt)
+		if err != nil {
+			t.Fatalf("cannot create login attempt: %s", err)
+		}
+	}
+
+	err = apiCfg.Queries.ScheduleUserDeletion(ctx, database.ScheduleUserDeletionParams{ID: user.ID, DeletionScheduledAt: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("cannot schedule deletion: %s", err)
+	}
+	err = userServ.DeleteScheduledUsers(ctx)
+	if err != nil {
+		t.Fatalf("cannot delete scheduled users: %s", err)
+	}
+
+	attempts, err := apiCfg.Queries.GetLoginAttempts(ctx, 10)
+	if err != nil {
+		t.Fatalf("cannot get login attempts: %s", err)
+	}
+	if len(attempts) != 1 || attempts[0].Email != "other@example.com" {
+		t.Fatalf("unexpected login attempts left: %+v", attempts)
+	}
+}
+
+func TestCreateUserWithTakenEmail(t *testing.T) {
+	for name, newApiConfig := range map[string]func(t *testing.T) *config.ApiConfig{
+		"sqlite": func(t *testing.T) *config.ApiConfig { return newTestApiConfig(t) },
+		"memory": func(t *testing.T) *config.ApiConfig {
+			apiCfg := newTestApiConfig(t)
+			apiCfg.Repositories = repository.NewMemory().Repositories()
+			return apiCfg
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			userServ := newTestUserService(newApiConfig(t))
+			request := models.UserRequest{Email: "user@example.com", Password: "Correct-horse-98battery"}
+
+			_, err := userServ.CreateUser(context.Background(), request)
+			if err != nil {
+				t.Fatalf("cannot create user: %s", err)
+			}
+			_, err = userServ.CreateUser(context.Background(), request)
+			requireErrorKind(t, err, ErrorConflict)
+		})
+	}
+}
+
+func TestLoginAttemptsInDemoMode(t *testing.T) {
+	apiCfg := newDemoTestApiConfig(t)
+	ctx := context.Background()
+	userServ := newTestUserService(apiCfg)
+	adminServ := NewAdminService(apiCfg, apiCfg.Repositories.LoginAttempts)
+	user := createDemoUser(t, userServ, "user@example.com")
+
+	_, err := userServ.LoginUser(ctx, models.UserRequest{Email: "user@example.com", Password: "Correct-horse-98battery"}, "192.0.2.1")
+	if err != nil {
+		t.Fatalf("cannot log in: %s", err)
+	}
+	_, err = userServ.LoginUser(ctx, models.UserRequest{Email: "other@example.com", Password: "wrong-password"}, "192.0.2.2")
+	requireErrorKind(t, err, ErrorUnauthenticated)
+
+	admin := auth.Principal{UserID: user.UserID, Roles: []string{auth.RoleAdmin}, TokenType: auth.TokenTypeSession}
+	attempts, err := adminServ.GetLoginAttempts(ctx, admin, "", "")
+	if err != nil {
+		t.Fatalf("cannot get login attempts: %s", err)
+	}
+	if len(attempts) != 2 || attempts[0].Email != "other@example.com" || !attempts[1].Succeeded {
+		t.Fatalf("unexpected login attempts: %+v", attempts)
+	}
+
+	attempts, err = adminServ.GetLoginAttempts(ctx, admin, "user@example.com", "")
+	if err != nil {
+		t.Fatalf("cannot get login attempts for email: %s", err)
+	}
+	if len(attempts) != 1 || attempts[0].Email != "user@example.com" {
+		t.Fatalf("unexpected login attempts for email: %+v", attempts)
+	}
+}
+
+func TestUpdateUserRequiresCurrentPassword(t *testing.T) {
+	apiCfg := newTestApiConfig(t)
+	userServ := newTestUserService(apiCfg)
+	ctx := context.Background()
+	user := createDemoUser(t, userServ, "user@example.com")
+	request := models.UpdateUserRequest{Email: "new@example.com", Password: "Battery-staple-42horse"}
+
+	_, err := userServ.UpdateUser(ctx, user, request, "127.0.0.1")
+	requireErrorKind(t, err, ErrorUnauthenticated)
+	request.CurrentPassword = "wrong-password"
+	_, err = userServ.UpdateUser(ctx, user, request, "127.0.0.1")
+	requireErrorKind(t, err, ErrorUnauthenticated)
+
+	oauthToken := auth.Principal{UserID: user.UserID, TokenType: auth.TokenTypeOAuth, ClientID: "client", Scopes: auth.Scopes}
+	request.CurrentPassword = "Correct-horse-98battery"
+	_, err = userServ.UpdateUser(ctx, oauthToken, request, "127.0.0.1")
+	requireErrorKind(t, err, ErrorForbidden)
+
+	updatedUser, err := userServ.UpdateUser(ctx, user, request, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("cannot update user: %s", err)
+	}
+	if updatedUser.Email != "new@example.com" {
+		t.Fatalf("email was not changed: %s", updatedUser.Email)
+	}
+	_, err = userServ.LoginUser(ctx, models.UserRequest{Email: "new@example.com", Password: "Battery-staple-42horse"}, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("cannot log in with new credentials: %s", err)
+	}
+}
+
+func TestLoginWithoutPasswordTakesAsLongAsUnknownEmail(t *testing.T) {
+	apiCfg := newTestApiConfig(t)
+	userServ := newTestUserService(apiCfg)
+	ctx := context.Background()
+	_, err := apiCfg.Queries.CreateUser(ctx, database.CreateUserParams{Email: "social@example.com", HashedPassword: unsetPasswordHash})
+	if err != nil {
+		t.Fatalf("cannot create user: %s", err)
+	}
+	// parameters expensive enough for hashing to dominate the response time
+	apiCfg.PasswordHash = auth.Argon2Params{MemoryKiB: 32 * 1024, Iterations: 3, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+	login := func(email, ipAddress string) time.Duration {
+		start := time.Now()
+		_, err := userServ.LoginUser(ctx, models.UserRequest{Email: email, Password: "Correct-horse-98battery"}, ipAddress)
+		requireErrorKind(t, err, ErrorUnauthenticated)
+		return time.Since(start)
+	}
+	login("warm-up@example.com", "192.0.2.1")
+	unknownEmail := login("unknown@example.com", "192.0.2.2")
+	socialAccount := login("social@example.com", "192.0.2.3")
+	if socialAccount < unknownEmail/4 {
+		t.Fatalf("login into account without password took %s, login with unknown email took %s", socialAccount, unknownEmail)
+	}
+}
+
+func TestLoginForConsentIssuesNoRefreshToken(t *testing.T) {
+	apiCfg := newTestApiConfig(t)
+	userServ := newTestUserService(apiCfg)
+	ctx := context.Background()
+	user := createDemoUser(t, userServ, "user@example.com")
+
+	session, err := userServ.LoginForConsent(ctx, models.UserRequest{Email: "user@example.com", Password: "Correct-horse-98battery"}, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("cannot log in: %s", err)
+	}
+	if session.Token == "" || session.RefreshToken != "" {
+		t.Fatalf("expected access token only, got %+v", session)
+	}
+	refreshTokens, err := apiCfg.Queries.GetRefreshTokensForUser(ctx, user.UserID)
+	if err != nil {
+		t.Fatalf("cannot get refresh tokens: %s", err)
+	}
+	if len(refreshTokens) != 0 {
+		t.Fatalf("consent login left refresh tokens: %+v", refreshTokens)
+	}
+}
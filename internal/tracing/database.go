@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DBTX is the database interface used by sqlc generated queries
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+// WrapDB records a client span for every query run through db. Spans are named after sqlc query names,
+// e.g. "Queries.GetAllMessages". Query spans end when the query returns, reading rows is not included.
+// driver is the database/sql driver name of db ("postgres" or "sqlite"), it is recorded as db.system.
+func WrapDB(db DBTX, driver string) DBTX {
+	return tracedDB{db: db, system: dbSystem(driver)}
+}
+
+type tracedDB struct {
+	db     DBTX
+	system attribute.KeyValue
+}
+
+func dbSystem(driver string) attribute.KeyValue {
+	switch driver {
+	case "sqlite":
+		return semconv.DBSystemSqlite
+	case "postgres":
+		return semconv.DBSystemPostgreSQL
+	default:
+		return semconv.DBSystemOtherSQL
+	}
+}
+
+func (tracedDB tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, tracedDB.system, query)
+	defer span.End()
+	result, err := tracedDB.db.ExecContext(ctx, query, args...)
+	recordError(span, err)
+	return result, err
+}
+
+func (tracedDB tracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := startQuerySpan(ctx, tracedDB.system, query)
+	defer span.End()
+	stmt, err := tracedDB.db.PrepareContext(ctx, query)
+	recordError(span, err)
+	return stmt, err
+}
+
+func (tracedDB tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, tracedDB.system, query)
+	defer span.End()
+	rows, err := tracedDB.db.QueryContext(ctx, query, args...)
+	recordError(span, err)
+	return rows, err
+}
+
+func (tracedDB tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuerySpan(ctx, tracedDB.system, query)
+	defer span.End()
+	row := tracedDB.db.QueryRowContext(ctx, query, args...)
+	recordError(span, row.Err())
+	return row
+}
+
+func startQuerySpan(ctx context.Context, system attribute.KeyValue, query string) (context.Context, trace.Span) {
+	name := queryName(query)
+	return Start(ctx, "Queries."+name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		system,
+		semconv.DBOperationName(name),
+		semconv.DBQueryText(query),
+	))
+}
+
+// queryName reads name from the "-- name: GetUser :one" comment that sqlc puts before every query
+func queryName(query string) string {
+	fields := strings.Fields(strings.TrimPrefix(query, "-- name:"))
+	if len(fields) == 0 || !strings.HasPrefix(query, "-- name:") {
+		return "Query"
+	}
+	return fields[0]
+}
+
+func recordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ech00wv/SNserver/internal/httpstatus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware continues trace from the traceparent header (or starts a new one) and records a server span
+// for every request. Spans are named after the pattern of routes matching the request, e.g. "GET /api/messages".
+func Middleware(routes *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		attributes := []attribute.KeyValue{
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.URLPath(req.URL.Path),
+		}
+		spanName := req.Method
+		if _, pattern := routes.Handler(req); pattern != "" {
+			spanName = pattern
+			attributes = append(attributes, semconv.HTTPRoute(pattern[strings.Index(pattern, "/"):]))
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+		ctx, span := Start(ctx, spanName, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attributes...))
+		defer span.End()
+
+		recorder := httpstatus.NewRecorder(rw)
+		next.ServeHTTP(recorder, req.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(recorder.Status()))
+		if recorder.Status() >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.Status()))
+		}
+	})
+}
@@ -0,0 +1,51 @@
+-- name: CreateBlock :exec
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES ($1, $2, CURRENT_TIMESTAMP)
+ON CONFLICT DO NOTHING;
+
+
+-- name: DeleteBlock :exec
+DELETE FROM blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+
+-- name: GetBlocksForUser :many
+SELECT * FROM blocks
+WHERE blocker_id = $1
+ORDER BY created_at DESC;
+
+
+-- name: CheckBlockBetween :one
+SELECT EXISTS(
+    SELECT 1
+    FROM blocks
+    WHERE (blocks.blocker_id = sqlc.arg(first_user_id) AND blocks.blocked_id = sqlc.arg(second_user_id))
+        OR (blocks.blocker_id = sqlc.arg(second_user_id) AND blocks.blocked_id = sqlc.arg(first_user_id))
+) AS blocked;
+
+
+-- name: CreateMute :exec
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES ($1, $2, CURRENT_TIMESTAMP)
+ON CONFLICT DO NOTHING;
+
+
+-- name: DeleteMute :exec
+DELETE FROM mutes
+WHERE muter_id = $1 AND muted_id = $2;
+
+
+-- name: GetMutesForUser :many
+SELECT * FROM mutes
+WHERE muter_id = $1
+ORDER BY created_at DESC;
+
+
+-- name: GetHiddenAuthorsForUser :many
+SELECT blocks.blocked_id AS author_id FROM blocks WHERE blocks.blocker_id = sqlc.arg(user_id)
+UNION
+SELECT blocks.blocker_id FROM blocks WHERE blocks.blocked_id = sqlc.arg(user_id)
+UNION
+SELECT mutes.muted_id FROM mutes WHERE mutes.muter_id = sqlc.arg(user_id)
+UNION
+SELECT users.id FROM users WHERE users.status = 'shadow_banned' AND users.id <> sqlc.arg(user_id);
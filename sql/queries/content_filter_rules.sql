@@ -0,0 +1,19 @@
+-- name: GetContentFilterRules :many
+SELECT * FROM content_filter_rules
+ORDER BY created_at;
+
+
+-- name: CreateContentFilterRule :one
+INSERT INTO content_filter_rules (id, created_at, term, action)
+VALUES (
+    gen_random_uuid(),
+    CURRENT_TIMESTAMP,
+    $1,
+    $2
+) RETURNING *;
+
+
+-- name: DeleteContentFilterRule :one
+DELETE FROM content_filter_rules
+WHERE id = $1
+RETURNING id;
@@ -0,0 +1,68 @@
+-- name: CreateDataExport :one
+INSERT INTO data_exports (id, created_at, updated_at, user_id, status)
+VALUES (
+    gen_random_uuid(),
+    CURRENT_TIMESTAMP,
+    CURRENT_TIMESTAMP,
+    $1,
+    $2
+) RETURNING *;
+
+
+-- name: GetDataExport :one
+SELECT * FROM data_exports
+WHERE id = $1;
+
+
+-- name: GetActiveDataExportForUser :one
+SELECT * FROM data_exports
+WHERE user_id = $1 AND status IN ('pending', 'running')
+ORDER BY created_at DESC
+LIMIT 1;
+
+
+-- name: ClaimDataExport :one
+UPDATE data_exports
+SET status = 'running', updated_at = CURRENT_TIMESTAMP
+WHERE id = (
+    SELECT data_exports.id FROM data_exports
+    WHERE data_exports.status = 'pending'
+        OR (data_exports.status = 'running' AND data_exports.updated_at < sqlc.arg(stale_before)::timestamp)
+    ORDER BY data_exports.created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+
+-- name: CompleteDataExport :execrows
+UPDATE data_exports
+SET status = 'completed', storage_key = sqlc.arg(storage_key)::text, expires_at = sqlc.arg(expires_at)::timestamp,
+    completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+
+-- name: FailDataExport :exec
+UPDATE data_exports
+SET status = 'failed', error = sqlc.arg(error)::text, updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+
+-- name: DeleteExpiredDataExports :many
+DELETE FROM data_exports
+WHERE expires_at < sqlc.arg(now)::timestamp
+RETURNING storage_key;
+
+
+-- name: DeleteDataExportsOfScheduledUsers :many
+DELETE FROM data_exports
+WHERE user_id IN (
+    SELECT users.id FROM users
+    WHERE users.deletion_scheduled_at <= sqlc.arg(now)::timestamp
+)
+RETURNING storage_key;
+
+
+-- name: DeleteAllDataExports :many
+DELETE FROM data_exports
+RETURNING storage_key;
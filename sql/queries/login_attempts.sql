@@ -0,0 +1,38 @@
+-- name: CreateLoginAttempt :exec
+INSERT INTO login_attempts (id, created_at, email, ip_address, user_id, succeeded)
+VALUES (
+    gen_random_uuid(),
+    CURRENT_TIMESTAMP,
+    $1,
+    $2,
+    $3,
+    $4
+);
+
+
+-- name: GetLoginAttempts :many
+SELECT * FROM login_attempts
+ORDER BY created_at DESC
+LIMIT $1;
+
+
+-- name: GetLoginAttemptsForEmail :many
+SELECT * FROM login_attempts
+WHERE email = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+
+-- name: DeleteLoginAttemptsOfScheduledUsers :exec
+DELETE FROM login_attempts
+WHERE user_id IN (
+    SELECT users.id FROM users
+    WHERE users.deletion_scheduled_at <= sqlc.arg(now)::timestamp
+) OR email IN (
+    SELECT users.email FROM users
+    WHERE users.deletion_scheduled_at <= sqlc.arg(now)::timestamp
+);
+
+
+-- name: DeleteAllLoginAttempts :exec
+DELETE FROM login_attempts;
@@ -0,0 +1,39 @@
+-- name: TakeLoginAttempt :one
+-- counts the attempt as failed before the password is checked and returns the number of failed attempts with it,
+-- parallel attempts of the key wait for each other on its row. The count starts over after window_seconds
+-- without attempts. There is no row while the key is locked out, the lockout starts at one second after
+-- free_failures failed attempts and doubles with every next one. Database clock is used, so all instances agree on time.
+INSERT INTO login_throttles (key, failed_count, last_attempt_at)
+VALUES (sqlc.arg(key), 1, CURRENT_TIMESTAMP)
+ON CONFLICT (key) DO UPDATE SET
+    failed_count = CASE WHEN login_throttles.last_attempt_at < CURRENT_TIMESTAMP - make_interval(secs => sqlc.arg(window_seconds)::float8) THEN 1
+        ELSE login_throttles.failed_count + 1 END,
+    last_attempt_at = CURRENT_TIMESTAMP
+WHERE login_throttles.last_attempt_at + make_interval(secs => CASE WHEN login_throttles.failed_count < sqlc.arg(free_failures)::int THEN 0
+        ELSE LEAST(sqlc.arg(max_lockout_seconds)::float8, (1 << LEAST(login_throttles.failed_count - sqlc.arg(free_failures)::int, 20))::float8) END) <= CURRENT_TIMESTAMP
+RETURNING failed_count;
+
+
+-- name: GetLoginLockout :one
+-- returns seconds left until the key accepts attempts again, they are not positive if the key is not locked out
+SELECT EXTRACT(EPOCH FROM (last_attempt_at + make_interval(secs => CASE WHEN failed_count < sqlc.arg(free_failures)::int THEN 0
+        ELSE LEAST(sqlc.arg(max_lockout_seconds)::float8, (1 << LEAST(failed_count - sqlc.arg(free_failures)::int, 20))::float8) END) - CURRENT_TIMESTAMP))::float8 AS wait_seconds
+FROM login_throttles
+WHERE key = sqlc.arg(key);
+
+
+-- name: ReleaseLoginAttempt :exec
+-- takes back the attempt counted by TakeLoginAttempt when the password was not checked or was correct
+UPDATE login_throttles
+SET failed_count = failed_count - 1
+WHERE key = $1 AND failed_count > 0;
+
+
+-- name: DeleteLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE key = $1;
+
+
+-- name: DeleteIdleLoginThrottles :exec
+DELETE FROM login_throttles
+WHERE last_attempt_at < CURRENT_TIMESTAMP - make_interval(secs => sqlc.arg(idle_seconds)::float8);
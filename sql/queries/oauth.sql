@@ -0,0 +1,54 @@
+-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (id, created_at, updated_at, name, secret_hash, redirect_uris, scopes, user_id)
+VALUES (
+    $1,
+    CURRENT_TIMESTAMP,
+    CURRENT_TIMESTAMP,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+) RETURNING *;
+
+
+-- name: GetOAuthClient :one
+SELECT * FROM oauth_clients
+WHERE id = $1;
+
+
+-- name: GetOAuthClientsForUser :many
+SELECT * FROM oauth_clients
+WHERE user_id = $1
+ORDER BY created_at;
+
+
+-- name: DeleteOAuthClient :one
+DELETE FROM oauth_clients
+WHERE id = $1 AND user_id = $2
+RETURNING id;
+
+
+-- name: CreateOAuthAuthorizationCode :exec
+INSERT INTO oauth_authorization_codes (code_hash, created_at, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at)
+VALUES (
+    $1,
+    CURRENT_TIMESTAMP,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+);
+
+
+-- name: ConsumeOAuthAuthorizationCode :one
+DELETE FROM oauth_authorization_codes
+WHERE code_hash = $1 AND expires_at > CURRENT_TIMESTAMP
+RETURNING *;
+
+
+-- name: DeleteExpiredOAuthAuthorizationCodes :exec
+DELETE FROM oauth_authorization_codes
+WHERE expires_at <= CURRENT_TIMESTAMP;
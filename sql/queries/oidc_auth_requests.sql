@@ -0,0 +1,22 @@
+-- name: CreateOIDCAuthRequest :exec
+INSERT INTO oidc_auth_requests (state, created_at, provider, code_verifier, nonce, link_user_id, expires_at)
+VALUES (
+    $1,
+    CURRENT_TIMESTAMP,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+);
+
+
+-- name: ConsumeOIDCAuthRequest :one
+DELETE FROM oidc_auth_requests
+WHERE state = $1 AND expires_at > CURRENT_TIMESTAMP
+RETURNING *;
+
+
+-- name: DeleteExpiredOIDCAuthRequests :exec
+DELETE FROM oidc_auth_requests
+WHERE expires_at <= CURRENT_TIMESTAMP;
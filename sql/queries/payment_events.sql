@@ -0,0 +1,14 @@
+-- name: CreatePaymentEvent :exec
+INSERT INTO payment_events (id, created_at, user_id, event)
+VALUES (
+    gen_random_uuid(),
+    CURRENT_TIMESTAMP,
+    $1,
+    $2
+);
+
+
+-- name: GetPaymentEventsForUser :many
+SELECT * FROM payment_events
+WHERE user_id = $1
+ORDER BY created_at;
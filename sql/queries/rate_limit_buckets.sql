@@ -0,0 +1,16 @@
+-- name: TakeRateLimitToken :one
+-- refills the bucket for the time passed since the last request and takes one token if there is one,
+-- "allowed" tells whether the token was taken. Database clock is used, so all instances agree on time.
+INSERT INTO rate_limit_buckets (key, tokens, allowed, updated_at)
+VALUES (sqlc.arg(key), sqlc.arg(capacity)::float8 - 1, TRUE, CURRENT_TIMESTAMP)
+ON CONFLICT (key) DO UPDATE SET
+    tokens = LEAST(sqlc.arg(capacity)::float8, rate_limit_buckets.tokens + GREATEST(0, EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - rate_limit_buckets.updated_at)))::float8 * sqlc.arg(refill_rate)::float8)
+        - CASE WHEN LEAST(sqlc.arg(capacity)::float8, rate_limit_buckets.tokens + GREATEST(0, EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - rate_limit_buckets.updated_at)))::float8 * sqlc.arg(refill_rate)::float8) >= 1 THEN 1 ELSE 0 END,
+    allowed = LEAST(sqlc.arg(capacity)::float8, rate_limit_buckets.tokens + GREATEST(0, EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - rate_limit_buckets.updated_at)))::float8 * sqlc.arg(refill_rate)::float8) >= 1,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING tokens, allowed;
+
+
+-- name: DeleteIdleRateLimitBuckets :exec
+DELETE FROM rate_limit_buckets
+WHERE updated_at < CURRENT_TIMESTAMP - make_interval(secs => sqlc.arg(idle_seconds)::float8);
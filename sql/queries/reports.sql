@@ -0,0 +1,52 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, updated_at, reporter_id, target_type, reported_user_id, message_id, message_body, reason, details, status)
+VALUES (
+    gen_random_uuid(),
+    CURRENT_TIMESTAMP,
+    CURRENT_TIMESTAMP,
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    'open'
+) RETURNING *;
+
+
+-- name: CheckOpenReportExists :one
+SELECT EXISTS(
+    SELECT 1
+    FROM reports
+    WHERE reports.reporter_id = sqlc.arg(reporter_id)::uuid
+        AND reports.reported_user_id = sqlc.arg(reported_user_id)
+        AND reports.message_id IS NOT DISTINCT FROM sqlc.narg(message_id)
+        AND reports.status <> 'resolved'
+) AS exists;
+
+
+-- name: GetReport :one
+SELECT * FROM reports
+WHERE id = $1;
+
+
+-- name: GetReportsByStatus :many
+SELECT * FROM reports
+WHERE status = $1
+ORDER BY created_at
+LIMIT $2;
+
+
+-- name: ClaimReport :one
+UPDATE reports
+SET status = 'claimed', claimed_by = sqlc.arg(moderator_id)::uuid, claimed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND status = 'open'
+RETURNING *;
+
+
+-- name: ResolveReport :one
+UPDATE reports
+SET status = 'resolved', resolution = sqlc.arg(resolution)::text, resolved_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND status = 'claimed' AND claimed_by = sqlc.arg(moderator_id)::uuid
+RETURNING *;
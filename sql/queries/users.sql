@@ -39,3 +39,44 @@ SET is_premium = true
 WHERE id = $1
 RETURNING id; 
 
+
+
+-- name: GetUserAccess :one
+SELECT is_admin, deletion_scheduled_at, status, suspended_until FROM users
+WHERE id = $1;
+
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE users.id = $1;
+
+
+-- name: UpdateUserPasswordHash :exec
+UPDATE users
+SET hashed_password = $2
+WHERE id = $1;
+
+
+-- name: ScheduleUserDeletion :exec
+UPDATE users
+SET deletion_scheduled_at = sqlc.arg(deletion_scheduled_at)::timestamp, updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+
+-- name: CancelUserDeletion :exec
+UPDATE users
+SET deletion_scheduled_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND deletion_scheduled_at IS NOT NULL;
+
+
+-- name: DeleteUsersScheduledForDeletion :many
+DELETE FROM users
+WHERE deletion_scheduled_at <= sqlc.arg(now)::timestamp
+RETURNING id;
+
+
+-- name: SetUserStatus :one
+UPDATE users
+SET status = sqlc.arg(status), status_reason = sqlc.arg(status_reason), suspended_until = sqlc.narg(suspended_until), updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)
+RETURNING id, updated_at, status, status_reason, suspended_until;
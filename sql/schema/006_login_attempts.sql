@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE login_attempts(
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    email TEXT NOT NULL,
+    ip_address TEXT NOT NULL,
+    user_id UUID,
+    succeeded BOOLEAN NOT NULL,
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_login_attempts_email ON login_attempts(email, created_at);
+CREATE INDEX idx_login_attempts_ip ON login_attempts(ip_address, created_at);
+
+-- +goose Down
+DROP TABLE login_attempts;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users
+ADD is_admin boolean DEFAULT false NOT NULL;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN is_admin;
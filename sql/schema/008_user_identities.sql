@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE user_identities(
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT NOT NULL,
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT uq_provider_subject UNIQUE(provider, subject),
+    CONSTRAINT uq_user_provider UNIQUE(user_id, provider)
+);
+
+-- +goose Down
+DROP TABLE user_identities;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE oidc_auth_requests(
+    state TEXT PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    provider TEXT NOT NULL,
+    code_verifier TEXT NOT NULL,
+    nonce TEXT NOT NULL,
+    link_user_id UUID,
+    expires_at TIMESTAMP NOT NULL,
+    CONSTRAINT fk_user FOREIGN KEY(link_user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE oidc_auth_requests;
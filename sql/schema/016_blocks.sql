@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE blocks(
+    blocker_id UUID NOT NULL,
+    blocked_id UUID NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CONSTRAINT fk_blocker FOREIGN KEY(blocker_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_blocked FOREIGN KEY(blocked_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT chk_not_self CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX idx_blocks_blocked ON blocks(blocked_id);
+
+-- +goose Down
+DROP TABLE blocks;
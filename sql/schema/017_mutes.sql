@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE mutes(
+    muter_id UUID NOT NULL,
+    muted_id UUID NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (muter_id, muted_id),
+    CONSTRAINT fk_muter FOREIGN KEY(muter_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_muted FOREIGN KEY(muted_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT chk_not_self CHECK (muter_id <> muted_id)
+);
+
+-- +goose Down
+DROP TABLE mutes;
@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE reports(
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    reporter_id UUID,
+    target_type TEXT NOT NULL,
+    reported_user_id UUID NOT NULL,
+    message_id UUID,
+    message_body TEXT,
+    reason TEXT NOT NULL,
+    details TEXT NOT NULL,
+    status TEXT NOT NULL,
+    claimed_by UUID,
+    claimed_at TIMESTAMP,
+    resolution TEXT,
+    resolved_at TIMESTAMP,
+    CONSTRAINT fk_reporter FOREIGN KEY(reporter_id) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_reported_user FOREIGN KEY(reported_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_message FOREIGN KEY(message_id) REFERENCES messages(id) ON DELETE SET NULL,
+    CONSTRAINT fk_claimed_by FOREIGN KEY(claimed_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_reports_status ON reports(status, created_at);
+
+-- +goose Down
+DROP TABLE reports;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE moderation_actions(
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    moderator_id UUID,
+    report_id UUID,
+    action TEXT NOT NULL,
+    target_user_id UUID,
+    message_id UUID,
+    note TEXT NOT NULL,
+    CONSTRAINT fk_moderator FOREIGN KEY(moderator_id) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_report FOREIGN KEY(report_id) REFERENCES reports(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_moderation_actions_report ON moderation_actions(report_id, created_at);
+
+-- +goose Down
+DROP TABLE moderation_actions;
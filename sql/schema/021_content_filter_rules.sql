@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE content_filter_rules(
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    term TEXT UNIQUE NOT NULL,
+    action TEXT NOT NULL
+);
+
+INSERT INTO content_filter_rules (term, action) VALUES
+    ('kerfuffle', 'mask'),
+    ('sharbert', 'mask'),
+    ('fornax', 'mask');
+
+-- +goose Down
+DROP TABLE content_filter_rules;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN status TEXT NOT NULL DEFAULT 'active',
+ADD COLUMN status_reason TEXT NOT NULL DEFAULT '';
+
+UPDATE users
+SET status = 'suspended'
+WHERE suspended_until > CURRENT_TIMESTAMP;
+
+CREATE INDEX idx_users_shadow_banned ON users(id) WHERE status = 'shadow_banned';
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN IF EXISTS status,
+DROP COLUMN IF EXISTS status_reason;
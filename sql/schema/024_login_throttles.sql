@@ -0,0 +1,13 @@
+-- +goose Up
+-- failed login counters of emails and ip addresses, a row is updated by every attempt,
+-- so parallel attempts of one key are serialized by its row lock
+CREATE TABLE login_throttles(
+    key TEXT PRIMARY KEY,
+    failed_count INTEGER NOT NULL,
+    last_attempt_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_login_throttles_last_attempt_at ON login_throttles(last_attempt_at);
+
+-- +goose Down
+DROP TABLE login_throttles;
@@ -0,0 +1,32 @@
+// Package schema embeds goose migrations, so the server knows which migration the database must be at
+package schema
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var Migrations embed.FS
+
+// LatestVersion returns number of the newest migration, e.g. 23 for 023_rate_limit_buckets.sql
+func LatestVersion() (int64, error) {
+	names, err := fs.Glob(Migrations, "*.sql")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest int64
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migration %s does not start with version number", name)
+		}
+		latest = max(latest, version)
+	}
+	return latest, nil
+}
@@ -0,0 +1,19 @@
+-- name: GetContentFilterRules :many
+SELECT * FROM content_filter_rules
+ORDER BY created_at;
+
+
+-- name: CreateContentFilterRule :one
+INSERT INTO content_filter_rules (id, created_at, term, action)
+VALUES (
+    ?,
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    ?,
+    ?
+) RETURNING *;
+
+
+-- name: DeleteContentFilterRule :one
+DELETE FROM content_filter_rules
+WHERE id = ?
+RETURNING id;
@@ -0,0 +1,68 @@
+-- name: CreateDataExport :one
+INSERT INTO data_exports (id, created_at, updated_at, user_id, status)
+VALUES (
+    ?,
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    ?,
+    ?
+) RETURNING *;
+
+
+-- name: GetDataExport :one
+SELECT * FROM data_exports
+WHERE id = ?;
+
+
+-- name: GetActiveDataExportForUser :one
+SELECT * FROM data_exports
+WHERE user_id = ? AND status IN ('pending', 'running')
+ORDER BY created_at DESC
+LIMIT 1;
+
+
+-- name: ClaimDataExport :one
+-- SQLite has a single writer, so the export cannot be claimed twice without row locks
+UPDATE data_exports
+SET status = 'running', updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE id = (
+    SELECT data_exports.id FROM data_exports
+    WHERE data_exports.status = 'pending'
+        OR (data_exports.status = 'running' AND data_exports.updated_at < sqlc.arg(stale_before))
+    ORDER BY data_exports.created_at
+    LIMIT 1
+)
+RETURNING *;
+
+
+-- name: CompleteDataExport :execrows
+UPDATE data_exports
+SET status = 'completed', storage_key = sqlc.arg(storage_key), expires_at = sqlc.arg(expires_at),
+    completed_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'), updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE id = sqlc.arg(id);
+
+
+-- name: FailDataExport :exec
+UPDATE data_exports
+SET status = 'failed', error = sqlc.arg(error), updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE id = sqlc.arg(id);
+
+
+-- name: DeleteExpiredDataExports :many
+DELETE FROM data_exports
+WHERE expires_at < sqlc.arg(now)
+RETURNING storage_key;
+
+
+-- name: DeleteDataExportsOfScheduledUsers :many
+DELETE FROM data_exports
+WHERE user_id IN (
+    SELECT users.id FROM users
+    WHERE users.deletion_scheduled_at <= sqlc.arg(now)
+)
+RETURNING storage_key;
+
+
+-- name: DeleteAllDataExports :many
+DELETE FROM data_exports
+RETURNING storage_key;
@@ -0,0 +1,38 @@
+-- name: CreateLoginAttempt :exec
+INSERT INTO login_attempts (id, created_at, email, ip_address, user_id, succeeded)
+VALUES (
+    ?,
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    ?,
+    ?,
+    ?,
+    ?
+);
+
+
+-- name: GetLoginAttempts :many
+SELECT * FROM login_attempts
+ORDER BY created_at DESC
+LIMIT ?;
+
+
+-- name: GetLoginAttemptsForEmail :many
+SELECT * FROM login_attempts
+WHERE email = ?
+ORDER BY created_at DESC
+LIMIT ?;
+
+
+-- name: DeleteLoginAttemptsOfScheduledUsers :exec
+DELETE FROM login_attempts
+WHERE user_id IN (
+    SELECT users.id FROM users
+    WHERE users.deletion_scheduled_at <= sqlc.arg(now)
+) OR email IN (
+    SELECT users.email FROM users
+    WHERE users.deletion_scheduled_at <= sqlc.arg(now)
+);
+
+
+-- name: DeleteAllLoginAttempts :exec
+DELETE FROM login_attempts;
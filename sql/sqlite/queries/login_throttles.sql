@@ -0,0 +1,45 @@
+-- name: CreateLoginThrottle :one
+-- creates the counter with the first failed attempt, there is no row if the counter exists
+INSERT INTO login_throttles (key, failed_count, last_attempt_at)
+VALUES (sqlc.arg(key), 1, strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+ON CONFLICT (key) DO NOTHING
+RETURNING failed_count;
+
+
+-- name: TakeLoginAttempt :one
+-- counts the attempt as failed before the password is checked and returns the number of failed attempts with it.
+-- The count starts over after window_seconds without attempts. There is no row while the key is locked out
+-- and if the counter does not exist.
+UPDATE login_throttles
+SET failed_count = CASE WHEN julianday(last_attempt_at) < julianday('now') - CAST(sqlc.arg(window_seconds) AS REAL) / 86400 THEN 1
+        ELSE failed_count + 1 END,
+    last_attempt_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE key = sqlc.arg(key)
+AND julianday(last_attempt_at) + CASE WHEN failed_count < CAST(sqlc.arg(free_failures) AS INTEGER) THEN 0
+        ELSE MIN(CAST(sqlc.arg(max_lockout_seconds) AS REAL), 1 << MIN(failed_count - CAST(sqlc.arg(free_failures) AS INTEGER), 20)) END / 86400.0 <= julianday('now')
+RETURNING failed_count;
+
+
+-- name: GetLoginLockout :one
+-- returns seconds left until the key accepts attempts again, they are not positive if the key is not locked out
+SELECT CAST((julianday(last_attempt_at) - julianday('now')) * 86400 + CASE WHEN failed_count < CAST(sqlc.arg(free_failures) AS INTEGER) THEN 0
+        ELSE MIN(CAST(sqlc.arg(max_lockout_seconds) AS REAL), 1 << MIN(failed_count - CAST(sqlc.arg(free_failures) AS INTEGER), 20)) END AS REAL) AS wait_seconds
+FROM login_throttles
+WHERE key = sqlc.arg(key);
+
+
+-- name: ReleaseLoginAttempt :exec
+-- takes back the attempt counted by TakeLoginAttempt when the password was not checked or was correct
+UPDATE login_throttles
+SET failed_count = failed_count - 1
+WHERE key = ? AND failed_count > 0;
+
+
+-- name: DeleteLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE key = ?;
+
+
+-- name: DeleteIdleLoginThrottles :exec
+DELETE FROM login_throttles
+WHERE julianday(last_attempt_at) < julianday('now') - CAST(sqlc.arg(idle_seconds) AS REAL) / 86400;
@@ -0,0 +1,28 @@
+-- name: CreateMessage :one
+INSERT INTO messages(id, created_at, updated_at, body, user_id)
+VALUES (
+    ?,
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    ?,
+    ?
+) RETURNING *;
+
+-- name: GetAllMessages :many
+SELECT * FROM messages;
+
+
+-- name: GetMessage :one
+SELECT * FROM messages
+where messages.id = ?;
+
+
+
+-- name: DeleteMessage :one
+DELETE FROM messages
+WHERE id = ? AND user_id = ?
+RETURNING id;
+
+-- name: GetAllMessagesForAuthor :many
+SELECT * FROM messages
+WHERE user_id = ?;
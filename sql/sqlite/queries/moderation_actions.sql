@@ -0,0 +1,24 @@
+-- name: CreateModerationAction :exec
+INSERT INTO moderation_actions (id, created_at, moderator_id, report_id, action, target_user_id, message_id, note)
+VALUES (
+    ?,
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?
+);
+
+
+-- name: GetModerationActions :many
+SELECT * FROM moderation_actions
+ORDER BY created_at DESC
+LIMIT ?;
+
+
+-- name: GetModerationActionsForReport :many
+SELECT * FROM moderation_actions
+WHERE report_id = ?
+ORDER BY created_at;
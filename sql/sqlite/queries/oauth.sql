@@ -0,0 +1,54 @@
+-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (id, created_at, updated_at, name, secret_hash, redirect_uris, scopes, user_id)
+VALUES (
+    ?,
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    ?,
+    ?,
+    ?,
+    ?,
+    ?
+) RETURNING *;
+
+
+-- name: GetOAuthClient :one
+SELECT * FROM oauth_clients
+WHERE id = ?;
+
+
+-- name: GetOAuthClientsForUser :many
+SELECT * FROM oauth_clients
+WHERE user_id = ?
+ORDER BY created_at;
+
+
+-- name: DeleteOAuthClient :one
+DELETE FROM oauth_clients
+WHERE id = ? AND user_id = ?
+RETURNING id;
+
+
+-- name: CreateOAuthAuthorizationCode :exec
+INSERT INTO oauth_authorization_codes (code_hash, created_at, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at)
+VALUES (
+    ?,
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?
+);
+
+
+-- name: ConsumeOAuthAuthorizationCode :one
+DELETE FROM oauth_authorization_codes
+WHERE code_hash = ? AND expires_at > strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+RETURNING *;
+
+
+-- name: DeleteExpiredOAuthAuthorizationCodes :exec
+DELETE FROM oauth_authorization_codes
+WHERE expires_at <= strftime('%Y-%m-%d %H:%M:%f+00:00', 'now');
@@ -0,0 +1,22 @@
+-- name: CreateOIDCAuthRequest :exec
+INSERT INTO oidc_auth_requests (state, created_at, provider, code_verifier, nonce, link_user_id, expires_at)
+VALUES (
+    ?,
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    ?,
+    ?,
+    ?,
+    ?,
+    ?
+);
+
+
+-- name: ConsumeOIDCAuthRequest :one
+DELETE FROM oidc_auth_requests
+WHERE state = ? AND expires_at > strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+RETURNING *;
+
+
+-- name: DeleteExpiredOIDCAuthRequests :exec
+DELETE FROM oidc_auth_requests
+WHERE expires_at <= strftime('%Y-%m-%d %H:%M:%f+00:00', 'now');
@@ -0,0 +1,14 @@
+-- name: CreatePaymentEvent :exec
+INSERT INTO payment_events (id, created_at, user_id, event)
+VALUES (
+    ?,
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    ?,
+    ?
+);
+
+
+-- name: GetPaymentEventsForUser :many
+SELECT * FROM payment_events
+WHERE user_id = ?
+ORDER BY created_at;
@@ -0,0 +1,35 @@
+-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens (id, created_at, updated_at, user_id, name, token_hash, scopes, expires_at)
+VALUES (
+    ?,
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    ?,
+    ?,
+    ?,
+    ?,
+    ?
+) RETURNING *;
+
+
+-- name: GetPersonalAccessTokensForUser :many
+SELECT * FROM personal_access_tokens
+WHERE user_id = ?
+ORDER BY created_at;
+
+
+-- name: GetActivePersonalAccessToken :one
+SELECT * FROM personal_access_tokens
+WHERE token_hash = ? AND (expires_at IS NULL OR expires_at > strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'));
+
+
+-- name: TouchPersonalAccessToken :exec
+UPDATE personal_access_tokens
+SET last_used_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE id = ?;
+
+
+-- name: DeletePersonalAccessToken :one
+DELETE FROM personal_access_tokens
+WHERE id = ? AND user_id = ?
+RETURNING id;
@@ -0,0 +1,23 @@
+-- name: CreateRateLimitBucket :one
+-- creates a full bucket and takes one token from it, there is no row if the bucket exists
+INSERT INTO rate_limit_buckets (key, tokens, allowed, updated_at)
+VALUES (sqlc.arg(key), CAST(sqlc.arg(capacity) AS REAL) - 1, TRUE, strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+ON CONFLICT (key) DO NOTHING
+RETURNING tokens, allowed;
+
+
+-- name: TakeRateLimitToken :one
+-- refills the bucket for the time passed since the last request and takes one token if there is one,
+-- "allowed" tells whether the token was taken. There is no row if the bucket does not exist.
+UPDATE rate_limit_buckets
+SET tokens = MIN(CAST(sqlc.arg(capacity) AS REAL), tokens + MAX(0, (julianday('now') - julianday(updated_at)) * 86400) * CAST(sqlc.arg(refill_rate) AS REAL))
+        - CASE WHEN MIN(CAST(sqlc.arg(capacity) AS REAL), tokens + MAX(0, (julianday('now') - julianday(updated_at)) * 86400) * CAST(sqlc.arg(refill_rate) AS REAL)) >= 1 THEN 1 ELSE 0 END,
+    allowed = MIN(CAST(sqlc.arg(capacity) AS REAL), tokens + MAX(0, (julianday('now') - julianday(updated_at)) * 86400) * CAST(sqlc.arg(refill_rate) AS REAL)) >= 1,
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE key = sqlc.arg(key)
+RETURNING tokens, allowed;
+
+
+-- name: DeleteIdleRateLimitBuckets :exec
+DELETE FROM rate_limit_buckets
+WHERE julianday(updated_at) < julianday('now') - CAST(sqlc.arg(idle_seconds) AS REAL) / 86400;
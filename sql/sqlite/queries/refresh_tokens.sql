@@ -0,0 +1,31 @@
+-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at) values (
+    ?,
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    ?,
+    ?
+);
+
+
+-- name: GetUserFromRefreshToken :one
+SELECT user_id FROM refresh_tokens
+WHERE expires_at > strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') AND revoked_at IS NULL AND token = ?;
+
+
+-- name: RevokeRefreshToken :exec
+UPDATE refresh_tokens
+SET revoked_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'), updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE token = ?;
+
+
+-- name: RevokeRefreshTokensForUser :exec
+UPDATE refresh_tokens
+SET revoked_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'), updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE user_id = ? AND revoked_at IS NULL;
+
+
+-- name: GetRefreshTokensForUser :many
+SELECT created_at, expires_at, revoked_at FROM refresh_tokens
+WHERE user_id = ?
+ORDER BY created_at;
@@ -0,0 +1,52 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, updated_at, reporter_id, target_type, reported_user_id, message_id, message_body, reason, details, status)
+VALUES (
+    ?,
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    'open'
+) RETURNING *;
+
+
+-- name: CheckOpenReportExists :one
+SELECT EXISTS(
+    SELECT 1
+    FROM reports
+    WHERE reports.reporter_id = sqlc.arg(reporter_id)
+        AND reports.reported_user_id = sqlc.arg(reported_user_id)
+        AND reports.message_id IS sqlc.narg(message_id)
+        AND reports.status <> 'resolved'
+) AS "exists";
+
+
+-- name: GetReport :one
+SELECT * FROM reports
+WHERE id = ?;
+
+
+-- name: GetReportsByStatus :many
+SELECT * FROM reports
+WHERE status = ?
+ORDER BY created_at
+LIMIT ?;
+
+
+-- name: ClaimReport :one
+UPDATE reports
+SET status = 'claimed', claimed_by = sqlc.arg(moderator_id), claimed_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'), updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE id = sqlc.arg(id) AND status = 'open'
+RETURNING *;
+
+
+-- name: ResolveReport :one
+UPDATE reports
+SET status = 'resolved', resolution = sqlc.arg(resolution), resolved_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'), updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE id = sqlc.arg(id) AND status = 'claimed' AND claimed_by = sqlc.arg(moderator_id)
+RETURNING *;
@@ -0,0 +1,22 @@
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (id, created_at, updated_at, user_id, provider, subject, email)
+VALUES (
+    ?,
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    ?,
+    ?,
+    ?,
+    ?
+) RETURNING *;
+
+
+-- name: GetUserIdentity :one
+SELECT * FROM user_identities
+WHERE provider = ? AND subject = ?;
+
+
+-- name: GetUserIdentitiesForUser :many
+SELECT * FROM user_identities
+WHERE user_id = ?
+ORDER BY created_at;
@@ -0,0 +1,80 @@
+-- name: CreateUser :one
+INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+VALUES (
+    ?,
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    ?,
+    ?
+) RETURNING *;
+
+
+-- name: CheckUserExists :one
+SELECT EXISTS(
+    SELECT 1
+    FROM users
+    WHERE users.id = ?
+) AS "exists";
+
+
+-- name: DeleteUsers :exec
+DELETE FROM users;
+
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE users.email = ?;
+
+
+-- name: UpdateUser :one
+UPDATE users
+SET email = sqlc.arg(email), hashed_password = sqlc.arg(hashed_password), updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+
+-- name: UpgradeToPremium :exec
+UPDATE users
+SET is_premium = true
+WHERE id = ?;
+
+
+-- name: GetUserAccess :one
+SELECT is_admin, deletion_scheduled_at, status, suspended_until FROM users
+WHERE id = ?;
+
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE users.id = ?;
+
+
+-- name: UpdateUserPasswordHash :exec
+UPDATE users
+SET hashed_password = sqlc.arg(hashed_password)
+WHERE id = sqlc.arg(id);
+
+
+-- name: ScheduleUserDeletion :exec
+UPDATE users
+SET deletion_scheduled_at = sqlc.arg(deletion_scheduled_at), updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE id = sqlc.arg(id);
+
+
+-- name: CancelUserDeletion :exec
+UPDATE users
+SET deletion_scheduled_at = NULL, updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE id = ? AND deletion_scheduled_at IS NOT NULL;
+
+
+-- name: DeleteUsersScheduledForDeletion :many
+DELETE FROM users
+WHERE deletion_scheduled_at <= sqlc.arg(now)
+RETURNING id;
+
+
+-- name: SetUserStatus :one
+UPDATE users
+SET status = sqlc.arg(status), status_reason = sqlc.arg(status_reason), suspended_until = sqlc.narg(suspended_until), updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE id = sqlc.arg(id)
+RETURNING id, updated_at, status, status_reason, suspended_until;
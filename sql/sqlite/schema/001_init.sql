@@ -0,0 +1,219 @@
+-- SQLite schema matches the Postgres one after its 023 migration. Columns are in the same order, so sqlc generates
+-- the same models. UUIDs are generated by the server, timestamps are UTC text with milliseconds, arrays are JSON.
+
+CREATE TABLE users(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    email TEXT UNIQUE NOT NULL,
+    hashed_password TEXT DEFAULT 'unset' NOT NULL,
+    is_premium BOOLEAN DEFAULT false,
+    is_admin BOOLEAN DEFAULT false NOT NULL,
+    deletion_scheduled_at TIMESTAMP,
+    suspended_until TIMESTAMP,
+    status TEXT NOT NULL DEFAULT 'active',
+    status_reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_users_deletion_scheduled_at ON users(deletion_scheduled_at) WHERE deletion_scheduled_at IS NOT NULL;
+CREATE INDEX idx_users_shadow_banned ON users(id) WHERE status = 'shadow_banned';
+
+CREATE TABLE messages(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    body TEXT NOT NULL,
+    user_id UUID NOT NULL,
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE refresh_tokens(
+    token TEXT PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    user_id UUID NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE login_attempts(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    email TEXT NOT NULL,
+    ip_address TEXT NOT NULL,
+    user_id UUID,
+    succeeded BOOLEAN NOT NULL,
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_login_attempts_email ON login_attempts(email, created_at);
+CREATE INDEX idx_login_attempts_ip ON login_attempts(ip_address, created_at);
+
+CREATE TABLE user_identities(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    user_id UUID NOT NULL,
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT NOT NULL,
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT uq_provider_subject UNIQUE(provider, subject),
+    CONSTRAINT uq_user_provider UNIQUE(user_id, provider)
+);
+
+CREATE TABLE oidc_auth_requests(
+    state TEXT PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    provider TEXT NOT NULL,
+    code_verifier TEXT NOT NULL,
+    nonce TEXT NOT NULL,
+    link_user_id UUID,
+    expires_at TIMESTAMP NOT NULL,
+    CONSTRAINT fk_user FOREIGN KEY(link_user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE oauth_clients(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    name TEXT NOT NULL,
+    secret_hash TEXT,
+    redirect_uris TEXT_LIST NOT NULL,
+    scopes TEXT_LIST NOT NULL,
+    user_id UUID NOT NULL,
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE oauth_authorization_codes(
+    code_hash TEXT PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    client_id TEXT NOT NULL,
+    user_id UUID NOT NULL,
+    redirect_uri TEXT NOT NULL,
+    scopes TEXT_LIST NOT NULL,
+    code_challenge TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    CONSTRAINT fk_client FOREIGN KEY(client_id) REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE personal_access_tokens(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    user_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    scopes TEXT_LIST NOT NULL,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE payment_events(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    user_id UUID NOT NULL,
+    event TEXT NOT NULL,
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE data_exports(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    user_id UUID NOT NULL,
+    status TEXT NOT NULL,
+    storage_key TEXT,
+    error TEXT,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_data_exports_status ON data_exports(status, created_at);
+
+CREATE TABLE blocks(
+    blocker_id UUID NOT NULL,
+    blocked_id UUID NOT NULL,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CONSTRAINT fk_blocker FOREIGN KEY(blocker_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_blocked FOREIGN KEY(blocked_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT chk_not_self CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX idx_blocks_blocked ON blocks(blocked_id);
+
+CREATE TABLE mutes(
+    muter_id UUID NOT NULL,
+    muted_id UUID NOT NULL,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    PRIMARY KEY (muter_id, muted_id),
+    CONSTRAINT fk_muter FOREIGN KEY(muter_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_muted FOREIGN KEY(muted_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT chk_not_self CHECK (muter_id <> muted_id)
+);
+
+CREATE TABLE reports(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    reporter_id UUID,
+    target_type TEXT NOT NULL,
+    reported_user_id UUID NOT NULL,
+    message_id UUID,
+    message_body TEXT,
+    reason TEXT NOT NULL,
+    details TEXT NOT NULL,
+    status TEXT NOT NULL,
+    claimed_by UUID,
+    claimed_at TIMESTAMP,
+    resolution TEXT,
+    resolved_at TIMESTAMP,
+    CONSTRAINT fk_reporter FOREIGN KEY(reporter_id) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_reported_user FOREIGN KEY(reported_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_message FOREIGN KEY(message_id) REFERENCES messages(id) ON DELETE SET NULL,
+    CONSTRAINT fk_claimed_by FOREIGN KEY(claimed_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_reports_status ON reports(status, created_at);
+
+CREATE TABLE moderation_actions(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    moderator_id UUID,
+    report_id UUID,
+    action TEXT NOT NULL,
+    target_user_id UUID,
+    message_id UUID,
+    note TEXT NOT NULL,
+    CONSTRAINT fk_moderator FOREIGN KEY(moderator_id) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_report FOREIGN KEY(report_id) REFERENCES reports(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_moderation_actions_report ON moderation_actions(report_id, created_at);
+
+CREATE TABLE content_filter_rules(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL,
+    term TEXT UNIQUE NOT NULL,
+    action TEXT NOT NULL
+);
+
+-- ids of the default rules are fixed, there is no UUID generator in SQLite
+INSERT INTO content_filter_rules (id, term, action) VALUES
+    ('4d0f6b8e-2f3c-4a8e-9a51-0c6f2d9b7e01', 'kerfuffle', 'mask'),
+    ('4d0f6b8e-2f3c-4a8e-9a51-0c6f2d9b7e02', 'sharbert', 'mask'),
+    ('4d0f6b8e-2f3c-4a8e-9a51-0c6f2d9b7e03', 'fornax', 'mask');
+
+CREATE TABLE rate_limit_buckets(
+    key TEXT PRIMARY KEY,
+    tokens REAL NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
@@ -0,0 +1,8 @@
+-- matches the Postgres 024 migration
+CREATE TABLE login_throttles(
+    key TEXT PRIMARY KEY,
+    failed_count INTEGER NOT NULL,
+    last_attempt_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) NOT NULL
+);
+
+CREATE INDEX idx_login_throttles_last_attempt_at ON login_throttles(last_attempt_at);
@@ -0,0 +1,7 @@
+// Package schema embeds SQLite migrations, the server applies them on startup
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var Migrations embed.FS